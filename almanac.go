@@ -0,0 +1,194 @@
+package rightround
+
+import (
+	"math"
+	"sort"
+)
+
+// Типы астрономических явлений.
+const (
+	EventCodeNewMoon                = 5  // новолуние
+	EventCodeFirstQuarter           = 6  // первая четверть
+	EventCodeFullMoon               = 7  // полнолуние
+	EventCodeLastQuarter            = 8  // последняя четверть
+	EventCodeConjunction            = 9  // соединение с Солнцем (по эклиптической долготе)
+	EventCodeOpposition             = 10 // противостояние
+	EventCodeGreatestElongationEast = 11 // наибольшая восточная элонгация
+	EventCodeGreatestElongationWest = 12 // наибольшая западная элонгация
+)
+
+// Шаги поиска явлений, в сутках. Разность долгот Луны и Солнца меняется на π/2 не быстрее чем за 6 суток,
+// соединения и элонгации планет разделены не менее чем несколькими неделями.
+const (
+	moonPhaseSearchStep   = 1.0
+	planetEventSearchStep = 2.0
+)
+
+// maxLongitudeResidual наибольшее допустимое значение разности долгот в найденном корне (в радианах):
+// корни с большим значением соответствуют скачку разности на 2π.
+const maxLongitudeResidual = 1e-3
+
+// CalculateApparentEclipticCoords вычисляет геоцентрические видимые эклиптическую долготу (от 0 до 2π)
+// и широту (в радианах) относительно истинного равноденствия и эклиптики даты, а также расстояние до объекта.
+func (e *Ephemeris) CalculateApparentEclipticCoords(object int, date1, date2 float64) (float64, float64, float64, error) {
+	coords, err := e.CalculateGeocentricApparentCoords(object, date1, date2)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	centuries := ((date1 - julianDate2000) + date2) / daysInCentury
+	dPsi, dEps := calcNutation(centuries)
+	matrix, eps := precessionNutation(centuries, dPsi, dEps)
+	ecliptic := rotationX(eps).Mul(matrix).Apply(coords)
+	latitude, longitude, distance := CartesianToPlanetocentric(ecliptic)
+	if longitude < 0 {
+		longitude += 2 * math.Pi
+	}
+	return longitude, latitude, distance, nil
+}
+
+// normalizeAngle приводит угол к диапазону (-π, π].
+func normalizeAngle(angle float64) float64 {
+	angle = math.Mod(angle, 2*math.Pi)
+	if angle > math.Pi {
+		angle -= 2 * math.Pi
+	} else if angle <= -math.Pi {
+		angle += 2 * math.Pi
+	}
+	return angle
+}
+
+// longitudeDifference возвращает функцию - разность видимых эклиптических долгот двух объектов
+// за вычетом angle, приведённую к диапазону (-π, π].
+func (e *Ephemeris) longitudeDifference(object1, object2 int, angle float64) func(date float64) (float64, error) {
+	return func(date float64) (float64, error) {
+		longitude1, _, _, err := e.CalculateApparentEclipticCoords(object1, date, 0)
+		if err != nil {
+			return 0, err
+		}
+		longitude2, _, _, err := e.CalculateApparentEclipticCoords(object2, date, 0)
+		if err != nil {
+			return 0, err
+		}
+		return normalizeAngle(longitude1 - longitude2 - angle), nil
+	}
+}
+
+// findLongitudeEvents находит моменты, когда разность видимых долгот двух объектов равна angle,
+// отбрасывая скачки разности на 2π.
+func (e *Ephemeris) findLongitudeEvents(object1, object2 int, angle, start, end, step float64) ([]root, error) {
+	f := e.longitudeDifference(object1, object2, angle)
+	roots, err := findRoots(f, start, end, constantStep(step), defaultSearchTolerance)
+	if err != nil {
+		return nil, err
+	}
+	var result []root
+	for _, r := range roots {
+		value, err := f(r.date)
+		if err != nil {
+			return nil, err
+		}
+		if math.Abs(value) < maxLongitudeResidual {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+// toTDBInterval переводит границы интервала из заданной шкалы времени в TDB.
+func (e *Ephemeris) toTDBInterval(start, end float64, timeScale int) (float64, float64, error) {
+	start, err := e.ConvertTimeScale(start, timeScale, TimeScaleCodeTDB)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err = e.ConvertTimeScale(end, timeScale, TimeScaleCodeTDB)
+	return start, end, err
+}
+
+// convertEvents переводит моменты событий из TDB в заданную шкалу времени и упорядочивает их.
+func (e *Ephemeris) convertEvents(events []Event, timeScale int) ([]Event, error) {
+	for i := range events {
+		date, err := e.ConvertTimeScale(events[i].JulianDate, TimeScaleCodeTDB, timeScale)
+		if err != nil {
+			return nil, err
+		}
+		events[i].JulianDate = date
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].JulianDate < events[j].JulianDate
+	})
+	return events, nil
+}
+
+// FindMoonPhases находит моменты главных фаз Луны (по разности видимых эклиптических долгот Луны и Солнца)
+// в интервале [start, end]. Границы интервала и моменты событий задаются в шкале времени timeScale.
+func (e *Ephemeris) FindMoonPhases(start, end float64, timeScale int) ([]Event, error) {
+	start, end, err := e.toTDBInterval(start, end, timeScale)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	for i, code := range []int{EventCodeNewMoon, EventCodeFirstQuarter, EventCodeFullMoon, EventCodeLastQuarter} {
+		roots, err := e.findLongitudeEvents(EphemerisMoon, EphemerisSun, float64(i)*math.Pi/2, start, end, moonPhaseSearchStep)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range roots {
+			// разность долгот Луны и Солнца всегда возрастает
+			if r.increasing {
+				events = append(events, Event{Code: code, JulianDate: r.date})
+			}
+		}
+	}
+	return e.convertEvents(events, timeScale)
+}
+
+// FindPlanetEvents находит соединения планеты с Солнцем, противостояния (для внешних планет)
+// и наибольшие элонгации (для Меркурия и Венеры) в интервале [start, end].
+// Границы интервала и моменты событий задаются в шкале времени timeScale.
+func (e *Ephemeris) FindPlanetEvents(object int, start, end float64, timeScale int) ([]Event, error) {
+	start, end, err := e.toTDBInterval(start, end, timeScale)
+	if err != nil {
+		return nil, err
+	}
+	inner := object == EphemerisMercury || object == EphemerisVenus ||
+		object == EphemerisMercuryBody || object == EphemerisVenusBody
+
+	var events []Event
+	roots, err := e.findLongitudeEvents(object, EphemerisSun, 0, start, end, planetEventSearchStep)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range roots {
+		events = append(events, Event{Code: EventCodeConjunction, JulianDate: r.date})
+	}
+
+	if !inner {
+		roots, err = e.findLongitudeEvents(object, EphemerisSun, math.Pi, start, end, planetEventSearchStep)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range roots {
+			events = append(events, Event{Code: EventCodeOpposition, JulianDate: r.date})
+		}
+		return e.convertEvents(events, timeScale)
+	}
+
+	elongation := e.AngularSeparationQuantity(object, EphemerisSun, EphemerisEarth)
+	maxima, err := Search(elongation, SearchCodeLocalMax, 0, Window{{Start: start, End: end}}, SearchOptions{Step: planetEventSearchStep})
+	if err != nil {
+		return nil, err
+	}
+	difference := e.longitudeDifference(object, EphemerisSun, 0)
+	for _, interval := range maxima {
+		value, err := difference(interval.Start)
+		if err != nil {
+			return nil, err
+		}
+		code := EventCodeGreatestElongationWest
+		if value > 0 {
+			code = EventCodeGreatestElongationEast
+		}
+		events = append(events, Event{Code: code, JulianDate: interval.Start})
+	}
+	return e.convertEvents(events, timeScale)
+}
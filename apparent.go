@@ -0,0 +1,76 @@
+package rightround
+
+// speedOfLight скорость света в вакууме, км/с.
+const speedOfLight = 299792.458
+
+// lightTimeIterations количество итераций при вычислении поправки за световое время.
+const lightTimeIterations = 3
+
+// speedOfLightPerDay возвращает скорость света в установленных единицах расстояния за сутки.
+func (e *Ephemeris) speedOfLightPerDay() float64 {
+	return speedOfLight * secondsInDay * e.distanceScalingFactor
+}
+
+// calculateBarycentricObserver вычисляет барицентрические координаты и скорость наблюдателя на поверхности Земли.
+func (e *Ephemeris) calculateBarycentricObserver(observer Observer, date1, date2 float64) (Coords, Coords, error) {
+	coords, velocity, err := e.CalculateRectangularCoordsAndScaleVelocity(EphemerisEarth, EphemerisSunSystem, date1, date2, true)
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+	observerCoords, observerVelocity, err := e.CalculateObserverCoords(observer, date1, date2, true)
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+	return Coords{X: coords.X + observerCoords.X, Y: coords.Y + observerCoords.Y, Z: coords.Z + observerCoords.Z},
+		Coords{X: velocity.X + observerVelocity.X, Y: velocity.Y + observerVelocity.Y, Z: velocity.Z + observerVelocity.Z}, nil
+}
+
+// calculateApparent вычисляет видимое положение объекта для наблюдателя с заданными барицентрическими
+// координатами и скоростью: учитываются световое время и аберрация (в первом порядке по v/c).
+func (e *Ephemeris) calculateApparent(object int, observerCoords, observerVelocity Coords, date1, date2 float64) (Coords, error) {
+	var coords Coords
+	lightTime := 0.0
+	for i := 0; i < lightTimeIterations; i++ {
+		target, _, err := e.CalculateRectangularCoords(object, EphemerisSunSystem, date1, date2-lightTime, false)
+		if err != nil {
+			return Coords{}, err
+		}
+		coords = Coords{X: target.X - observerCoords.X, Y: target.Y - observerCoords.Y, Z: target.Z - observerCoords.Z}
+		lightTime = coords.length() / e.speedOfLightPerDay()
+	}
+
+	// скорость наблюдателя в долях скорости света
+	speed := e.speedOfLightPerDay() / e.timeScalingFactor
+	beta := Coords{X: observerVelocity.X / speed, Y: observerVelocity.Y / speed, Z: observerVelocity.Z / speed}
+	distance := coords.length()
+	direction := Coords{X: coords.X / distance, Y: coords.Y / distance, Z: coords.Z / distance}
+	projection := direction.X*beta.X + direction.Y*beta.Y + direction.Z*beta.Z
+	apparent := Coords{
+		X: direction.X + beta.X - projection*direction.X,
+		Y: direction.Y + beta.Y - projection*direction.Y,
+		Z: direction.Z + beta.Z - projection*direction.Z,
+	}
+	scale := distance / apparent.length()
+	return Coords{X: apparent.X * scale, Y: apparent.Y * scale, Z: apparent.Z * scale}, nil
+}
+
+// CalculateApparentCoords вычисляет видимое положение объекта относительно наблюдателя в ICRF
+// с учётом светового времени и аберрации. Расстояние соответствует геометрическому
+// на момент излучения света.
+func (e *Ephemeris) CalculateApparentCoords(object int, observer Observer, date1, date2 float64) (Coords, error) {
+	observerCoords, observerVelocity, err := e.calculateBarycentricObserver(observer, date1, date2)
+	if err != nil {
+		return Coords{}, err
+	}
+	return e.calculateApparent(object, observerCoords, observerVelocity, date1, date2)
+}
+
+// CalculateGeocentricApparentCoords вычисляет видимое положение объекта относительно центра Земли в ICRF
+// с учётом светового времени и аберрации.
+func (e *Ephemeris) CalculateGeocentricApparentCoords(object int, date1, date2 float64) (Coords, error) {
+	earthCoords, earthVelocity, err := e.CalculateRectangularCoordsAndScaleVelocity(EphemerisEarth, EphemerisSunSystem, date1, date2, true)
+	if err != nil {
+		return Coords{}, err
+	}
+	return e.calculateApparent(object, earthCoords, earthVelocity, date1, date2)
+}
@@ -0,0 +1,122 @@
+package rightround
+
+import (
+	"fmt"
+	"math"
+)
+
+// TimeGrid набор дат TDB для пакетных расчётов.
+// Если заданы Dates, используются перечисленные даты, иначе - равномерная сетка
+// от Start до End включительно с шагом Step (в сутках).
+type TimeGrid struct {
+	Start float64
+	End   float64
+	Step  float64
+	Dates []float64
+}
+
+// Len возвращает количество дат сетки.
+func (g TimeGrid) Len() (int, error) {
+	if len(g.Dates) > 0 {
+		return len(g.Dates), nil
+	}
+	if !(g.Step > 0) || math.IsInf(g.Step, 0) {
+		return 0, fmt.Errorf("%w: step must be positive", ErrInvalidGrid)
+	}
+	if !(g.End >= g.Start) {
+		return 0, fmt.Errorf("%w: end date is before start date", ErrInvalidGrid)
+	}
+	count := math.Floor((g.End-g.Start)/g.Step+1e-9) + 1
+	if count > math.MaxInt32 {
+		return 0, fmt.Errorf("%w: too many dates", ErrInvalidGrid)
+	}
+	return int(count), nil
+}
+
+// date возвращает i-ю дату сетки, разделённую на две части для сохранения точности.
+func (g TimeGrid) date(i int) (float64, float64) {
+	if len(g.Dates) > 0 {
+		return g.Dates[i], 0
+	}
+	return g.Start, float64(i) * g.Step
+}
+
+// StateSample положение и скорость объекта на дату сетки.
+type StateSample struct {
+	Date     float64
+	Coords   Coords
+	Velocity Coords
+}
+
+// AnglesSample эйлеровы углы и скорости их изменения на дату сетки.
+type AnglesSample struct {
+	Date   float64
+	Angles Coords
+	Rates  Coords
+}
+
+// TimeDiffSample разность шкал времени на дату сетки.
+type TimeDiffSample struct {
+	Date float64
+	Diff float64
+}
+
+// EvaluateStates вычисляет положения и скорости объекта относительно центра в установленных единицах
+// на датах сетки и передаёт их handle в порядке дат. Расчёт прекращается при первой ошибке.
+func (e *Ephemeris) EvaluateStates(object, basis int, grid TimeGrid, handle func(StateSample) error) error {
+	count, err := grid.Len()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < count; i++ {
+		date1, date2 := grid.date(i)
+		coords, velocity, err := e.CalculateRectangularCoordsAndScaleVelocity(object, basis, date1, date2, true)
+		if err != nil {
+			return err
+		}
+		if err := handle(StateSample{Date: date1 + date2, Coords: coords, Velocity: velocity}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EvaluateEulerAngles вычисляет эйлеровы углы системы координат PCK на датах сетки
+// и передаёт их handle в порядке дат. Расчёт прекращается при первой ошибке.
+func (e *Ephemeris) EvaluateEulerAngles(frame int, grid TimeGrid, handle func(AnglesSample) error) error {
+	count, err := grid.Len()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < count; i++ {
+		date1, date2 := grid.date(i)
+		angles, rates, err := e.CalculateEulerAngles(frame, date1, date2, true)
+		if err != nil {
+			return err
+		}
+		if err := handle(AnglesSample{Date: date1 + date2, Angles: angles, Rates: rates}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EvaluateTimeDiff вычисляет разность шкал времени (например, EphemerisCodeMinusTDB) на датах сетки
+// и передаёт её handle в порядке дат. Расчёт прекращается при первой ошибке.
+func (e *Ephemeris) EvaluateTimeDiff(code int, grid TimeGrid, handle func(TimeDiffSample) error) error {
+	count, err := grid.Len()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < count; i++ {
+		date1, date2 := grid.date(i)
+		diff, err := e.CalculateTimeDiff(code, date1, date2)
+		if err != nil {
+			return err
+		}
+		if err := handle(TimeDiffSample{Date: date1 + date2, Diff: diff}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
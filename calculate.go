@@ -1,7 +1,6 @@
 package rightround
 
 import (
-	"errors"
 	"fmt"
 	"math"
 )
@@ -23,17 +22,25 @@ func (e *Ephemeris) CalculateRectangularCoordsAndScaleVelocity(object, basis int
 
 // CalculateEulerAngles вычисляет эйлеровы углы и скорости их изменения на заданную дату.
 func (e *Ephemeris) CalculateEulerAngles(frame int, date1, date2 float64, withRates bool) (Coords, Coords, error) {
-	// поиск нужного сегмента
-	var theory, singleTheory *Theory
+	theory, err := e.findFrameTheory(frame, date1, date2)
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+	return e.calculateEulerAnglesByTheory(theory, date1, date2, withRates)
+}
+
+// findFrameTheory ищет PCK-теорию для системы координат на заданную дату.
+func (e *Ephemeris) findFrameTheory(frame int, date1, date2 float64) (*Theory, error) {
+	var singleTheory *Theory
 	isSingle := true
-	for _, t := range e.theories {
+	for i := len(e.theories) - 1; i >= 0; i-- {
+		t := e.theories[i]
 		// проверка, что заданная дата - дата начала фрейма в диапазоне [0, длина сегмента]
 		if !t.isDateInRange(date1, date2) {
 			continue
 		}
 		if t.object == frame {
-			theory = t
-			break
+			return t, nil
 		}
 		// проверка, что это одиночная PCK-теория
 		if frame == 0 && t.fileType == FormatPCK {
@@ -45,13 +52,14 @@ func (e *Ephemeris) CalculateEulerAngles(frame int, date1, date2 float64, withRa
 		}
 	}
 
-	if theory == nil {
-		if singleTheory == nil {
-			return Coords{}, Coords{}, fmt.Errorf("theory for frame %d not found", frame)
-		}
-		theory = singleTheory
+	if singleTheory == nil {
+		return nil, fmt.Errorf("theory for frame %d %w", frame, ErrNotFound)
 	}
+	return singleTheory, nil
+}
 
+// calculateEulerAnglesByTheory вычисляет эйлеровы углы и скорости их изменения для заданной теории.
+func (e *Ephemeris) calculateEulerAnglesByTheory(theory *Theory, date1, date2 float64, withRates bool) (Coords, Coords, error) {
 	angles, rates, err := e.calculateByTheory(theory, date1, date2, false, withRates)
 	if err != nil {
 		return Coords{}, Coords{}, err
@@ -68,15 +76,11 @@ func (e *Ephemeris) CalculateEulerAngles(frame int, date1, date2 float64, withRa
 
 // CalculateTimeDiff вычисляет разности шкал времени на заданную дату.
 func (e *Ephemeris) CalculateTimeDiff(code int, date1, date2 float64) (float64, error) {
-	var theory *Theory
-	for _, t := range e.theories {
-		if t.object == code && t.isDateInRange(date1, date2) {
-			theory = t
-			break
-		}
-	}
+	theory := e.findTheory(func(t *Theory) bool {
+		return t.object == code && t.isDateInRange(date1, date2)
+	})
 	if theory == nil {
-		return 0, fmt.Errorf("theory for time difference %d not found", code)
+		return 0, fmt.Errorf("theory for time difference %d %w", code, ErrNotFound)
 	}
 	coords, _, err := e.calculateByTheory(theory, date1, date2, false, false)
 	if err != nil {
@@ -151,34 +155,55 @@ func (e *Ephemeris) CalculateRectangularCoords(object, basis int, date1, date2 f
 		}
 		return coords, velocity, nil
 	}
-	var theory *Theory
-	for _, t := range e.theories {
-		if t.object == object && t.basis == basis && t.isDateInRange(date1, date2) {
-			theory = t
-			break
+	theory := e.findTheory(func(t *Theory) bool {
+		return t.object == object && t.basis == basis && t.isDateInRange(date1, date2)
+	})
+	if theory == nil && basis == EphemerisSunSystem {
+		// объект задан относительно другого центра (например, центр Марса относительно барицентра системы Марса)
+		if t := e.findTheory(func(t *Theory) bool {
+			return t.object == object && t.basis != basis && t.isDateInRange(date1, date2)
+		}); t != nil {
+			return e.combineTwoEphemeris(object, t.basis, t.basis, EphemerisSunSystem, 1, 1, date1, date2, withVelocity)
 		}
 	}
 	if theory == nil {
-		return Coords{}, Coords{}, errors.New("theory for object and reference not found")
+		return Coords{}, Coords{}, fmt.Errorf("theory for object %d and reference %d %w", object, basis, ErrNotFound)
 	}
 
 	return e.calculateByTheory(theory, date1, date2, true, withVelocity)
 }
 
+// findTheory возвращает теорию, удовлетворяющую условию.
+// Как и в SPICE, приоритет имеют сегменты, загруженные последними.
+func (e *Ephemeris) findTheory(match func(t *Theory) bool) *Theory {
+	for i := len(e.theories) - 1; i >= 0; i-- {
+		if match(e.theories[i]) {
+			return e.theories[i]
+		}
+	}
+	return nil
+}
+
 // calculateByTheory вычисляет прямоугольные координаты для заданной теории и даты.
 func (e *Ephemeris) calculateByTheory(theory *Theory, date1, date2 float64, scaleDistance, withVelocity bool) (Coords, Coords, error) {
+	if theory.representation == representationDiscreteStates {
+		return e.calculateByDiscreteStates(theory, date1, date2, scaleDistance, withVelocity)
+	}
+	if theory.representation == representationLagrange || theory.representation == representationHermite {
+		return e.calculateByInterpolation(theory, date1, date2, scaleDistance, withVelocity)
+	}
 	interval, posInInterval := theory.findInterval(date1, date2)
 
 	coefficients := theory.cachedCoefficients
 	toRead := theory.cachedInterval != interval
 
 	var coords, velocity Coords
-	if theory.representation == representationPositionOnly {
+	if theory.representation == representationPositionOnly || theory.representation == representationPositionVelocity {
 		polynomials := calcChebyshevPolynomials(theory.polynomialDegree+1, posInInterval)
 
 		if toRead {
 			var err error
-			if coefficients, err = theory.segment.readRange(theory.rSize*interval+2, theory.rSize-2); err != nil {
+			if coefficients, err = theory.read(theory.rSize*interval+2, theory.rSize-2); err != nil {
 				return coords, velocity, err
 			}
 		}
@@ -193,11 +218,29 @@ func (e *Ephemeris) calculateByTheory(theory *Theory, date1, date2 float64, scal
 			coords.Y *= e.distanceScalingFactor
 			coords.Z *= e.distanceScalingFactor
 		}
-		if withVelocity {
+		if withVelocity && theory.representation == representationPositionVelocity {
+			// скорость задана собственными коэффициентами в км/с
+			n := theory.polynomialDegree + 1
 			for i := theory.polynomialDegree; i >= 0; i-- {
-				velocity.X += polynomials[i] * coefficients[i]
-				velocity.Y += polynomials[i] * coefficients[i+theory.polynomialDegree+1]
-				velocity.Z += polynomials[i] * coefficients[i+(theory.polynomialDegree+1)*2]
+				velocity.X += polynomials[i] * coefficients[i+n*3]
+				velocity.Y += polynomials[i] * coefficients[i+n*4]
+				velocity.Z += polynomials[i] * coefficients[i+n*5]
+			}
+			velocity.X *= secondsInDay
+			velocity.Y *= secondsInDay
+			velocity.Z *= secondsInDay
+
+			if scaleDistance {
+				velocity.X *= e.distanceScalingFactor
+				velocity.Y *= e.distanceScalingFactor
+				velocity.Z *= e.distanceScalingFactor
+			}
+		} else if withVelocity {
+			derivatives := calcChebyshevDerivatives(theory.polynomialDegree+1, posInInterval, polynomials)
+			for i := theory.polynomialDegree; i >= 0; i-- {
+				velocity.X += derivatives[i] * coefficients[i]
+				velocity.Y += derivatives[i] * coefficients[i+theory.polynomialDegree+1]
+				velocity.Z += derivatives[i] * coefficients[i+(theory.polynomialDegree+1)*2]
 			}
 			velocity.X /= 0.5 * theory.intervalLen
 			velocity.Y /= 0.5 * theory.intervalLen
@@ -222,7 +265,7 @@ func (e *Ephemeris) calculateByTheory(theory *Theory, date1, date2 float64, scal
 
 		if toRead {
 			var err error
-			if coefficients, err = theory.segment.readRange(theory.rSize*interval, theory.rSize); err != nil {
+			if coefficients, err = theory.read(theory.rSize*interval, theory.rSize); err != nil {
 				return coords, velocity, err
 			}
 		}
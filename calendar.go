@@ -0,0 +1,69 @@
+package rightround
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// calendarToJulianDate возвращает юлианскую дату для даты григорианского календаря.
+// dayFraction - доля суток, прошедшая с полуночи.
+func calendarToJulianDate(year, month, day int, dayFraction float64) float64 {
+	a := (14 - month) / 12
+	y := year + 4800 - a
+	m := month + 12*a - 3
+	// целочисленное деление с округлением вниз для отрицательных годов
+	days := day + (153*m+2)/5 + 365*y + floorDiv(y, 4) - floorDiv(y, 100) + floorDiv(y, 400) - 32045
+	return float64(days) - 0.5 + dayFraction
+}
+
+// julianDateToCalendar возвращает дату григорианского календаря и долю суток для юлианской даты.
+func julianDateToCalendar(julianDate float64) (int, int, int, float64) {
+	shifted := julianDate + 0.5
+	days := math.Floor(shifted)
+	fraction := shifted - days
+	a := int(days) + 32044
+	b := floorDiv(4*a+3, 146097)
+	c := a - 146097*b/4
+	d := floorDiv(4*c+3, 1461)
+	e := c - 1461*d/4
+	m := (5*e + 2) / 153
+	day := e - (153*m+2)/5 + 1
+	month := m + 3 - 12*(m/10)
+	year := 100*b + d - 4800 + m/10
+	return year, month, day, fraction
+}
+
+// floorDiv выполняет целочисленное деление с округлением вниз.
+func floorDiv(a, b int) int {
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		return a/b - 1
+	}
+	return a / b
+}
+
+// ParseJulianDate разбирает дату, заданную юлианской датой (числом) либо календарной датой
+// григорианского календаря в форматах текстовых ядер ("2021-06-30", "2021-06-30T12:00:00", "2021 JUN 30 12:00").
+// Шкала времени не преобразуется.
+func ParseJulianDate(text string) (float64, error) {
+	text = strings.TrimSpace(text)
+	if julianDate, err := strconv.ParseFloat(text, 64); err == nil {
+		return julianDate, nil
+	}
+	seconds, err := parseKernelDate(text)
+	if err != nil {
+		return 0, err
+	}
+	return julianDate2000 + seconds/secondsInDay, nil
+}
+
+// FormatJulianDate возвращает календарную дату в формате ISO 8601 с миллисекундами.
+func FormatJulianDate(julianDate float64) string {
+	// округление до миллисекунд до разложения, чтобы не получить 60 секунд
+	julianDate = math.Round(julianDate*secondsInDay*1000)/(secondsInDay*1000) + 1e-10
+	year, month, day, fraction := julianDateToCalendar(julianDate)
+	milliseconds := int(fraction * secondsInDay * 1000)
+	return fmt.Sprintf("%04d-%02d-%02dT%02d:%02d:%02d.%03d", year, month, day,
+		milliseconds/3600000, milliseconds/60000%60, milliseconds/1000%60, milliseconds%1000)
+}
@@ -0,0 +1,389 @@
+// Команда rightround выполняет расчёты по файлам эфемерид без написания кода.
+//
+// Использование:
+//
+//	rightround list [-format table|csv|json] file ...
+//	rightround coverage -object 301 [-basis 399] file ...
+//	rightround state -object 301 -basis 399 -start 2021-06-30 [-end 2021-07-30] [-step 1] [-units km|au] [-time s|day] [-frame icrf|eclipj2000|itrf|<код PCK>] file ...
+//	rightround angles -frame 31008 -start 2021-06-30 [-end ...] [-step ...] file ...
+//	rightround timediff -start 2021-06-30 [-end ...] [-step ...] file ...
+//
+// Файлы загружаются в указанном порядке (SPK, PCK, текстовые ядра и мета-ядра).
+// Даты задаются юлианскими датами или календарными датами; шкала времени выбирается флагом -scale (по умолчанию TDB).
+// Шаг сетки дат задаётся в сутках.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dvoeglazyi/rightround"
+)
+
+// command подкоманда: настройка флагов и выполнение над загруженными эфемеридами.
+type command struct {
+	description string
+	run         func(args []string) (*table, string, error)
+}
+
+var commands = map[string]command{
+	"list":     {"список сегментов загруженных файлов", runList},
+	"coverage": {"интервалы дат, на которых определён объект", runCoverage},
+	"state":    {"координаты и скорости объекта относительно центра", runState},
+	"angles":   {"эйлеровы углы системы координат PCK и скорости их изменения", runAngles},
+	"timediff": {"разность шкал TT - TDB", runTimeDiff},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	result, format, err := cmd.run(os.Args[2:])
+	if err == nil {
+		err = result.write(os.Stdout, format)
+	}
+	if err == flag.ErrHelp {
+		os.Exit(2)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "rightround:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rightround <command> [flags] file ...")
+	for _, name := range []string{"list", "coverage", "state", "angles", "timediff"} {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].description)
+	}
+}
+
+// options общие флаги подкоманд.
+type options struct {
+	flags  *flag.FlagSet
+	format *string
+	scale  *string
+	start  *string
+	end    *string
+	step   *float64
+}
+
+// newOptions создаёт набор флагов подкоманды; withGrid добавляет флаги сетки дат.
+func newOptions(name string, withGrid bool) *options {
+	o := &options{flags: flag.NewFlagSet(name, flag.ContinueOnError)}
+	o.format = o.flags.String("format", formatTable, "формат вывода: table, csv или json")
+	if withGrid {
+		o.scale = o.flags.String("scale", "tdb", "шкала времени дат: tdb, tt, utc или ut1")
+		o.start = o.flags.String("start", "", "начальная дата (юлианская или календарная)")
+		o.end = o.flags.String("end", "", "конечная дата (по умолчанию - только начальная)")
+		o.step = o.flags.Float64("step", 1, "шаг сетки дат в сутках")
+	}
+	return o
+}
+
+// parse разбирает аргументы и загружает файлы эфемерид.
+func (o *options) parse(args []string) (*rightround.Ephemeris, error) {
+	if err := o.flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if o.flags.NArg() == 0 {
+		return nil, fmt.Errorf("at least one input file is required")
+	}
+	ephemeris := rightround.NewEphemeris()
+	for _, path := range o.flags.Args() {
+		if err := ephemeris.LoadFile(path); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return ephemeris, nil
+}
+
+// timeScale возвращает код шкалы времени, заданной флагом -scale.
+func (o *options) timeScale() (int, error) {
+	switch strings.ToLower(*o.scale) {
+	case "tdb":
+		return rightround.TimeScaleCodeTDB, nil
+	case "tt":
+		return rightround.TimeScaleCodeTT, nil
+	case "utc":
+		return rightround.TimeScaleCodeUTC, nil
+	case "ut1":
+		return rightround.TimeScaleCodeUT1, nil
+	default:
+		return 0, fmt.Errorf("unknown time scale %q", *o.scale)
+	}
+}
+
+// gridDate дата сетки: исходная дата в выбранной шкале и соответствующая ей дата TDB,
+// разделённая на две части для сохранения точности.
+type gridDate struct {
+	date         float64
+	date1, date2 float64
+}
+
+// grid возвращает сетку дат, заданную флагами -start, -end и -step.
+func (o *options) grid(ephemeris *rightround.Ephemeris) ([]gridDate, error) {
+	if *o.start == "" {
+		return nil, fmt.Errorf("start date is required")
+	}
+	start, err := rightround.ParseJulianDate(*o.start)
+	if err != nil {
+		return nil, err
+	}
+	end := start
+	if *o.end != "" {
+		if end, err = rightround.ParseJulianDate(*o.end); err != nil {
+			return nil, err
+		}
+	}
+	if end < start {
+		return nil, fmt.Errorf("end date is before start date")
+	}
+	if *o.step <= 0 {
+		return nil, fmt.Errorf("step must be positive")
+	}
+	scale, err := o.timeScale()
+	if err != nil {
+		return nil, err
+	}
+
+	count := int(math.Floor((end-start)/(*o.step)+1e-9)) + 1
+	dates := make([]gridDate, 0, count)
+	for i := 0; i < count; i++ {
+		offset := float64(i) * *o.step
+		date := gridDate{date: start + offset, date1: start, date2: offset}
+		if scale != rightround.TimeScaleCodeTDB {
+			tdb, err := ephemeris.ConvertTimeScale(start+offset, scale, rightround.TimeScaleCodeTDB)
+			if err != nil {
+				return nil, err
+			}
+			// поправка к шкале мала, поэтому прибавляется ко второй части даты
+			date.date2 += tdb - (start + offset)
+		}
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
+
+// parseCode разбирает числовой код объекта.
+func parseCode(name, text string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil {
+		return 0, fmt.Errorf("bad %s code %q", name, text)
+	}
+	return code, nil
+}
+
+func runList(args []string) (*table, string, error) {
+	o := newOptions("list", false)
+	if err := o.flags.Parse(args); err != nil {
+		return nil, "", err
+	}
+	if o.flags.NArg() == 0 {
+		return nil, "", fmt.Errorf("at least one input file is required")
+	}
+
+	result := &table{columns: []string{"file", "type", "object", "basis", "frame", "representation", "start", "end", "degree", "intervals"}}
+	ephemeris := rightround.NewEphemeris()
+	for _, path := range o.flags.Args() {
+		loaded := len(ephemeris.Segments())
+		if err := ephemeris.LoadFile(path); err != nil {
+			return nil, "", fmt.Errorf("%s: %v", path, err)
+		}
+		for _, segment := range ephemeris.Segments()[loaded:] {
+			fileType := "SPK"
+			if segment.FileType == rightround.FormatPCK {
+				fileType = "PCK"
+			}
+			result.add(path, fileType, segment.Object, segment.Basis, segment.Frame, segment.Representation,
+				rightround.FormatJulianDate(segment.Start), rightround.FormatJulianDate(segment.End),
+				segment.Degree, segment.Intervals)
+		}
+	}
+	return result, *o.format, nil
+}
+
+func runCoverage(args []string) (*table, string, error) {
+	o := newOptions("coverage", false)
+	object := o.flags.String("object", "", "код объекта")
+	basis := o.flags.String("basis", "", "код центра (по умолчанию - любой)")
+	ephemeris, err := o.parse(args)
+	if err != nil {
+		return nil, "", err
+	}
+	objectCode, err := parseCode("object", *object)
+	if err != nil {
+		return nil, "", err
+	}
+	window := ephemeris.Coverage(objectCode)
+	if *basis != "" {
+		basisCode, err := parseCode("basis", *basis)
+		if err != nil {
+			return nil, "", err
+		}
+		window = ephemeris.CoverageRelative(objectCode, basisCode)
+	}
+
+	result := &table{columns: []string{"start_jd", "end_jd", "start", "end", "days"}}
+	for _, interval := range window {
+		result.add(interval.Start, interval.End, rightround.FormatJulianDate(interval.Start),
+			rightround.FormatJulianDate(interval.End), interval.Length())
+	}
+	return result, *o.format, nil
+}
+
+func runState(args []string) (*table, string, error) {
+	o := newOptions("state", true)
+	object := o.flags.String("object", "", "код объекта")
+	basis := o.flags.String("basis", "0", "код центра")
+	units := o.flags.String("units", "km", "единицы расстояния: km или au")
+	timeUnits := o.flags.String("time", "s", "единицы времени скорости: s или day")
+	frame := o.flags.String("frame", "icrf", "система координат: icrf, eclipj2000, itrf или код системы PCK")
+	ephemeris, err := o.parse(args)
+	if err != nil {
+		return nil, "", err
+	}
+	objectCode, err := parseCode("object", *object)
+	if err != nil {
+		return nil, "", err
+	}
+	basisCode, err := parseCode("basis", *basis)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := setUnits(ephemeris, *units, *timeUnits); err != nil {
+		return nil, "", err
+	}
+	transform, err := frameTransform(ephemeris, *frame)
+	if err != nil {
+		return nil, "", err
+	}
+	dates, err := o.grid(ephemeris)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := &table{columns: []string{"jd", "date", "x", "y", "z", "vx", "vy", "vz"}}
+	for _, date := range dates {
+		coords, velocity, err := ephemeris.CalculateRectangularCoordsAndScaleVelocity(objectCode, basisCode, date.date1, date.date2, true)
+		if err != nil {
+			return nil, "", err
+		}
+		t, err := transform(date.date1, date.date2)
+		if err != nil {
+			return nil, "", err
+		}
+		coords, velocity = t.Apply(coords, velocity)
+		result.add(date.date, rightround.FormatJulianDate(date.date),
+			coords.X, coords.Y, coords.Z, velocity.X, velocity.Y, velocity.Z)
+	}
+	return result, *o.format, nil
+}
+
+func runAngles(args []string) (*table, string, error) {
+	o := newOptions("angles", true)
+	frame := o.flags.String("frame", "", "код системы координат PCK")
+	timeUnits := o.flags.String("time", "s", "единицы времени скоростей: s или day")
+	ephemeris, err := o.parse(args)
+	if err != nil {
+		return nil, "", err
+	}
+	frameCode, err := parseCode("frame", *frame)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := setUnits(ephemeris, "km", *timeUnits); err != nil {
+		return nil, "", err
+	}
+	dates, err := o.grid(ephemeris)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := &table{columns: []string{"jd", "date", "phi", "theta", "psi", "dphi", "dtheta", "dpsi"}}
+	for _, date := range dates {
+		angles, rates, err := ephemeris.CalculateEulerAngles(frameCode, date.date1, date.date2, true)
+		if err != nil {
+			return nil, "", err
+		}
+		result.add(date.date, rightround.FormatJulianDate(date.date),
+			angles.X, angles.Y, angles.Z, rates.X, rates.Y, rates.Z)
+	}
+	return result, *o.format, nil
+}
+
+func runTimeDiff(args []string) (*table, string, error) {
+	o := newOptions("timediff", true)
+	timeUnits := o.flags.String("time", "s", "единицы разности: s или day")
+	ephemeris, err := o.parse(args)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := setUnits(ephemeris, "km", *timeUnits); err != nil {
+		return nil, "", err
+	}
+	dates, err := o.grid(ephemeris)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := &table{columns: []string{"jd", "date", "tt_tdb"}}
+	for _, date := range dates {
+		diff, err := ephemeris.CalculateTimeDiff(rightround.EphemerisCodeMinusTDB, date.date1, date.date2)
+		if err != nil {
+			return nil, "", err
+		}
+		result.add(date.date, rightround.FormatJulianDate(date.date), diff)
+	}
+	return result, *o.format, nil
+}
+
+// setUnits устанавливает единицы расстояния и времени, заданные флагами.
+func setUnits(ephemeris *rightround.Ephemeris, distance, time string) error {
+	distanceUnits := map[string]int{"km": rightround.UnitCodeKM, "au": rightround.UnitCodeAU}
+	timeUnits := map[string]int{"s": rightround.UnitCodeSec, "day": rightround.UnitCodeDay}
+	unit, ok := distanceUnits[strings.ToLower(distance)]
+	if !ok {
+		return fmt.Errorf("unknown distance units %q", distance)
+	}
+	if err := ephemeris.SetDistanceUnits(unit); err != nil {
+		return err
+	}
+	if unit, ok = timeUnits[strings.ToLower(time)]; !ok {
+		return fmt.Errorf("unknown time units %q", time)
+	}
+	return ephemeris.SetTimeUnits(unit)
+}
+
+// frameTransform возвращает функцию, вычисляющую преобразование из ICRF в заданную систему координат.
+func frameTransform(ephemeris *rightround.Ephemeris, frame string) (func(date1, date2 float64) (rightround.Transform, error), error) {
+	switch strings.ToLower(frame) {
+	case "icrf", "j2000":
+		identity := rightround.Transform{Rotation: rightround.Matrix{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}}
+		return func(date1, date2 float64) (rightround.Transform, error) {
+			return identity, nil
+		}, nil
+	case "eclipj2000":
+		return func(date1, date2 float64) (rightround.Transform, error) {
+			return rightround.EclipticJ2000Transform(), nil
+		}, nil
+	case "itrf", "itrf93":
+		return ephemeris.CalculateEarthTransform, nil
+	}
+	code, err := parseCode("frame", frame)
+	if err != nil {
+		return nil, err
+	}
+	return func(date1, date2 float64) (rightround.Transform, error) {
+		return ephemeris.CalculateFrameTransform(code, date1, date2)
+	}, nil
+}
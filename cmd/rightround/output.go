@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Форматы вывода.
+const (
+	formatTable = "table"
+	formatCSV   = "csv"
+	formatJSON  = "json"
+)
+
+// table результат команды: строки значений с именованными столбцами.
+type table struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+// add добавляет строку значений в порядке столбцов.
+func (t *table) add(values ...interface{}) {
+	t.rows = append(t.rows, values)
+}
+
+// write выводит таблицу в заданном формате.
+func (t *table) write(w io.Writer, format string) error {
+	switch format {
+	case formatTable:
+		writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		if err := t.writeTabbed(writer); err != nil {
+			return err
+		}
+		return writer.Flush()
+	case formatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(t.columns); err != nil {
+			return err
+		}
+		for _, row := range t.rows {
+			if err := writer.Write(formatRow(row)); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case formatJSON:
+		return t.writeJSON(w)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// writeTabbed выводит заголовок и строки, разделяя значения табуляцией.
+func (t *table) writeTabbed(w io.Writer) error {
+	for _, row := range append([][]string{t.columns}, formatRows(t.rows)...) {
+		if _, err := io.WriteString(w, strings.Join(row, "\t")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSON выводит массив объектов, сохраняя порядок столбцов.
+func (t *table) writeJSON(w io.Writer) error {
+	buffer := []byte("[")
+	for i, row := range t.rows {
+		if i > 0 {
+			buffer = append(buffer, ',')
+		}
+		buffer = append(buffer, "\n  {"...)
+		for j, value := range row {
+			if j > 0 {
+				buffer = append(buffer, ", "...)
+			}
+			key, err := json.Marshal(t.columns[j])
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			buffer = append(buffer, key...)
+			buffer = append(buffer, ": "...)
+			buffer = append(buffer, data...)
+		}
+		buffer = append(buffer, '}')
+	}
+	buffer = append(buffer, "\n]\n"...)
+	_, err := w.Write(buffer)
+	return err
+}
+
+// formatRows преобразует строки значений в текст.
+func formatRows(rows [][]interface{}) [][]string {
+	result := make([][]string, len(rows))
+	for i, row := range rows {
+		result[i] = formatRow(row)
+	}
+	return result
+}
+
+// formatRow преобразует значения строки в текст; числа выводятся без потери точности.
+func formatRow(row []interface{}) []string {
+	result := make([]string, len(row))
+	for i, value := range row {
+		switch v := value.(type) {
+		case float64:
+			result[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			result[i] = fmt.Sprint(v)
+		}
+	}
+	return result
+}
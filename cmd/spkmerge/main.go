@@ -0,0 +1,71 @@
+// Команда spkmerge создаёт файл SPK из сегментов одного или нескольких файлов эфемерид,
+// оставляя только выбранные объекты и интервал дат.
+//
+// Использование:
+//
+//	spkmerge -o out.bsp [-objects 10,399,301] [-start 2000-01-01] [-end 2050-01-01] in1.bsp [in2.bsp ...]
+//
+// Файлы загружаются в указанном порядке; при перекрытии сегментов приоритет имеют файлы, указанные позже.
+// Даты задаются юлианскими датами или календарными датами в шкале TDB.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dvoeglazyi/rightround"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "spkmerge:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	output := flag.String("o", "", "выходной файл SPK")
+	objects := flag.String("objects", "", "коды объектов через запятую (по умолчанию - все)")
+	start := flag.String("start", "", "начало интервала (юлианская дата или календарная дата TDB)")
+	end := flag.String("end", "", "конец интервала (юлианская дата или календарная дата TDB)")
+	name := flag.String("name", "SPKMERGE", "внутреннее имя выходного файла")
+	flag.Parse()
+	if *output == "" || flag.NArg() == 0 {
+		flag.Usage()
+		return fmt.Errorf("output file and at least one input file are required")
+	}
+
+	options := rightround.SubsetOptions{InternalName: *name}
+	if *objects != "" {
+		for _, field := range strings.Split(*objects, ",") {
+			object, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				return fmt.Errorf("bad object code %q", field)
+			}
+			options.Objects = append(options.Objects, object)
+		}
+	}
+	var err error
+	if *start != "" {
+		if options.Start, err = rightround.ParseJulianDate(*start); err != nil {
+			return err
+		}
+	}
+	if *end != "" {
+		if options.End, err = rightround.ParseJulianDate(*end); err != nil {
+			return err
+		}
+	}
+
+	ephemeris := rightround.NewEphemeris()
+	for _, path := range flag.Args() {
+		if err := ephemeris.LoadFile(path); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		options.Comments = append(options.Comments, "source: "+path)
+	}
+	return ephemeris.WriteSPK(*output, options)
+}
@@ -30,6 +30,19 @@ const (
 	EphemerisEarth     = 399 // Земля
 )
 
+// Числовые коды центров планет.
+// Нумерация соответствует принятой в формате SPK.
+const (
+	EphemerisMercuryBody = 199 // Меркурий
+	EphemerisVenusBody   = 299 // Венера
+	EphemerisMarsBody    = 499 // Марс
+	EphemerisJupiterBody = 599 // Юпитер
+	EphemerisSaturnBody  = 699 // Сатурн
+	EphemerisUranusBody  = 799 // Уран
+	EphemerisNeptuneBody = 899 // Нептун
+	EphemerisPlutoBody   = 999 // Плутон
+)
+
 // Разность шкал TT - TDB.
 const EphemerisCodeMinusTDB = 1000000001
 
@@ -39,8 +52,12 @@ const (
 	EphemerisMoonPrincipalAxesDE403   = 31002
 	EphemerisMoonPrincipalAxesDE421   = 31006
 	EphemerisMoonPrincipalAxesDE430   = 32006
+	EphemerisMoonPrincipalAxesDE440   = 31008
 	EphemerisMoonPrincipalAxesInPOP   = 1900301
 	EphemerisMoonPrincipalAxesEPM2011 = 1800301
 	EphemerisMoonPrincipalAxesEPM2015 = 1800302
 	EphemerisMoonPrincipalAxesEPM2017 = 1800303
 )
+
+// Код системы координат ITRF93, заданной в бинарном PCK ориентации Земли.
+const EphemerisEarthITRF93 = 3000
@@ -1,5 +1,27 @@
 package rightround
 
+import "math"
+
 type Coords struct {
 	X, Y, Z float64
 }
+
+// length возвращает длину вектора.
+func (c Coords) length() float64 {
+	return math.Sqrt(c.X*c.X + c.Y*c.Y + c.Z*c.Z)
+}
+
+// dot возвращает скалярное произведение векторов.
+func (c Coords) dot(other Coords) float64 {
+	return c.X*other.X + c.Y*other.Y + c.Z*other.Z
+}
+
+// sub возвращает разность векторов.
+func (c Coords) sub(other Coords) Coords {
+	return Coords{X: c.X - other.X, Y: c.Y - other.Y, Z: c.Z - other.Z}
+}
+
+// scale возвращает вектор, умноженный на число.
+func (c Coords) scale(factor float64) Coords {
+	return Coords{X: c.X * factor, Y: c.Y * factor, Z: c.Z * factor}
+}
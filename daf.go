@@ -3,6 +3,7 @@ package rightround
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"math"
 	"os"
@@ -24,8 +25,12 @@ type DAFSegment struct {
 }
 
 const (
-	representationPositionOnly = 2
-	representationVelocityOnly = 20
+	representationPositionOnly     = 2
+	representationPositionVelocity = 3
+	representationDiscreteStates   = 5
+	representationLagrange         = 9
+	representationHermite          = 13
+	representationVelocityOnly     = 20
 )
 
 type DAF struct {
@@ -98,7 +103,7 @@ func newDAF(file *os.File) (*DAF, error) {
 	} else if strings.Contains(id, "DAF/PCK") {
 		d.fileType = FormatPCK
 	} else {
-		return nil, errors.New("unsupported format")
+		return nil, fmt.Errorf("%w format", ErrUnsupported)
 	}
 	return &d, nil
 }
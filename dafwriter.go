@@ -0,0 +1,148 @@
+package rightround
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// Параметры структуры файла DAF.
+const (
+	dafRecordLength      = 1024 // длина записи в байтах
+	dafRecordWords       = 128  // длина записи в словах двойной точности
+	dafCommentLength     = 1000 // количество символов комментария в записи
+	dafInternalNameLen   = 60   // длина внутреннего имени файла
+	dafSummaryControlLen = 3    // управляющие слова записи сводок: следующая, предыдущая, количество
+	dafFTPString         = "FTPSTR:\r:\n:\r\n:\r\x00:\x81:\x10\xce:ENDFTP"
+)
+
+// dafWriterSegment сегмент, подготовленный к записи в файл DAF.
+type dafWriterSegment struct {
+	name        string
+	dParameters []float64
+	iParameters []int32 // без начального и конечного адресов
+	data        []float64
+}
+
+// writeDAF записывает файл DAF в формате LTL-IEEE (little-endian) с заданными идентификатором,
+// количеством параметров сводки, комментариями и сегментами.
+func writeDAF(path, id string, nd, ni int, internalName string, comments []string, segments []dafWriterSegment) error {
+	// размер сводки в словах двойной точности и количество сводок в записи
+	summarySize := nd + (ni+1)/2
+	perRecord := (dafRecordWords - dafSummaryControlLen) / summarySize
+	for _, segment := range segments {
+		if len(segment.dParameters) != nd || len(segment.iParameters) != ni-2 {
+			return errors.New("bad segment summary size")
+		}
+	}
+
+	// область комментариев: строки разделяются нулевым символом, конец отмечается символом EOT
+	commentText := ""
+	if len(comments) > 0 {
+		commentText = strings.Join(comments, "\x00") + "\x00\x04"
+	}
+	commentRecords := (len(commentText) + dafCommentLength - 1) / dafCommentLength
+
+	summaryRecords := (len(segments) + perRecord - 1) / perRecord
+	if summaryRecords == 0 {
+		summaryRecords = 1
+	}
+	firstSummary := commentRecords + 2
+	// записи сводок и имён чередуются, данные начинаются после последней записи имён
+	address := (firstSummary-1+2*summaryRecords)*dafRecordWords + 1
+	addresses := make([][2]int32, len(segments))
+	for i, segment := range segments {
+		addresses[i] = [2]int32{int32(address), int32(address + len(segment.data) - 1)}
+		address += len(segment.data)
+	}
+
+	buffer := make([]byte, (firstSummary-1+2*summaryRecords)*dafRecordLength)
+
+	// запись файла
+	copy(buffer, fmt.Sprintf("%-8s", id))
+	binary.LittleEndian.PutUint32(buffer[8:], uint32(nd))
+	binary.LittleEndian.PutUint32(buffer[12:], uint32(ni))
+	copy(buffer[16:16+dafInternalNameLen], fmt.Sprintf("%-60.60s", internalName))
+	binary.LittleEndian.PutUint32(buffer[76:], uint32(firstSummary))
+	binary.LittleEndian.PutUint32(buffer[80:], uint32(firstSummary+2*(summaryRecords-1)))
+	binary.LittleEndian.PutUint32(buffer[84:], uint32(address))
+	copy(buffer[88:], "LTL-IEEE")
+	copy(buffer[699:], dafFTPString)
+
+	// записи комментариев
+	for i := 0; i < commentRecords; i++ {
+		end := (i + 1) * dafCommentLength
+		if end > len(commentText) {
+			end = len(commentText)
+		}
+		copy(buffer[(i+1)*dafRecordLength:], commentText[i*dafCommentLength:end])
+	}
+
+	// записи сводок и имён
+	putFloat := func(offset int, value float64) {
+		binary.LittleEndian.PutUint64(buffer[offset:], math.Float64bits(value))
+	}
+	for r := 0; r < summaryRecords; r++ {
+		record := firstSummary + 2*r
+		offset := (record - 1) * dafRecordLength
+		next, prev := 0, 0
+		if r < summaryRecords-1 {
+			next = record + 2
+		}
+		if r > 0 {
+			prev = record - 2
+		}
+		first := r * perRecord
+		last := first + perRecord
+		if last > len(segments) {
+			last = len(segments)
+		}
+		putFloat(offset, float64(next))
+		putFloat(offset+8, float64(prev))
+		putFloat(offset+16, float64(last-first))
+		for i := first; i < last; i++ {
+			summary := offset + (dafSummaryControlLen+(i-first)*summarySize)*8
+			for j, value := range segments[i].dParameters {
+				putFloat(summary+j*8, value)
+			}
+			ints := summary + nd*8
+			for j, value := range segments[i].iParameters {
+				binary.LittleEndian.PutUint32(buffer[ints+j*4:], uint32(value))
+			}
+			binary.LittleEndian.PutUint32(buffer[ints+(ni-2)*4:], uint32(addresses[i][0]))
+			binary.LittleEndian.PutUint32(buffer[ints+(ni-1)*4:], uint32(addresses[i][1]))
+
+			name := offset + dafRecordLength + (i-first)*summarySize*8
+			copy(buffer[name:name+summarySize*8], fmt.Sprintf("%-*.*s", summarySize*8, summarySize*8, segments[i].name))
+		}
+	}
+
+	// данные сегментов, дополненные до целой записи
+	dataWords := address - 1 - (firstSummary-1+2*summaryRecords)*dafRecordWords
+	dataWords = (dataWords + dafRecordWords - 1) / dafRecordWords * dafRecordWords
+	data := make([]byte, dataWords*8)
+	position := 0
+	for _, segment := range segments {
+		for _, value := range segment.data {
+			binary.LittleEndian.PutUint64(data[position:], math.Float64bits(value))
+			position += 8
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(buffer); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
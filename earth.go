@@ -0,0 +1,403 @@
+package rightround
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// modifiedJulianDateOffset разность между юлианской и модифицированной юлианской датой.
+const modifiedJulianDateOffset = 2400000.5
+
+// eopRecord параметры вращения Земли на дату.
+type eopRecord struct {
+	mjd        float64 // модифицированная юлианская дата UTC
+	x, y       float64 // координаты полюса, угловые секунды
+	ut1MinusAT float64 // разность UT1 - TAI, секунды
+	dX, dY     float64 // поправки к положению небесного промежуточного полюса, угловые секунды
+}
+
+// EarthOrientation модель ориентации Земли: прецессия IAU 2006, нутация IAU 2000B,
+// угол вращения Земли и движение полюса по данным IERS.
+// Поправки dX, dY из данных IERS отсчитываются от модели IAU 2000A, поэтому отличие IAU 2000B
+// от неё (до 1 миллисекунды дуги) остаётся в результате.
+type EarthOrientation struct {
+	records []eopRecord
+}
+
+// NewEarthOrientation создаёт модель ориентации Земли без данных IERS:
+// пока данные не загружены, UT1 - UTC, координаты полюса и поправки нутации считаются нулевыми.
+func NewEarthOrientation() *EarthOrientation {
+	return &EarthOrientation{}
+}
+
+// LoadFile загружает параметры вращения Земли из файла IERS в формате finals2000A или EOP C04.
+func (o *EarthOrientation) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var records []eopRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var record eopRecord
+		var ok bool
+		if len(line) >= 68 && (line[16] == 'I' || line[16] == 'P') {
+			record, ok = parseFinalsLine(line)
+		} else {
+			record, ok = parseC04Line(line)
+		}
+		if ok {
+			record.ut1MinusAT -= taiMinusUTC(record.mjd + modifiedJulianDateOffset)
+			records = append(records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no earth orientation parameters in %s", path)
+	}
+
+	o.records = append(o.records, records...)
+	sort.Slice(o.records, func(i, j int) bool {
+		return o.records[i].mjd < o.records[j].mjd
+	})
+	return nil
+}
+
+// parseFixedFloat разбирает число в колонках [start, end) строки фиксированного формата.
+func parseFixedFloat(line string, start, end int) (float64, bool) {
+	if len(line) < end {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(line[start:end]), 64)
+	return value, err == nil
+}
+
+// parseFinalsLine разбирает строку файла finals2000A (Бюллетень A).
+func parseFinalsLine(line string) (eopRecord, bool) {
+	var record eopRecord
+	var ok bool
+	if record.mjd, ok = parseFixedFloat(line, 7, 15); !ok {
+		return record, false
+	}
+	if record.x, ok = parseFixedFloat(line, 18, 27); !ok {
+		return record, false
+	}
+	if record.y, ok = parseFixedFloat(line, 37, 46); !ok {
+		return record, false
+	}
+	if record.ut1MinusAT, ok = parseFixedFloat(line, 58, 68); !ok {
+		return record, false
+	}
+	// поправки нутации в миллисекундах дуги, могут отсутствовать для прогноза
+	if dX, ok := parseFixedFloat(line, 97, 106); ok {
+		record.dX = dX / 1000
+	}
+	if dY, ok := parseFixedFloat(line, 116, 125); ok {
+		record.dY = dY / 1000
+	}
+	return record, true
+}
+
+// parseC04Line разбирает строку файла EOP C04: год, месяц, день, [час,] MJD, x, y, UT1-UTC, ...
+func parseC04Line(line string) (eopRecord, bool) {
+	var record eopRecord
+	fields := strings.Fields(line)
+	if len(fields) < 10 {
+		return record, false
+	}
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return record, false
+		}
+		values[i] = value
+	}
+	if values[3] > 30000 {
+		// формат 14: MJD x y UT1-UTC LOD dX dY
+		record.mjd, record.x, record.y, record.ut1MinusAT = values[3], values[4], values[5], values[6]
+		record.dX, record.dY = values[8], values[9]
+	} else if values[4] > 30000 && len(values) >= 10 {
+		// формат 20: час MJD x y UT1-UTC dX dY
+		record.mjd, record.x, record.y, record.ut1MinusAT = values[4], values[5], values[6], values[7]
+		record.dX, record.dY = values[8], values[9]
+	} else {
+		return record, false
+	}
+	return record, true
+}
+
+// parameters возвращает интерполированные параметры вращения Земли на юлианскую дату TT.
+func (o *EarthOrientation) parameters(julianDateTT float64) (eopRecord, error) {
+	mjd := ttToUTC(julianDateTT) - modifiedJulianDateOffset
+	if len(o.records) == 0 {
+		return eopRecord{mjd: mjd, ut1MinusAT: -taiMinusUTC(mjd + modifiedJulianDateOffset)}, nil
+	}
+	i := sort.Search(len(o.records), func(i int) bool {
+		return o.records[i].mjd > mjd
+	})
+	if i == 0 || i == len(o.records) {
+		if i > 0 && o.records[i-1].mjd == mjd {
+			return o.records[i-1], nil
+		}
+		return eopRecord{}, fmt.Errorf("earth orientation parameters for MJD %.2f %w", mjd, ErrNotFound)
+	}
+	left, right := o.records[i-1], o.records[i]
+	f := (mjd - left.mjd) / (right.mjd - left.mjd)
+	return eopRecord{
+		mjd:        mjd,
+		x:          left.x + (right.x-left.x)*f,
+		y:          left.y + (right.y-left.y)*f,
+		ut1MinusAT: left.ut1MinusAT + (right.ut1MinusAT-left.ut1MinusAT)*f,
+		dX:         left.dX + (right.dX-left.dX)*f,
+		dY:         left.dY + (right.dY-left.dY)*f,
+	}, nil
+}
+
+// nutationTerm член ряда нутации: множители фундаментальных аргументов (l, l', F, D, Ω)
+// и коэффициенты в 0.1 микросекунды дуги.
+type nutationTerm struct {
+	l, lp, f, d, om          int
+	ps, pst, pc, ec, ect, es float64
+}
+
+// nutationSeries лунно-солнечные члены ряда нутации IAU 2000B (77 членов, McCarthy & Luzum 2003).
+// Вместе с постоянными поправками за планетные члены ряд отличается от модели IAU 2000A
+// не более чем на 1 миллисекунду дуги в интервале 1995-2050 гг.
+var nutationSeries = []nutationTerm{
+	{0, 0, 0, 0, 1, -172064161, -174666, 33386, 92052331, 9086, 15377},
+	{0, 0, 2, -2, 2, -13170906, -1675, -13696, 5730336, -3015, -4587},
+	{0, 0, 2, 0, 2, -2276413, -234, 2796, 978459, -485, 1374},
+	{0, 0, 0, 0, 2, 2074554, 207, -698, -897492, 470, -291},
+	{0, 1, 0, 0, 0, 1475877, -3633, 11817, 73871, -184, -1924},
+	{0, 1, 2, -2, 2, -516821, 1226, -524, 224386, -677, -174},
+	{1, 0, 0, 0, 0, 711159, 73, -872, -6750, 0, 358},
+	{0, 0, 2, 0, 1, -387298, -367, 380, 200728, 18, 318},
+	{1, 0, 2, 0, 2, -301461, -36, 816, 129025, -63, 367},
+	{0, -1, 2, -2, 2, 215829, -494, 111, -95929, 299, 132},
+	{0, 0, 2, -2, 1, 128227, 137, 181, -68982, -9, 39},
+	{-1, 0, 2, 0, 2, 123457, 11, 19, -53311, 32, -4},
+	{-1, 0, 0, 2, 0, 156994, 10, -168, -1235, 0, 82},
+	{1, 0, 0, 0, 1, 63110, 63, 27, -33228, 0, -9},
+	{-1, 0, 0, 0, 1, -57976, -63, -189, 31429, 0, -75},
+	{-1, 0, 2, 2, 2, -59641, -11, 149, 25543, -11, 66},
+	{1, 0, 2, 0, 1, -51613, -42, 129, 26366, 0, 78},
+	{-2, 0, 2, 0, 1, 45893, 50, 31, -24236, -10, 20},
+	{0, 0, 0, 2, 0, 63384, 11, -150, -1220, 0, 29},
+	{0, 0, 2, 2, 2, -38571, -1, 158, 16452, -11, 68},
+	{0, -2, 2, -2, 2, 32481, 0, 0, -13870, 0, 0},
+	{-2, 0, 0, 2, 0, -47722, 0, -18, 477, 0, -25},
+	{2, 0, 2, 0, 2, -31046, -1, 131, 13238, -11, 59},
+	{1, 0, 2, -2, 2, 28593, 0, -1, -12338, 10, -3},
+	{-1, 0, 2, 0, 1, 20441, 21, 10, -10758, 0, -3},
+	{2, 0, 0, 0, 0, 29243, 0, -74, -609, 0, 13},
+	{0, 0, 2, 0, 0, 25887, 0, -66, -550, 0, 11},
+	{0, 1, 0, 0, 1, -14053, -25, 79, 8551, -2, -45},
+	{-1, 0, 0, 2, 1, 15164, 10, 11, -8001, 0, -1},
+	{0, 2, 2, -2, 2, -15794, 72, -16, 6850, -42, -5},
+	{0, 0, -2, 2, 0, 21783, 0, 13, -167, 0, 13},
+	{1, 0, 0, -2, 1, -12873, -10, -37, 6953, 0, -14},
+	{0, -1, 0, 0, 1, -12654, 11, 63, 6415, 0, 26},
+	{-1, 0, 2, 2, 1, -10204, 0, 25, 5222, 0, 15},
+	{0, 2, 0, 0, 0, 16707, -85, -10, 168, -1, 10},
+	{1, 0, 2, 2, 2, -7691, 0, 44, 3268, 0, 19},
+	{-2, 0, 2, 0, 0, -11024, 0, -14, 104, 0, 2},
+	{0, 1, 2, 0, 2, 7566, -21, -11, -3250, 0, -5},
+	{0, 0, 2, 2, 1, -6637, -11, 25, 3353, 0, 14},
+	{0, -1, 2, 0, 2, -7141, 21, 8, 3070, 0, 4},
+	{0, 0, 0, 2, 1, -6302, -11, 2, 3272, 0, 4},
+	{1, 0, 2, -2, 1, 5800, 10, 2, -3045, 0, -1},
+	{2, 0, 2, -2, 2, 6443, 0, -7, -2768, 0, -4},
+	{-2, 0, 0, 2, 1, -5774, -11, -15, 3041, 0, -5},
+	{2, 0, 2, 0, 1, -5350, 0, 21, 2695, 0, 12},
+	{0, -1, 2, -2, 1, -4752, -11, -3, 2719, 0, -3},
+	{0, 0, 0, -2, 1, -4940, -11, -21, 2720, 0, -9},
+	{-1, -1, 0, 2, 0, 7350, 0, -8, -51, 0, 4},
+	{2, 0, 0, -2, 1, 4065, 0, 6, -2206, 0, 1},
+	{1, 0, 0, 2, 0, 6579, 0, -24, -199, 0, 2},
+	{0, 1, 2, -2, 1, 3579, 0, 5, -1900, 0, 1},
+	{1, -1, 0, 0, 0, 4725, 0, -6, -41, 0, 3},
+	{-2, 0, 2, 0, 2, -3075, 0, -2, 1313, 0, -1},
+	{3, 0, 2, 0, 2, -2904, 0, 15, 1233, 0, 7},
+	{0, -1, 0, 2, 0, 4348, 0, -10, -81, 0, 2},
+	{1, -1, 2, 0, 2, -2878, 0, 8, 1232, 0, 4},
+	{0, 0, 0, 1, 0, -4230, 0, 5, -20, 0, -2},
+	{-1, -1, 2, 2, 2, -2819, 0, 7, 1207, 0, 3},
+	{-1, 0, 2, 0, 0, -4056, 0, 5, 40, 0, -2},
+	{0, -1, 2, 2, 2, -2647, 0, 11, 1129, 0, 5},
+	{-2, 0, 0, 0, 1, -2294, 0, -10, 1266, 0, -4},
+	{1, 1, 2, 0, 2, 2481, 0, -7, -1062, 0, -3},
+	{2, 0, 0, 0, 1, 2179, 0, -2, -1129, 0, -2},
+	{-1, 1, 0, 1, 0, 3276, 0, 1, -9, 0, 0},
+	{1, 1, 0, 0, 0, -3389, 0, 5, 35, 0, -2},
+	{1, 0, 2, 0, 0, 3339, 0, -13, -107, 0, 1},
+	{-1, 0, 2, -2, 1, -1987, 0, -6, 1073, 0, -2},
+	{1, 0, 0, 0, 2, -1981, 0, 0, 854, 0, 0},
+	{-1, 0, 0, 1, 0, 4026, 0, -353, -553, 0, -139},
+	{0, 0, 2, 1, 2, 1660, 0, -5, -710, 0, -2},
+	{-1, 0, 2, 4, 2, -1521, 0, 9, 647, 0, 4},
+	{-1, 1, 0, 1, 1, 1314, 0, 0, -700, 0, 0},
+	{0, -2, 2, -2, 1, -1283, 0, 0, 672, 0, 0},
+	{1, 0, 2, 2, 1, -1331, 0, 8, 663, 0, 4},
+	{-2, 0, 2, 2, 2, 1383, 0, -2, -594, 0, -2},
+	{-1, 0, 0, 0, 2, 1405, 0, 4, -610, 0, 2},
+	{1, 1, 2, -2, 2, 1290, 0, 0, -556, 0, 0},
+}
+
+// fundamentalArguments вычисляет фундаментальные аргументы Делоне (l, l', F, D, Ω) в радианах, IERS 2003.
+// terms - количество используемых членов полиномов (2 - линейные аргументы модели IAU 2000B, 5 - полные).
+func fundamentalArguments(centuries float64, terms int) [5]float64 {
+	polynomials := [5][5]float64{
+		{485868.249036, 1717915923.2178, 31.8792, 0.051635, -0.00024470},
+		{1287104.79305, 129596581.0481, -0.5532, 0.000136, -0.00001149},
+		{335779.526232, 1739527262.8478, -12.7512, -0.001037, 0.00000417},
+		{1072260.70369, 1602961601.2090, -6.3706, 0.006593, -0.00003169},
+		{450160.398036, -6962890.5431, 7.4722, 0.007702, -0.00005939},
+	}
+	var result [5]float64
+	for i, coefficients := range polynomials {
+		value, _ := calcPolynomial(coefficients[:terms], centuries)
+		result[i] = math.Mod(value, 1296000) / arcsecondsInRadian
+	}
+	return result
+}
+
+// calcNutation вычисляет нутацию в долготе и наклоне (в радианах) на момент TT в юлианских столетиях от J2000.
+func calcNutation(centuries float64) (float64, float64) {
+	arguments := fundamentalArguments(centuries, 2)
+	var dPsi, dEps float64
+	for i := len(nutationSeries) - 1; i >= 0; i-- {
+		term := nutationSeries[i]
+		argument := float64(term.l)*arguments[0] + float64(term.lp)*arguments[1] +
+			float64(term.f)*arguments[2] + float64(term.d)*arguments[3] + float64(term.om)*arguments[4]
+		sin, cos := math.Sincos(argument)
+		dPsi += (term.ps+term.pst*centuries)*sin + term.pc*cos
+		dEps += (term.ec+term.ect*centuries)*cos + term.es*sin
+	}
+	// перевод из 0.1 микросекунды дуги, поправка за планетные члены IAU 2000B
+	dPsi = dPsi*1e-7 - 0.135e-3
+	dEps = dEps*1e-7 + 0.388e-3
+	// согласование с прецессией IAU 2006
+	correction := -2.7774e-6 * centuries
+	dPsi *= 1 + 0.4697e-6 + correction
+	dEps *= 1 + correction
+	return dPsi / arcsecondsInRadian, dEps / arcsecondsInRadian
+}
+
+// meanObliquity вычисляет средний наклон эклиптики (в радианах) по модели IAU 2006.
+func meanObliquity(centuries float64) float64 {
+	value, _ := calcPolynomial([]float64{84381.406, -46.836769, -0.0001831, 0.00200340, -0.000000576, -0.0000000434}, centuries)
+	return value / arcsecondsInRadian
+}
+
+// precessionNutation вычисляет матрицу перехода от GCRS к истинному экватору и равноденствию даты
+// (с учётом смещения системы отсчёта, прецессии IAU 2006 по Фукусиме-Уильямсу и нутации),
+// а также нутацию в долготе и истинный наклон эклиптики (в радианах).
+func precessionNutation(centuries, dPsi, dEps float64) (Matrix, float64) {
+	gamma, _ := calcPolynomial([]float64{-0.052928, 10.556378, 0.4932044, -0.00031238, -0.000002788, 0.0000000260}, centuries)
+	phi, _ := calcPolynomial([]float64{84381.412819, -46.811016, 0.0511268, 0.00053289, -0.000000440, -0.0000000176}, centuries)
+	psi, _ := calcPolynomial([]float64{-0.041775, 5038.481484, 1.5584175, -0.00018522, -0.000026452, -0.0000000148}, centuries)
+	eps := meanObliquity(centuries) + dEps
+	return rotationX(-eps).
+		Mul(rotationZ(-(psi/arcsecondsInRadian + dPsi))).
+		Mul(rotationX(phi / arcsecondsInRadian)).
+		Mul(rotationZ(gamma / arcsecondsInRadian)), eps
+}
+
+// earthRotationAngle вычисляет угол вращения Земли (в радианах) на юлианскую дату UT1.
+func earthRotationAngle(date1, date2 float64) float64 {
+	days := (date1 - julianDate2000) + date2
+	fraction := math.Mod(date1, 1) + math.Mod(date2, 1)
+	angle := 2 * math.Pi * math.Mod(fraction+0.7790572732640+0.00273781191135448*days, 1)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	return angle
+}
+
+// earthRotationRate скорость вращения Земли в радианах за сутки UT1.
+const earthRotationRate = 2 * math.Pi * 1.00273781191135448
+
+// greenwichSiderealTime вычисляет истинное гринвичское звёздное время (в радианах)
+// по углу вращения Земли, нутации в долготе и истинному наклону эклиптики.
+func greenwichSiderealTime(era, centuries, dPsi, eps float64) float64 {
+	precession, _ := calcPolynomial([]float64{0.014506, 4612.156534, 1.3915817, -0.00000044, -0.000029956, -0.0000000368}, centuries)
+	arguments := fundamentalArguments(centuries, 5)
+	f, d, om := arguments[2], arguments[3], arguments[4]
+	// главные дополнительные члены уравнения равноденствий, угловые секунды
+	complementary := 2640.96e-6*math.Sin(om) + 63.52e-6*math.Sin(2*om) +
+		11.75e-6*math.Sin(2*f-2*d+3*om) + 11.21e-6*math.Sin(2*f-2*d+om) -
+		4.55e-6*math.Sin(2*f-2*d+2*om) - 0.87e-6*centuries*math.Sin(om)
+	gst := era + (precession+complementary)/arcsecondsInRadian + dPsi*math.Cos(eps)
+	gst = math.Mod(gst, 2*math.Pi)
+	if gst < 0 {
+		gst += 2 * math.Pi
+	}
+	return gst
+}
+
+// CalculateTransform вычисляет преобразование состояния из GCRS в ITRS на юлианскую дату TT (date1 + date2).
+// Производная матрицы поворота выражается в сутках и учитывает только суточное вращение Земли.
+func (o *EarthOrientation) CalculateTransform(date1, date2 float64) (Transform, error) {
+	eop, err := o.parameters(date1 + date2)
+	if err != nil {
+		return Transform{}, err
+	}
+	centuries := ((date1 - julianDate2000) + date2) / daysInCentury
+
+	dPsi, dEps := calcNutation(centuries)
+	// поправки dX, dY к положению полюса пересчитываются в поправки нутации
+	dPsi += eop.dX / arcsecondsInRadian / math.Sin(meanObliquity(centuries))
+	dEps += eop.dY / arcsecondsInRadian
+	nutationMatrix, eps := precessionNutation(centuries, dPsi, dEps)
+
+	// UT1 = TT - (TT - TAI) + (UT1 - TAI)
+	ut1Offset := (eop.ut1MinusAT - ttMinusTAI) / secondsInDay
+	gst := greenwichSiderealTime(earthRotationAngle(date1, date2+ut1Offset), centuries, dPsi, eps)
+
+	sPrime := -47e-6 * centuries / arcsecondsInRadian
+	polarMotion := rotationX(-eop.y / arcsecondsInRadian).
+		Mul(rotationY(-eop.x / arcsecondsInRadian)).
+		Mul(rotationZ(sPrime))
+
+	return Transform{
+		Rotation:   polarMotion.Mul(rotationZ(gst)).Mul(nutationMatrix),
+		Derivative: polarMotion.Mul(rotationZDerivative(gst)).Mul(nutationMatrix).Scale(earthRotationRate),
+	}, nil
+}
+
+// LoadEOPFile загружает параметры вращения Земли IERS (finals2000A или EOP C04).
+func (e *Ephemeris) LoadEOPFile(path string) error {
+	return e.earthOrientation.LoadFile(path)
+}
+
+// EarthOrientation возвращает модель ориентации Земли, основанную на данных IERS.
+func (e *Ephemeris) EarthOrientation() *EarthOrientation {
+	return e.earthOrientation
+}
+
+// CalculateEarthTransform вычисляет преобразование состояния из ICRF (GCRS) в земную систему ITRS.
+// Если загружен бинарный PCK Земли (ITRF93), покрывающий дату, используются его углы,
+// иначе - модель ориентации Земли по данным IERS.
+// Производная матрицы поворота выражается в установленных единицах времени.
+func (e *Ephemeris) CalculateEarthTransform(date1, date2 float64) (Transform, error) {
+	if _, err := e.findFrameTheory(EphemerisEarthITRF93, date1, date2); err == nil {
+		return e.CalculateFrameTransform(EphemerisEarthITRF93, date1, date2)
+	}
+	transform, err := e.earthOrientation.CalculateTransform(date1, date2)
+	if err != nil {
+		return Transform{}, err
+	}
+	transform.Derivative = transform.Derivative.Scale(1 / e.timeScalingFactor)
+	return transform, nil
+}
@@ -0,0 +1,82 @@
+package rightround
+
+import (
+	"math"
+	"testing"
+)
+
+// Эталонные значения - из тестов библиотеки IAU SOFA (t_sofa_c.c). Нутация вычисляется по модели IAU 2000B,
+// поэтому величины, зависящие от неё, сравниваются с моделью IAU 2000A с точностью 1 миллисекунда дуги.
+const nutationTolerance = 1e-3 / arcsecondsInRadian
+
+// testCenturies возвращает юлианские столетия TT от J2000 для модифицированной юлианской даты.
+func testCenturies(mjd float64) float64 {
+	return (modifiedJulianDateOffset - julianDate2000 + mjd) / daysInCentury
+}
+
+// testMatrix сравнивает матрицу с эталонной.
+func testMatrix(t *testing.T, name string, actual, expected Matrix, tolerance float64) {
+	t.Helper()
+	for i := range expected {
+		for j := range expected[i] {
+			if math.Abs(actual[i][j]-expected[i][j]) > tolerance {
+				t.Fatalf("%s[%d][%d] = %.15g, expected %.15g", name, i, j, actual[i][j], expected[i][j])
+			}
+		}
+	}
+}
+
+func TestEarthRotationAngle(t *testing.T) {
+	// iauEra00
+	if era := earthRotationAngle(modifiedJulianDateOffset, 54388); math.Abs(era-0.4022837240028158102) > 1e-12 {
+		t.Fatalf("ERA %.16f", era)
+	}
+	// iauObl06
+	if eps := meanObliquity(testCenturies(54388)); math.Abs(eps-0.4090749229387258204) > 1e-14 {
+		t.Fatalf("obliquity %.16f", eps)
+	}
+}
+
+func TestNutation(t *testing.T) {
+	centuries := testCenturies(53736)
+	// iauNut06a
+	dPsi, dEps := calcNutation(centuries)
+	if math.Abs(dPsi+0.9630912025820308797e-5) > nutationTolerance || math.Abs(dEps-0.4063238496887249798e-4) > nutationTolerance {
+		t.Fatalf("nutation %g %g", dPsi, dEps)
+	}
+	// iauGst06a
+	_, eps := precessionNutation(centuries, dPsi, dEps)
+	gst := greenwichSiderealTime(earthRotationAngle(modifiedJulianDateOffset, 53736), centuries, dPsi, eps)
+	if math.Abs(gst-1.754166137675019159) > nutationTolerance {
+		t.Fatalf("GST %.16f", gst)
+	}
+
+	// iauPnm06a
+	centuries = testCenturies(50123.9999)
+	dPsi, dEps = calcNutation(centuries)
+	matrix, _ := precessionNutation(centuries, dPsi, dEps)
+	testMatrix(t, "NPB", matrix, Matrix{
+		{0.9999995832794205484, 0.8372382772630962111e-3, 0.3639684771140623099e-3},
+		{-0.8372533744743683605e-3, 0.9999996486492861646, 0.4132905944611019498e-4},
+		{-0.3639337469629464969e-3, -0.4163377605910663999e-4, 0.9999999329310274805},
+	}, nutationTolerance)
+}
+
+func TestEarthTransform(t *testing.T) {
+	// iauC2t06a: UT1 совпадает с TT, координаты полюса xp = 2.55060238e-7, yp = 1.860359247e-6 радиан
+	record := eopRecord{x: 2.55060238e-7 * arcsecondsInRadian, y: 1.860359247e-6 * arcsecondsInRadian, ut1MinusAT: ttMinusTAI}
+	orientation := NewEarthOrientation()
+	for _, mjd := range []float64{53735, 53737} {
+		record.mjd = mjd
+		orientation.records = append(orientation.records, record)
+	}
+	transform, err := orientation.CalculateTransform(modifiedJulianDateOffset, 53736)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testMatrix(t, "C2T", transform.Rotation, Matrix{
+		{-0.1810332128528685730, 0.9834769806897685071, 0.6555535639982634449e-4},
+		{-0.9834768134095211257, -0.1810332203871023800, 0.5749801116126438962e-3},
+		{0.5773474014081539467e-3, 0.3961832391768640871e-4, 0.9999998325501691969},
+	}, nutationTolerance)
+}
@@ -0,0 +1,557 @@
+package rightround
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Типы затмений.
+const (
+	EclipseCodePartial   = 1 // частное
+	EclipseCodeAnnular   = 2 // кольцеобразное
+	EclipseCodeTotal     = 3 // полное
+	EclipseCodeHybrid    = 4 // гибридное (кольцеобразно-полное)
+	EclipseCodePenumbral = 5 // полутеневое лунное
+)
+
+// Параметры поиска затмений, в сутках.
+const (
+	eclipseSearchHalfWidth = 0.5  // окрестность новолуния или полнолуния для поиска максимальной фазы
+	eclipseContactRange    = 0.3  // окрестность максимальной фазы для поиска контактов
+	eclipseContactStep     = 0.01 // шаг поиска контактов
+	eclipseMaximumStep     = 0.05 // шаг поиска максимальной фазы
+	localEclipseMargin     = 0.05 // запас при поиске местных обстоятельств
+)
+
+// danjonEnlargement относительное увеличение радиуса Земли за счёт атмосферы (правило Данжона).
+const danjonEnlargement = 1.0 / 85
+
+// SolarEclipse общие обстоятельства солнечного затмения. Моменты задаются в шкале времени TimeScale,
+// отсутствующие контакты (например, центральной тени при частном затмении) равны 0.
+type SolarEclipse struct {
+	Code      int
+	TimeScale int
+	Maximum   float64 // момент наибольшей фазы (наименьшего расстояния оси тени от центра Земли)
+	Magnitude float64 // наибольшая фаза в долях диаметра Солнца
+	Gamma     float64 // наименьшее расстояние оси тени от центра Земли в экваториальных радиусах Земли
+	P1, P4    float64 // начало и конец частного затмения на Земле (контакты полутени)
+	U1, U4    float64 // начало и конец центрального затмения на Земле (контакты тени или антитени)
+}
+
+// LocalSolarEclipse местные обстоятельства солнечного затмения. Code равен 0, если затмение
+// в месте наблюдения не происходит. Моменты задаются в шкале времени TimeScale.
+type LocalSolarEclipse struct {
+	Code         int
+	TimeScale    int
+	Maximum      float64 // момент наибольшей фазы
+	Magnitude    float64 // наибольшая фаза в долях диаметра Солнца
+	C1, C2       float64 // первый и второй контакты (второй - только для полного и кольцеобразного затмений)
+	C3, C4       float64 // третий и четвёртый контакты
+	SunElevation float64 // высота Солнца над горизонтом в момент наибольшей фазы, радианы
+}
+
+// CentralLinePoint точка линии центрального затмения.
+type CentralLinePoint struct {
+	JulianDate float64 // момент в шкале времени затмения
+	Latitude   float64 // геодезическая широта, радианы
+	Longitude  float64 // восточная долгота, радианы
+	Magnitude  float64 // отношение видимых диаметров Луны и Солнца
+}
+
+// LunarEclipse обстоятельства лунного затмения. Моменты задаются в шкале времени TimeScale,
+// отсутствующие контакты равны 0.
+type LunarEclipse struct {
+	Code               int
+	TimeScale          int
+	Maximum            float64 // момент наибольшей фазы
+	UmbralMagnitude    float64 // теневая фаза в долях диаметра Луны
+	PenumbralMagnitude float64 // полутеневая фаза в долях диаметра Луны
+	P1, P4             float64 // начало и конец полутеневого затмения
+	U1, U4             float64 // начало и конец частного (теневого) затмения
+	U2, U3             float64 // начало и конец полного затмения
+}
+
+// solarShadow геометрия тени Луны относительно Земли на заданный момент. Координаты приведены
+// к сфере с экваториальным радиусом Земли растяжением вдоль оси вращения.
+type solarShadow struct {
+	moon, sun Coords  // геоцентрические видимые положения Луны и Солнца
+	axis      Coords  // единичный вектор оси тени (от Солнца к Луне)
+	delta     float64 // расстояние оси тени от центра Земли
+	penumbra  float64 // радиус полутени в основной плоскости
+	umbra     float64 // радиус тени в основной плоскости (отрицательный для антитени)
+	distance  float64 // расстояние от Луны до основной плоскости
+
+	umbraTangent float64 // тангенс угла раствора конуса тени
+}
+
+// eclipseRadii возвращает радиусы Солнца, Луны и экваториальный и полярный радиусы Земли
+// в установленных единицах расстояния.
+func (e *Ephemeris) eclipseRadii() (float64, float64, float64, float64, error) {
+	sun, err := e.BodyEllipsoid(EphemerisSun)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	moon, err := e.BodyEllipsoid(EphemerisMoon)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	earth, err := e.BodyEllipsoid(EphemerisEarth)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	f := e.distanceScalingFactor
+	return sun.A * f, moon.A * f, earth.A * f, earth.C * f, nil
+}
+
+// calculateSolarShadow вычисляет геометрию тени Луны на дату TDB.
+func (e *Ephemeris) calculateSolarShadow(date float64) (solarShadow, error) {
+	sunRadius, moonRadius, earthRadius, polarRadius, err := e.eclipseRadii()
+	if err != nil {
+		return solarShadow{}, err
+	}
+	moon, err := e.CalculateGeocentricApparentCoords(EphemerisMoon, date, 0)
+	if err != nil {
+		return solarShadow{}, err
+	}
+	sun, err := e.CalculateGeocentricApparentCoords(EphemerisSun, date, 0)
+	if err != nil {
+		return solarShadow{}, err
+	}
+	transform, err := e.CalculateEarthTransform(date, 0)
+	if err != nil {
+		return solarShadow{}, err
+	}
+	// растяжение вдоль оси вращения Земли превращает земной эллипсоид в сферу
+	pole := Coords{X: transform.Rotation[2][0], Y: transform.Rotation[2][1], Z: transform.Rotation[2][2]}
+	stretch := func(c Coords) Coords {
+		return c.sub(pole.scale(-(earthRadius/polarRadius - 1) * c.dot(pole)))
+	}
+	shadow := solarShadow{moon: moon, sun: sun}
+	moon, sun = stretch(moon), stretch(sun)
+
+	sunToMoon := moon.sub(sun)
+	length := sunToMoon.length()
+	shadow.axis = sunToMoon.scale(1 / length)
+	shadow.distance = -moon.dot(shadow.axis)
+	shadow.delta = moon.sub(shadow.axis.scale(moon.dot(shadow.axis))).length()
+
+	penumbraAngle := math.Asin((sunRadius + moonRadius) / length)
+	umbraAngle := math.Asin((sunRadius - moonRadius) / length)
+	shadow.penumbra = moonRadius/math.Cos(penumbraAngle) + shadow.distance*math.Tan(penumbraAngle)
+	shadow.umbraTangent = math.Tan(umbraAngle)
+	shadow.umbra = moonRadius/math.Cos(umbraAngle) - shadow.distance*shadow.umbraTangent
+	return shadow, nil
+}
+
+// findContacts находит моменты, когда функция f обращается в ноль до и после момента maximum
+// (в пределах eclipseContactRange). Если смены знака нет, соответствующий момент равен 0.
+func findContacts(f func(date float64) (float64, error), maximum float64) (float64, float64, error) {
+	before, err := findRoots(f, maximum-eclipseContactRange, maximum, constantStep(eclipseContactStep), defaultSearchTolerance)
+	if err != nil {
+		return 0, 0, err
+	}
+	after, err := findRoots(f, maximum, maximum+eclipseContactRange, constantStep(eclipseContactStep), defaultSearchTolerance)
+	if err != nil {
+		return 0, 0, err
+	}
+	var first, last float64
+	if len(before) > 0 {
+		first = before[len(before)-1].date
+	}
+	if len(after) > 0 {
+		last = after[0].date
+	}
+	return first, last, nil
+}
+
+// findSyzygyMinimum находит момент наименьшего значения величины в окрестности новолуния или полнолуния.
+func findSyzygyMinimum(quantity Quantity, syzygy float64) (float64, error) {
+	confine := Window{{Start: syzygy - eclipseSearchHalfWidth, End: syzygy + eclipseSearchHalfWidth}}
+	minimum, err := Search(quantity, SearchCodeAbsMin, 0, confine, SearchOptions{Step: eclipseMaximumStep})
+	if err != nil || len(minimum) == 0 {
+		return syzygy, err
+	}
+	return minimum[0].Start, nil
+}
+
+// FindSolarEclipses находит солнечные затмения в интервале [start, end]. Границы интервала
+// и моменты событий задаются в шкале времени timeScale.
+func (e *Ephemeris) FindSolarEclipses(start, end float64, timeScale int) ([]SolarEclipse, error) {
+	start, end, err := e.toTDBInterval(start, end, timeScale)
+	if err != nil {
+		return nil, err
+	}
+	_, _, earthRadius, _, err := e.eclipseRadii()
+	if err != nil {
+		return nil, err
+	}
+	newMoons, err := e.findLongitudeEvents(EphemerisMoon, EphemerisSun, 0, start, end, moonPhaseSearchStep)
+	if err != nil {
+		return nil, err
+	}
+
+	delta := func(date float64) (float64, error) {
+		shadow, err := e.calculateSolarShadow(date)
+		return shadow.delta, err
+	}
+	var eclipses []SolarEclipse
+	for _, newMoon := range newMoons {
+		if !newMoon.increasing {
+			continue
+		}
+		maximum, err := findSyzygyMinimum(delta, newMoon.date)
+		if err != nil {
+			return nil, err
+		}
+		shadow, err := e.calculateSolarShadow(maximum)
+		if err != nil {
+			return nil, err
+		}
+		if shadow.delta >= earthRadius+shadow.penumbra {
+			continue
+		}
+
+		eclipse := SolarEclipse{TimeScale: timeScale, Maximum: maximum, Gamma: shadow.delta / earthRadius}
+		if shadow.delta < earthRadius {
+			// ось тени пересекает Землю: тип затмения определяется радиусом тени в точке пересечения
+			// и в основной плоскости
+			// (у края Земли - в основной плоскости, в середине полосы - ближе к Луне)
+			surface := math.Sqrt(earthRadius*earthRadius - shadow.delta*shadow.delta)
+			umbraAtSurface := shadow.umbra + surface*shadow.umbraTangent
+			switch {
+			case shadow.umbra > 0:
+				eclipse.Code = EclipseCodeTotal
+			case umbraAtSurface > 0:
+				eclipse.Code = EclipseCodeHybrid
+			default:
+				eclipse.Code = EclipseCodeAnnular
+			}
+			point, ok, err := e.calculateCentralPoint(maximum)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				eclipse.Magnitude = point.Magnitude
+			}
+		} else {
+			eclipse.Code = EclipseCodePartial
+			eclipse.Magnitude = (shadow.penumbra - (shadow.delta - earthRadius)) / (shadow.penumbra - shadow.umbra)
+		}
+
+		penumbra := func(date float64) (float64, error) {
+			shadow, err := e.calculateSolarShadow(date)
+			return shadow.delta - earthRadius - shadow.penumbra, err
+		}
+		if eclipse.P1, eclipse.P4, err = findContacts(penumbra, maximum); err != nil {
+			return nil, err
+		}
+		if shadow.delta < earthRadius+math.Abs(shadow.umbra) {
+			umbra := func(date float64) (float64, error) {
+				shadow, err := e.calculateSolarShadow(date)
+				return shadow.delta - earthRadius - math.Abs(shadow.umbra), err
+			}
+			if eclipse.U1, eclipse.U4, err = findContacts(umbra, maximum); err != nil {
+				return nil, err
+			}
+		}
+		eclipses = append(eclipses, eclipse)
+	}
+	if err := e.convertSolarEclipses(eclipses, timeScale); err != nil {
+		return nil, err
+	}
+	return eclipses, nil
+}
+
+// convertSolarEclipses переводит моменты солнечных затмений из TDB в заданную шкалу времени.
+func (e *Ephemeris) convertSolarEclipses(eclipses []SolarEclipse, timeScale int) error {
+	for i := range eclipses {
+		if err := e.convertDates(timeScale, &eclipses[i].Maximum, &eclipses[i].P1, &eclipses[i].P4, &eclipses[i].U1, &eclipses[i].U4); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertDates переводит ненулевые юлианские даты из TDB в заданную шкалу времени.
+func (e *Ephemeris) convertDates(timeScale int, dates ...*float64) error {
+	for _, date := range dates {
+		if *date == 0 {
+			continue
+		}
+		converted, err := e.ConvertTimeScale(*date, TimeScaleCodeTDB, timeScale)
+		if err != nil {
+			return err
+		}
+		*date = converted
+	}
+	return nil
+}
+
+// calculateCentralPoint вычисляет точку пересечения оси тени Луны с поверхностью Земли на дату TDB.
+// Если ось тени не пересекает Землю, возвращается false.
+func (e *Ephemeris) calculateCentralPoint(date float64) (CentralLinePoint, bool, error) {
+	sunRadius, moonRadius, _, _, err := e.eclipseRadii()
+	if err != nil {
+		return CentralLinePoint{}, false, err
+	}
+	earth, err := e.BodyEllipsoid(EphemerisEarth)
+	if err != nil {
+		return CentralLinePoint{}, false, err
+	}
+	moon, err := e.CalculateGeocentricApparentCoords(EphemerisMoon, date, 0)
+	if err != nil {
+		return CentralLinePoint{}, false, err
+	}
+	sun, err := e.CalculateGeocentricApparentCoords(EphemerisSun, date, 0)
+	if err != nil {
+		return CentralLinePoint{}, false, err
+	}
+	transform, err := e.CalculateEarthTransform(date, 0)
+	if err != nil {
+		return CentralLinePoint{}, false, err
+	}
+
+	// луч от Луны вдоль оси тени в земной системе координат (в километрах)
+	origin := transform.Rotation.Apply(moon).scale(1 / e.distanceScalingFactor)
+	direction := transform.Rotation.Apply(moon.sub(sun))
+	direction = direction.scale(1 / direction.length())
+	// пересечение луча с эллипсоидом: квадратное уравнение относительно расстояния вдоль луча
+	weights := Coords{X: 1 / (earth.A * earth.A), Y: 1 / (earth.B * earth.B), Z: 1 / (earth.C * earth.C)}
+	a := direction.X*direction.X*weights.X + direction.Y*direction.Y*weights.Y + direction.Z*direction.Z*weights.Z
+	b := 2 * (origin.X*direction.X*weights.X + origin.Y*direction.Y*weights.Y + origin.Z*direction.Z*weights.Z)
+	c := origin.X*origin.X*weights.X + origin.Y*origin.Y*weights.Y + origin.Z*origin.Z*weights.Z - 1
+	discriminant := b*b - 4*a*c
+	if discriminant < 0 {
+		return CentralLinePoint{}, false, nil
+	}
+	t := (-b - math.Sqrt(discriminant)) / (2 * a)
+	surface := Coords{X: origin.X + t*direction.X, Y: origin.Y + t*direction.Y, Z: origin.Z + t*direction.Z}
+	latitude, longitude, _ := earth.CartesianToGeodetic(surface)
+
+	// отношение видимых диаметров Луны и Солнца из точки на поверхности
+	surfaceICRF := transform.Rotation.Transpose().Apply(surface).scale(e.distanceScalingFactor)
+	moonAngle := math.Asin(moonRadius / moon.sub(surfaceICRF).length())
+	sunAngle := math.Asin(sunRadius / sun.sub(surfaceICRF).length())
+	return CentralLinePoint{JulianDate: date, Latitude: latitude, Longitude: longitude, Magnitude: moonAngle / sunAngle}, true, nil
+}
+
+// CalculateCentralLine вычисляет точки линии центрального затмения с шагом step (в сутках)
+// между моментами U1 и U4 затмения.
+func (e *Ephemeris) CalculateCentralLine(eclipse SolarEclipse, step float64) ([]CentralLinePoint, error) {
+	if eclipse.U1 == 0 || eclipse.U4 == 0 {
+		return nil, errors.New("eclipse is not central")
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("bad central line step: %f", step)
+	}
+	start, end, err := e.toTDBInterval(eclipse.U1, eclipse.U4, eclipse.TimeScale)
+	if err != nil {
+		return nil, err
+	}
+	var points []CentralLinePoint
+	for date := start; date <= end; date += step {
+		point, ok, err := e.calculateCentralPoint(date)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if err := e.convertDates(eclipse.TimeScale, &point.JulianDate); err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// localDisks возвращает угловое расстояние между центрами Солнца и Луны и их видимые угловые радиусы
+// (в радианах) для наблюдателя на дату TDB.
+func (e *Ephemeris) localDisks(observer Observer, date float64) (float64, float64, float64, error) {
+	sunRadius, moonRadius, _, _, err := e.eclipseRadii()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	sun, err := e.CalculateApparentCoords(EphemerisSun, observer, date, 0)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	moon, err := e.CalculateApparentCoords(EphemerisMoon, observer, date, 0)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return angleBetween(sun, moon), math.Asin(sunRadius / sun.length()), math.Asin(moonRadius / moon.length()), nil
+}
+
+// CalculateLocalSolarEclipse вычисляет местные обстоятельства солнечного затмения для наблюдателя
+// (по топоцентрическим видимым положениям Солнца и Луны, без учёта рефракции).
+func (e *Ephemeris) CalculateLocalSolarEclipse(eclipse SolarEclipse, observer Observer) (LocalSolarEclipse, error) {
+	start, end, err := e.toTDBInterval(eclipse.P1, eclipse.P4, eclipse.TimeScale)
+	if err != nil {
+		return LocalSolarEclipse{}, err
+	}
+	separation := func(date float64) (float64, error) {
+		value, _, _, err := e.localDisks(observer, date)
+		return value, err
+	}
+	confine := Window{{Start: start - localEclipseMargin, End: end + localEclipseMargin}}
+	minimum, err := Search(separation, SearchCodeAbsMin, 0, confine, SearchOptions{Step: eclipseContactStep})
+	if err != nil || len(minimum) == 0 {
+		return LocalSolarEclipse{}, err
+	}
+
+	local := LocalSolarEclipse{TimeScale: eclipse.TimeScale, Maximum: minimum[0].Start}
+	value, sunAngle, moonAngle, err := e.localDisks(observer, local.Maximum)
+	if err != nil {
+		return LocalSolarEclipse{}, err
+	}
+	if value >= sunAngle+moonAngle {
+		return local, nil
+	}
+	local.Magnitude = (sunAngle + moonAngle - value) / (2 * sunAngle)
+	switch {
+	case value > math.Abs(sunAngle-moonAngle):
+		local.Code = EclipseCodePartial
+	case moonAngle >= sunAngle:
+		local.Code = EclipseCodeTotal
+	default:
+		local.Code = EclipseCodeAnnular
+	}
+
+	external := func(date float64) (float64, error) {
+		value, sunAngle, moonAngle, err := e.localDisks(observer, date)
+		return value - sunAngle - moonAngle, err
+	}
+	if local.C1, local.C4, err = findContacts(external, local.Maximum); err != nil {
+		return LocalSolarEclipse{}, err
+	}
+	if local.Code != EclipseCodePartial {
+		internal := func(date float64) (float64, error) {
+			value, sunAngle, moonAngle, err := e.localDisks(observer, date)
+			return value - math.Abs(sunAngle-moonAngle), err
+		}
+		if local.C2, local.C3, err = findContacts(internal, local.Maximum); err != nil {
+			return LocalSolarEclipse{}, err
+		}
+	}
+
+	horizontal, err := e.CalculateHorizontalCoords(EphemerisSun, observer, local.Maximum, 0, nil)
+	if err != nil {
+		return LocalSolarEclipse{}, err
+	}
+	local.SunElevation = horizontal.Elevation
+	return local, e.convertDates(local.TimeScale, &local.Maximum, &local.C1, &local.C2, &local.C3, &local.C4)
+}
+
+// lunarShadow геометрия тени Земли на расстоянии Луны.
+type lunarShadow struct {
+	delta      float64 // расстояние центра Луны от оси тени
+	penumbra   float64 // радиус полутени
+	umbra      float64 // радиус тени
+	moonRadius float64 // радиус Луны
+}
+
+// calculateLunarShadow вычисляет геометрию тени Земли на дату TDB. Радиус Земли берётся средним
+// по сжатию и увеличивается за счёт атмосферы по правилу Данжона.
+func (e *Ephemeris) calculateLunarShadow(date float64) (lunarShadow, error) {
+	sunRadius, moonRadius, earthRadius, polarRadius, err := e.eclipseRadii()
+	if err != nil {
+		return lunarShadow{}, err
+	}
+	moon, err := e.CalculateGeocentricApparentCoords(EphemerisMoon, date, 0)
+	if err != nil {
+		return lunarShadow{}, err
+	}
+	sun, err := e.CalculateGeocentricApparentCoords(EphemerisSun, date, 0)
+	if err != nil {
+		return lunarShadow{}, err
+	}
+	radius := (2*earthRadius + polarRadius) / 3 * (1 + danjonEnlargement)
+	sunDistance := sun.length()
+	// ось тени направлена от Солнца через центр Земли
+	axis := sun.scale(-1 / sunDistance)
+	distance := moon.dot(axis)
+	delta := moon.sub(axis.scale(distance)).length()
+
+	penumbraAngle := math.Asin((sunRadius + radius) / sunDistance)
+	umbraAngle := math.Asin((sunRadius - radius) / sunDistance)
+	return lunarShadow{
+		delta:      delta,
+		penumbra:   radius/math.Cos(penumbraAngle) + distance*math.Tan(penumbraAngle),
+		umbra:      radius/math.Cos(umbraAngle) - distance*math.Tan(umbraAngle),
+		moonRadius: moonRadius,
+	}, nil
+}
+
+// FindLunarEclipses находит лунные затмения в интервале [start, end]. Границы интервала
+// и моменты событий задаются в шкале времени timeScale.
+func (e *Ephemeris) FindLunarEclipses(start, end float64, timeScale int) ([]LunarEclipse, error) {
+	start, end, err := e.toTDBInterval(start, end, timeScale)
+	if err != nil {
+		return nil, err
+	}
+	fullMoons, err := e.findLongitudeEvents(EphemerisMoon, EphemerisSun, math.Pi, start, end, moonPhaseSearchStep)
+	if err != nil {
+		return nil, err
+	}
+
+	// контактная функция: расстояние центра Луны от оси тени за вычетом заданной комбинации радиусов
+	contact := func(radius func(s lunarShadow) float64) func(date float64) (float64, error) {
+		return func(date float64) (float64, error) {
+			shadow, err := e.calculateLunarShadow(date)
+			return shadow.delta - radius(shadow), err
+		}
+	}
+	delta := contact(func(lunarShadow) float64 { return 0 })
+
+	var eclipses []LunarEclipse
+	for _, fullMoon := range fullMoons {
+		if !fullMoon.increasing {
+			continue
+		}
+		maximum, err := findSyzygyMinimum(delta, fullMoon.date)
+		if err != nil {
+			return nil, err
+		}
+		shadow, err := e.calculateLunarShadow(maximum)
+		if err != nil {
+			return nil, err
+		}
+		eclipse := LunarEclipse{
+			TimeScale:          timeScale,
+			Maximum:            maximum,
+			UmbralMagnitude:    (shadow.umbra + shadow.moonRadius - shadow.delta) / (2 * shadow.moonRadius),
+			PenumbralMagnitude: (shadow.penumbra + shadow.moonRadius - shadow.delta) / (2 * shadow.moonRadius),
+		}
+		switch {
+		case eclipse.PenumbralMagnitude <= 0:
+			continue
+		case eclipse.UmbralMagnitude >= 1:
+			eclipse.Code = EclipseCodeTotal
+		case eclipse.UmbralMagnitude > 0:
+			eclipse.Code = EclipseCodePartial
+		default:
+			eclipse.Code = EclipseCodePenumbral
+		}
+
+		if eclipse.P1, eclipse.P4, err = findContacts(contact(func(s lunarShadow) float64 { return s.penumbra + s.moonRadius }), maximum); err != nil {
+			return nil, err
+		}
+		if eclipse.Code != EclipseCodePenumbral {
+			if eclipse.U1, eclipse.U4, err = findContacts(contact(func(s lunarShadow) float64 { return s.umbra + s.moonRadius }), maximum); err != nil {
+				return nil, err
+			}
+		}
+		if eclipse.Code == EclipseCodeTotal {
+			if eclipse.U2, eclipse.U3, err = findContacts(contact(func(s lunarShadow) float64 { return s.umbra - s.moonRadius }), maximum); err != nil {
+				return nil, err
+			}
+		}
+		if err := e.convertDates(timeScale, &eclipse.Maximum, &eclipse.P1, &eclipse.P4, &eclipse.U1, &eclipse.U4, &eclipse.U2, &eclipse.U3); err != nil {
+			return nil, err
+		}
+		eclipses = append(eclipses, eclipse)
+	}
+	return eclipses, nil
+}
@@ -0,0 +1,329 @@
+package rightround
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// testMoonTerms главные периодические члены долготы (10^-6 градуса) и расстояния (10^-3 км) Луны
+// по аргументам D, M, M', F (Meeus, Astronomical Algorithms, табл. 47.A).
+var testMoonTerms = []struct{ d, m, mp, f, l, r float64 }{
+	{0, 0, 1, 0, 6288774, -20905355},
+	{2, 0, -1, 0, 1274027, -3699111},
+	{2, 0, 0, 0, 658314, -2955968},
+	{0, 0, 2, 0, 213618, -569925},
+	{0, 1, 0, 0, -185116, 48888},
+	{0, 0, 0, 2, -114332, -3149},
+	{2, 0, -2, 0, 58793, 246158},
+	{2, -1, -1, 0, 57066, -152138},
+	{2, 0, 1, 0, 53322, -170733},
+	{2, -1, 0, 0, 45758, -204586},
+	{0, 1, -1, 0, -40923, -129620},
+	{1, 0, 0, 0, -34720, 108743},
+	{0, 1, 1, 0, -30383, 104755},
+	{2, 0, 0, -2, 15327, 10321},
+	{0, 0, 1, 2, -12528, 0},
+	{0, 0, 1, -2, 10980, 79661},
+	{4, 0, -1, 0, 10675, -34782},
+	{0, 0, 3, 0, 10034, -23210},
+	{4, 0, -2, 0, 8548, -21636},
+	{2, 1, -1, 0, -7888, 24208},
+	{2, 1, 0, 0, -6766, 30824},
+	{1, 0, -1, 0, -5163, -8379},
+	{1, 1, 0, 0, 4987, -16675},
+	{2, -1, 1, 0, 4036, -12831},
+	{2, 0, 2, 0, 3994, -10445},
+	{4, 0, 0, 0, 3861, -11650},
+	{2, 0, -3, 0, 3665, 14403},
+	{0, 1, -2, 0, -2689, -7003},
+	{2, 0, -1, 2, -2602, 0},
+	{2, -1, -2, 0, 2390, 10056},
+	{1, 0, 1, 0, -2348, 6322},
+	{2, -2, 0, 0, 2236, -9884},
+	{0, 1, 2, 0, -2120, 5751},
+	{0, 2, 0, 0, -2069, 0},
+	{2, -2, -1, 0, 2048, -4950},
+	{2, 0, 1, -2, -1773, 4130},
+	{2, 0, 0, 2, -1595, 0},
+	{4, -1, -1, 0, 1215, -3958},
+	{0, 0, 2, 2, -1110, 0},
+	{3, 0, -1, 0, -892, 3258},
+	{2, 1, 1, 0, -810, 2616},
+	{4, -1, -2, 0, 759, -1897},
+	{0, 2, -1, 0, -713, -2117},
+	{2, 2, -1, 0, -700, 2354},
+	{2, 1, -2, 0, 691, 0},
+	{2, -1, 0, -2, 596, 0},
+	{4, 0, 1, 0, 549, -1423},
+	{0, 0, 4, 0, 537, -1117},
+	{4, -1, 0, 0, 520, -1571},
+	{1, 0, -2, 0, -487, -1739},
+	{2, 1, 0, -2, -399, 0},
+	{0, 0, 2, -2, -381, -4421},
+	{1, 1, 1, 0, 351, 0},
+	{3, 0, -2, 0, -340, 0},
+	{4, 0, -3, 0, 330, 0},
+	{2, -1, 2, 0, 327, 0},
+	{0, 2, 1, 0, -323, 1165},
+	{1, 1, -1, 0, 299, 0},
+	{2, 0, 3, 0, 294, 0},
+	{2, 0, -1, -2, 0, 8752},
+}
+
+// testMoonLatitudeTerms главные периодические члены широты Луны (10^-6 градуса, табл. 47.B).
+var testMoonLatitudeTerms = []struct{ d, m, mp, f, b float64 }{
+	{0, 0, 0, 1, 5128122},
+	{0, 0, 1, 1, 280602},
+	{0, 0, 1, -1, 277693},
+	{2, 0, 0, -1, 173237},
+	{2, 0, -1, 1, 55413},
+	{2, 0, -1, -1, 46271},
+	{2, 0, 0, 1, 32573},
+	{0, 0, 2, 1, 17198},
+	{2, 0, 1, -1, 9266},
+	{0, 0, 2, -1, 8822},
+	{2, -1, 0, -1, 8216},
+	{2, 0, -2, -1, 4324},
+	{2, 0, 1, 1, 4200},
+	{2, 1, 0, -1, -3359},
+	{2, -1, -1, 1, 2463},
+	{2, -1, 0, 1, 2211},
+	{2, -1, -1, -1, 2065},
+	{0, 1, -1, -1, -1870},
+	{4, 0, -1, -1, 1828},
+	{0, 1, 0, 1, -1794},
+	{0, 0, 0, 3, -1749},
+	{0, 1, -1, 1, -1565},
+	{1, 0, 0, 1, -1491},
+	{0, 1, 1, 1, -1475},
+	{0, 1, 1, -1, -1410},
+	{0, 1, 0, -1, -1344},
+	{1, 0, 0, -1, -1335},
+	{0, 0, 3, 1, 1107},
+	{4, 0, 0, -1, 1021},
+	{4, 0, -1, 1, 833},
+	{0, 0, 1, -3, 777},
+	{4, 0, -2, 1, 671},
+	{2, 0, 0, -3, 607},
+	{2, 0, 2, -1, 596},
+	{2, -1, 1, -1, 491},
+	{2, 0, -2, 1, -451},
+	{0, 0, 3, -1, 439},
+	{2, 0, 2, 1, 422},
+	{2, 0, -3, -1, 421},
+	{2, 1, -1, 1, -366},
+	{2, 1, 0, 1, -351},
+	{4, 0, 0, 1, 331},
+	{2, -1, 1, 1, 315},
+	{2, -2, 0, -1, 302},
+	{0, 0, 1, 3, -283},
+}
+
+// testDegrees вычисляет полином от столетий с коэффициентами в градусах и переводит результат в радианы.
+func testDegrees(centuries float64, coefficients ...float64) float64 {
+	value, _ := calcPolynomial(coefficients, centuries)
+	return math.Mod(value, 360) * math.Pi / 180
+}
+
+// testMoonEcliptic вычисляет геоцентрические долготу и широту (в радианах) Луны относительно
+// средних эклиптики и равноденствия даты и её расстояние (в километрах) по сокращённой теории ELP-2000/82
+// (воспроизводит пример 47.a из Meeus с точностью 0.1").
+func testMoonEcliptic(centuries float64) (float64, float64, float64) {
+	meanLongitude := testDegrees(centuries, 218.3164477, 481267.88123421, -0.0015786, 1.0/538841, -1.0/65194000)
+	d := testDegrees(centuries, 297.8501921, 445267.1114034, -0.0018819, 1.0/545868, -1.0/113065000)
+	m := testDegrees(centuries, 357.5291092, 35999.0502909, -0.0001536, 1.0/24490000)
+	mp := testDegrees(centuries, 134.9633964, 477198.8675055, 0.0087414, 1.0/69699, -1.0/14712000)
+	f := testDegrees(centuries, 93.2720950, 483202.0175233, -0.0036539, -1.0/3526000, 1.0/863310000)
+	a1 := testDegrees(centuries, 119.75, 131.849)
+	a2 := testDegrees(centuries, 53.09, 479264.290)
+	a3 := testDegrees(centuries, 313.45, 481266.484)
+	// уменьшение эксцентриситета орбиты Земли
+	eccentricity := 1 - 0.002516*centuries - 0.0000074*centuries*centuries
+	factor := func(m float64) float64 {
+		return math.Pow(eccentricity, math.Abs(m))
+	}
+
+	var longitude, distance, latitude float64
+	for _, term := range testMoonTerms {
+		sin, cos := math.Sincos(term.d*d + term.m*m + term.mp*mp + term.f*f)
+		longitude += term.l * factor(term.m) * sin
+		distance += term.r * factor(term.m) * cos
+	}
+	for _, term := range testMoonLatitudeTerms {
+		latitude += term.b * factor(term.m) * math.Sin(term.d*d+term.m*m+term.mp*mp+term.f*f)
+	}
+	longitude += 3958*math.Sin(a1) + 1962*math.Sin(meanLongitude-f) + 318*math.Sin(a2)
+	latitude += -2235*math.Sin(meanLongitude) + 382*math.Sin(a3) + 175*math.Sin(a1-f) + 175*math.Sin(a1+f) +
+		127*math.Sin(meanLongitude-mp) - 115*math.Sin(meanLongitude+mp)
+	return meanLongitude + longitude*1e-6*math.Pi/180, latitude * 1e-6 * math.Pi / 180, 385000.56 + distance/1000
+}
+
+// testSunEcliptic вычисляет геометрическую долготу Солнца (в радианах) относительно средних эклиптики
+// и равноденствия даты и его расстояние (в километрах) по кеплеровой орбите с вековыми изменениями элементов.
+func testSunEcliptic(centuries float64) (float64, float64) {
+	meanLongitude := testDegrees(centuries, 280.46646, 36000.76983, 0.0003032)
+	m := testDegrees(centuries, 357.52911, 35999.05029, -0.0001537)
+	eccentricity := 0.016708634 - 0.000042037*centuries - 0.0000001267*centuries*centuries
+	center := (1.914602-0.004817*centuries-0.000014*centuries*centuries)*math.Sin(m) +
+		(0.019993-0.000101*centuries)*math.Sin(2*m) + 0.000289*math.Sin(3*m)
+	anomaly := m + center*math.Pi/180
+	distance := 1.000001018 * (1 - eccentricity*eccentricity) / (1 + eccentricity*math.Cos(anomaly))
+	return meanLongitude + center*math.Pi/180, distance * kilometersInAU
+}
+
+// testEclipticToICRF переводит сферические координаты относительно средних эклиптики и равноденствия даты в ICRF.
+func testEclipticToICRF(centuries, longitude, latitude, distance float64) Coords {
+	// матрица перехода из GCRS к средним экватору и равноденствию даты, повёрнутая к эклиптике даты
+	precession, eps := precessionNutation(centuries, 0, 0)
+	toEcliptic := rotationX(eps).Mul(precession)
+	sinLongitude, cosLongitude := math.Sincos(longitude)
+	sinLatitude, cosLatitude := math.Sincos(latitude)
+	ecliptic := Coords{X: distance * cosLatitude * cosLongitude, Y: distance * cosLatitude * sinLongitude, Z: distance * sinLatitude}
+	return toEcliptic.Transpose().Apply(ecliptic)
+}
+
+// testEarthMoonRatio отношение масс Земли и Луны.
+const testEarthMoonRatio = 81.30056
+
+// testGeocentric возвращает геоцентрические положения Луны и Солнца в ICRF на юлианскую дату TDB.
+func testGeocentric(date float64) (Coords, Coords) {
+	centuries := (date - julianDate2000) / daysInCentury
+	moonLongitude, moonLatitude, moonDistance := testMoonEcliptic(centuries)
+	sunLongitude, sunDistance := testSunEcliptic(centuries)
+	return testEclipticToICRF(centuries, moonLongitude, moonLatitude, moonDistance),
+		testEclipticToICRF(centuries, sunLongitude, 0, sunDistance)
+}
+
+// testDifferentiate дополняет функцию положений скоростями, вычисленными центральными разностями.
+func testDifferentiate(position func(date float64) Coords) StateFunc {
+	const step = 1.0 / 1440
+	return func(date1, date2 float64) (Coords, Coords, error) {
+		date := date1 + date2
+		velocity := position(date + step).sub(position(date - step)).scale(1 / (2 * step * secondsInDay))
+		return position(date), velocity, nil
+	}
+}
+
+// testEclipseEphemeris записывает и загружает эфемериды Солнца, Земли и Луны на интервале [start, end]
+// по аналитическим теориям движения Луны и Солнца (точность порядка 10").
+// Барицентр Солнечной системы совмещён с центром Солнца.
+func testEclipseEphemeris(t *testing.T, start, end float64) *Ephemeris {
+	moon := func(date float64) Coords {
+		moon, _ := testGeocentric(date)
+		return moon
+	}
+	earthMoon := func(date float64) Coords {
+		moon, sun := testGeocentric(date)
+		return moon.scale(1 / (1 + testEarthMoonRatio)).sub(sun)
+	}
+	path := filepath.Join(tempDir(t), "eclipse.bsp")
+	writer := NewSPKWriter(path, "test eclipse")
+	for _, segment := range []struct {
+		object, center int
+		position       func(date float64) Coords
+	}{
+		{EphemerisSun, EphemerisSunSystem, func(float64) Coords { return Coords{} }},
+		{EphemerisEarthMoon, EphemerisSunSystem, earthMoon},
+		{EphemerisEarth, EphemerisEarthMoon, func(date float64) Coords { return moon(date).scale(-1 / (1 + testEarthMoonRatio)) }},
+		{EphemerisMoon, EphemerisEarthMoon, func(date float64) Coords {
+			return moon(date).scale(testEarthMoonRatio / (1 + testEarthMoonRatio))
+		}},
+	} {
+		spec := SegmentSpec{Object: segment.object, Center: segment.center, Representation: representationPositionOnly,
+			Start: start, End: end, Tolerance: 1e-3}
+		if err := writer.AddChebyshevSegment(spec, testDifferentiate(segment.position)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Save(); err != nil {
+		t.Fatal(err)
+	}
+	ephemeris := NewEphemeris()
+	if err := ephemeris.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return ephemeris
+}
+
+// testEclipseTime проверяет момент события затмения (UTC) с точностью, допускаемой аналитическими теориями.
+func testEclipseTime(t *testing.T, name string, actual float64, expected string) {
+	t.Helper()
+	const tolerance = 90.0 / secondsInDay
+	date, err := ParseJulianDate(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(actual-date) > tolerance {
+		t.Errorf("%s %s, expected %s", name, FormatJulianDate(actual), expected)
+	}
+}
+
+func TestSolarEclipse(t *testing.T) {
+	// полное солнечное затмение 2017-08-21 (F. Espenak, NASA GSFC)
+	ephemeris := testEclipseEphemeris(t, 2457984.5, 2457989.5)
+	start, err := ParseJulianDate("2017-08-21")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eclipses, err := ephemeris.FindSolarEclipses(start, start+1, TimeScaleCodeUTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eclipses) != 1 {
+		t.Fatalf("%d eclipses", len(eclipses))
+	}
+	eclipse := eclipses[0]
+	if eclipse.Code != EclipseCodeTotal || math.Abs(eclipse.Gamma-0.4367) > 0.005 || math.Abs(eclipse.Magnitude-1.0306) > 0.001 {
+		t.Fatalf("eclipse %+v", eclipse)
+	}
+	testEclipseTime(t, "P1", eclipse.P1, "2017-08-21T15:46:48")
+	testEclipseTime(t, "U1", eclipse.U1, "2017-08-21T16:48:32")
+	testEclipseTime(t, "maximum", eclipse.Maximum, "2017-08-21T18:25:32")
+	testEclipseTime(t, "U4", eclipse.U4, "2017-08-21T20:01:58")
+	testEclipseTime(t, "P4", eclipse.P4, "2017-08-21T21:04:18")
+
+	// точка наибольшей фазы на линии центрального затмения: 36°58' N, 87°40' W
+	line, err := ephemeris.CalculateCentralLine(eclipse, 1.0/1440)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(line) == 0 || line[0].JulianDate < eclipse.U1 || line[len(line)-1].JulianDate > eclipse.U4 {
+		t.Fatalf("central line of %d points", len(line))
+	}
+	var nearest CentralLinePoint
+	for _, point := range line {
+		if math.Abs(point.JulianDate-eclipse.Maximum) < math.Abs(nearest.JulianDate-eclipse.Maximum) {
+			nearest = point
+		}
+	}
+	latitude, longitude := nearest.Latitude*180/math.Pi, nearest.Longitude*180/math.Pi
+	if math.Abs(latitude-36.967) > 0.1 || math.Abs(longitude+87.667) > 0.1 || math.Abs(nearest.Magnitude-eclipse.Magnitude) > 1e-4 {
+		t.Fatalf("central point at maximum %+v", nearest)
+	}
+}
+
+func TestLunarEclipse(t *testing.T) {
+	// полное лунное затмение 2019-01-21 (F. Espenak, NASA GSFC)
+	ephemeris := testEclipseEphemeris(t, 2458501.5, 2458506.5)
+	start, err := ParseJulianDate("2019-01-20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eclipses, err := ephemeris.FindLunarEclipses(start, start+2, TimeScaleCodeUTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eclipses) != 1 {
+		t.Fatalf("%d eclipses", len(eclipses))
+	}
+	eclipse := eclipses[0]
+	if eclipse.Code != EclipseCodeTotal || math.Abs(eclipse.UmbralMagnitude-1.195) > 0.005 || math.Abs(eclipse.PenumbralMagnitude-2.170) > 0.005 {
+		t.Fatalf("eclipse %+v", eclipse)
+	}
+	testEclipseTime(t, "U1", eclipse.U1, "2019-01-21T03:33:54")
+	testEclipseTime(t, "U2", eclipse.U2, "2019-01-21T04:41:17")
+	testEclipseTime(t, "maximum", eclipse.Maximum, "2019-01-21T05:12:14")
+	testEclipseTime(t, "U3", eclipse.U3, "2019-01-21T05:43:16")
+	testEclipseTime(t, "U4", eclipse.U4, "2019-01-21T06:50:39")
+}
@@ -0,0 +1,290 @@
+package rightround
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// KeplerianElements оскулирующие элементы орбиты относительно центрального тела в системе ICRF.
+// Единицы расстояния и времени совпадают с единицами состояния, по которому вычислены элементы.
+type KeplerianElements struct {
+	PeriapsisDistance   float64 // перицентрическое расстояние
+	SemiMajorAxis       float64 // большая полуось (отрицательная для гиперболы, бесконечная для параболы)
+	Eccentricity        float64 // эксцентриситет
+	Inclination         float64 // наклонение, радианы
+	AscendingNode       float64 // долгота восходящего узла, радианы
+	ArgumentOfPeriapsis float64 // аргумент перицентра, радианы
+	MeanAnomaly         float64 // средняя аномалия (для параболы - D + D^3/3, D = tg(ν/2)), радианы
+	TrueAnomaly         float64 // истинная аномалия, радианы
+	MeanMotion          float64 // среднее движение, радианы в единицу времени
+	GM                  float64 // гравитационный параметр центрального тела
+}
+
+// EquinoctialElements равноденственные элементы орбиты, не имеющие особенностей при малых
+// эксцентриситетах и наклонениях (для эллиптических орбит с наклонением меньше π).
+type EquinoctialElements struct {
+	SemiMajorAxis float64 // большая полуось
+	H, K          float64 // e·sin(ω+Ω), e·cos(ω+Ω)
+	P, Q          float64 // tg(i/2)·sin(Ω), tg(i/2)·cos(Ω)
+	MeanLongitude float64 // средняя долгота M+ω+Ω, радианы
+	GM            float64 // гравитационный параметр центрального тела
+}
+
+// Пороги вырожденных случаев: круговой и экваториальной орбит, параболы.
+const (
+	circularEccentricity  = 1e-11
+	equatorialInclination = 1e-11
+	parabolicEccentricity = 1e-10
+)
+
+// maxKeplerIterations наибольшее количество итераций при решении уравнения Кеплера.
+const maxKeplerIterations = 50
+
+// bodyGM встроенные гравитационные параметры тел (в км^3/с^2) по DE440.
+var bodyGM = map[int]float64{
+	EphemerisMercury:     22031.868551,
+	EphemerisVenus:       324858.592,
+	EphemerisEarthMoon:   403503.235502,
+	EphemerisMars:        42828.375816,
+	EphemerisJupiter:     126712764.1,
+	EphemerisSaturn:      37940584.8418,
+	EphemerisUranus:      5794556.4,
+	EphemerisNeptune:     6836527.10058,
+	EphemerisPluto:       975.5,
+	EphemerisSun:         132712440041.279419,
+	EphemerisMercuryBody: 22031.868551,
+	EphemerisVenusBody:   324858.592,
+	EphemerisEarth:       398600.435507,
+	EphemerisMoon:        4902.800118,
+	EphemerisMarsBody:    42828.373620,
+	EphemerisJupiterBody: 126686534.1960,
+	EphemerisSaturnBody:  37931206.2344,
+	EphemerisUranusBody:  5793951.2565,
+	EphemerisNeptuneBody: 6835103.1455,
+	EphemerisPlutoBody:   869.6138,
+}
+
+// GravitationalParameter возвращает гравитационный параметр тела (в км^3/с^2) из загруженных текстовых PCK,
+// а при его отсутствии - встроенное значение.
+func (e *Ephemeris) GravitationalParameter(body int) (float64, error) {
+	gm, err := e.BodyGM(body)
+	if err == nil {
+		return gm, nil
+	}
+	if gm, ok := bodyGM[body]; ok {
+		return gm, nil
+	}
+	return 0, err
+}
+
+// cross возвращает векторное произведение.
+func (c Coords) cross(other Coords) Coords {
+	return Coords{X: c.Y*other.Z - c.Z*other.Y, Y: c.Z*other.X - c.X*other.Z, Z: c.X*other.Y - c.Y*other.X}
+}
+
+// normalizeAngle2Pi приводит угол к диапазону [0, 2π).
+func normalizeAngle2Pi(angle float64) float64 {
+	angle = math.Mod(angle, 2*math.Pi)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	return angle
+}
+
+// StateToElements вычисляет оскулирующие элементы по положению и скорости относительно центрального тела
+// с гравитационным параметром gm (в единицах состояния).
+func StateToElements(coords, velocity Coords, gm float64) (KeplerianElements, error) {
+	r := coords.length()
+	if gm <= 0 || r == 0 {
+		return KeplerianElements{}, errors.New("bad state or gravitational parameter")
+	}
+	momentum := coords.cross(velocity)
+	h := momentum.length()
+	if h == 0 {
+		return KeplerianElements{}, errors.New("degenerate (rectilinear) orbit")
+	}
+	// вектор эксцентриситета направлен в перицентр
+	eccentricityVector := velocity.cross(momentum).scale(1 / gm).sub(coords.scale(1 / r))
+	ecc := eccentricityVector.length()
+	node := Coords{X: -momentum.Y, Y: momentum.X}
+
+	el := KeplerianElements{Eccentricity: ecc, GM: gm}
+	el.PeriapsisDistance = h * h / gm / (1 + ecc)
+	el.Inclination = math.Atan2(math.Hypot(momentum.X, momentum.Y), momentum.Z)
+
+	// направление отсчёта аргумента перицентра: узел, а для экваториальной орбиты - ось X
+	reference := Coords{X: 1}
+	if el.Inclination > equatorialInclination && el.Inclination < math.Pi-equatorialInclination {
+		el.AscendingNode = normalizeAngle2Pi(math.Atan2(node.Y, node.X))
+		reference = node.scale(1 / node.length())
+	}
+	// перпендикуляр к направлению отсчёта в плоскости орбиты по направлению движения
+	normal := momentum.scale(1 / h)
+	perpendicular := normal.cross(reference)
+
+	periapsis := reference
+	if ecc > circularEccentricity {
+		periapsis = eccentricityVector.scale(1 / ecc)
+		el.ArgumentOfPeriapsis = normalizeAngle2Pi(math.Atan2(periapsis.dot(perpendicular), periapsis.dot(reference)))
+	}
+	along := normal.cross(periapsis)
+	nu := math.Atan2(coords.dot(along), coords.dot(periapsis))
+	el.TrueAnomaly = normalizeAngle2Pi(nu)
+
+	switch {
+	case math.Abs(ecc-1) < parabolicEccentricity:
+		el.SemiMajorAxis = math.Inf(1)
+		d := math.Tan(nu / 2)
+		el.MeanAnomaly = d + d*d*d/3
+		el.MeanMotion = math.Sqrt(gm / (2 * math.Pow(el.PeriapsisDistance, 3)))
+	case ecc < 1:
+		el.SemiMajorAxis = el.PeriapsisDistance / (1 - ecc)
+		eccentric := 2 * math.Atan(math.Sqrt((1-ecc)/(1+ecc))*math.Tan(nu/2))
+		el.MeanAnomaly = normalizeAngle2Pi(eccentric - ecc*math.Sin(eccentric))
+		el.MeanMotion = math.Sqrt(gm / math.Pow(el.SemiMajorAxis, 3))
+	default:
+		el.SemiMajorAxis = el.PeriapsisDistance / (1 - ecc)
+		hyperbolic := 2 * math.Atanh(math.Sqrt((ecc-1)/(ecc+1))*math.Tan(nu/2))
+		el.MeanAnomaly = ecc*math.Sinh(hyperbolic) - hyperbolic
+		el.MeanMotion = math.Sqrt(gm / math.Pow(-el.SemiMajorAxis, 3))
+	}
+	return el, nil
+}
+
+// trueAnomaly решает уравнение Кеплера и возвращает истинную аномалию по средней.
+func (el KeplerianElements) trueAnomaly() (float64, error) {
+	ecc, m := el.Eccentricity, el.MeanAnomaly
+	switch {
+	case math.Abs(ecc-1) < parabolicEccentricity:
+		// уравнение Баркера D + D^3/3 = M решается в радикалах
+		w := math.Cbrt(1.5*m + math.Sqrt(2.25*m*m+1))
+		return 2 * math.Atan(w-1/w), nil
+	case ecc < 1:
+		m = normalizeAngle2Pi(m)
+		eccentric := m
+		if ecc > 0.8 {
+			eccentric = math.Pi
+		}
+		for i := 0; i < maxKeplerIterations; i++ {
+			delta := (eccentric - ecc*math.Sin(eccentric) - m) / (1 - ecc*math.Cos(eccentric))
+			eccentric -= delta
+			if math.Abs(delta) < 1e-15 {
+				return 2 * math.Atan2(math.Sqrt(1+ecc)*math.Sin(eccentric/2), math.Sqrt(1-ecc)*math.Cos(eccentric/2)), nil
+			}
+		}
+	default:
+		hyperbolic := math.Asinh(m / ecc)
+		for i := 0; i < maxKeplerIterations; i++ {
+			delta := (ecc*math.Sinh(hyperbolic) - hyperbolic - m) / (ecc*math.Cosh(hyperbolic) - 1)
+			hyperbolic -= delta
+			if math.Abs(delta) < 1e-15*math.Max(1, math.Abs(hyperbolic)) {
+				return 2 * math.Atan(math.Sqrt((ecc+1)/(ecc-1))*math.Tanh(hyperbolic/2)), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("kepler equation did not converge (e = %g, M = %g)", ecc, m)
+}
+
+// State вычисляет положение и скорость по оскулирующим элементам (по средней аномалии).
+func (el KeplerianElements) State() (Coords, Coords, error) {
+	if el.GM <= 0 || el.PeriapsisDistance <= 0 || el.Eccentricity < 0 {
+		return Coords{}, Coords{}, errors.New("bad orbital elements")
+	}
+	nu, err := el.trueAnomaly()
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+	// положение и скорость в перифокальной системе
+	p := el.PeriapsisDistance * (1 + el.Eccentricity)
+	sinNu, cosNu := math.Sincos(nu)
+	r := p / (1 + el.Eccentricity*cosNu)
+	speed := math.Sqrt(el.GM / p)
+	coords := Coords{X: r * cosNu, Y: r * sinNu}
+	velocity := Coords{X: -speed * sinNu, Y: speed * (el.Eccentricity + cosNu)}
+
+	rotation := rotationZ(-el.AscendingNode).Mul(rotationX(-el.Inclination)).Mul(rotationZ(-el.ArgumentOfPeriapsis))
+	return rotation.Apply(coords), rotation.Apply(velocity), nil
+}
+
+// Equinoctial возвращает равноденственные элементы эллиптической орбиты.
+func (el KeplerianElements) Equinoctial() (EquinoctialElements, error) {
+	if el.Eccentricity >= 1 {
+		return EquinoctialElements{}, errors.New("equinoctial elements are defined for elliptic orbits only")
+	}
+	periapsisLongitude := el.ArgumentOfPeriapsis + el.AscendingNode
+	tanHalf := math.Tan(el.Inclination / 2)
+	return EquinoctialElements{
+		SemiMajorAxis: el.SemiMajorAxis,
+		H:             el.Eccentricity * math.Sin(periapsisLongitude),
+		K:             el.Eccentricity * math.Cos(periapsisLongitude),
+		P:             tanHalf * math.Sin(el.AscendingNode),
+		Q:             tanHalf * math.Cos(el.AscendingNode),
+		MeanLongitude: normalizeAngle2Pi(el.MeanAnomaly + periapsisLongitude),
+		GM:            el.GM,
+	}, nil
+}
+
+// Keplerian возвращает кеплеровы элементы по равноденственным.
+func (q EquinoctialElements) Keplerian() (KeplerianElements, error) {
+	ecc := math.Hypot(q.H, q.K)
+	if ecc >= 1 || q.SemiMajorAxis <= 0 || q.GM <= 0 {
+		return KeplerianElements{}, errors.New("bad equinoctial elements")
+	}
+	el := KeplerianElements{
+		SemiMajorAxis: q.SemiMajorAxis,
+		Eccentricity:  ecc,
+		Inclination:   2 * math.Atan(math.Hypot(q.P, q.Q)),
+		GM:            q.GM,
+	}
+	el.PeriapsisDistance = q.SemiMajorAxis * (1 - ecc)
+	el.MeanMotion = math.Sqrt(q.GM / math.Pow(q.SemiMajorAxis, 3))
+	if el.Inclination > equatorialInclination {
+		el.AscendingNode = normalizeAngle2Pi(math.Atan2(q.P, q.Q))
+	}
+	periapsisLongitude := 0.0
+	if ecc > circularEccentricity {
+		periapsisLongitude = math.Atan2(q.H, q.K)
+	}
+	el.ArgumentOfPeriapsis = normalizeAngle2Pi(periapsisLongitude - el.AscendingNode)
+	el.MeanAnomaly = normalizeAngle2Pi(q.MeanLongitude - periapsisLongitude)
+	nu, err := el.trueAnomaly()
+	if err != nil {
+		return KeplerianElements{}, err
+	}
+	el.TrueAnomaly = normalizeAngle2Pi(nu)
+	return el, nil
+}
+
+// State вычисляет положение и скорость по равноденственным элементам.
+func (q EquinoctialElements) State() (Coords, Coords, error) {
+	el, err := q.Keplerian()
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+	return el.State()
+}
+
+// scaledGM возвращает гравитационный параметр тела в установленных единицах расстояния и времени.
+func (e *Ephemeris) scaledGM(body int) (float64, error) {
+	gm, err := e.GravitationalParameter(body)
+	if err != nil {
+		return 0, err
+	}
+	secondsInTimeUnit := secondsInDay / e.timeScalingFactor
+	return gm * math.Pow(e.distanceScalingFactor, 3) * secondsInTimeUnit * secondsInTimeUnit, nil
+}
+
+// CalculateKeplerianElements вычисляет оскулирующие элементы орбиты объекта относительно центрального тела
+// (в ICRF, в установленных единицах измерения). Используется гравитационный параметр центрального тела.
+func (e *Ephemeris) CalculateKeplerianElements(object, center int, date1, date2 float64) (KeplerianElements, error) {
+	coords, velocity, err := e.CalculateRectangularCoordsAndScaleVelocity(object, center, date1, date2, true)
+	if err != nil {
+		return KeplerianElements{}, err
+	}
+	gm, err := e.scaledGM(center)
+	if err != nil {
+		return KeplerianElements{}, err
+	}
+	return StateToElements(coords, velocity, gm)
+}
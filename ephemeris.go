@@ -3,6 +3,7 @@ package rightround
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 type Ephemeris struct {
@@ -22,6 +23,13 @@ type Ephemeris struct {
 
 	leftmostJulianDate  float64
 	rightmostJulianDate float64
+
+	// переменные загруженных текстовых ядер
+	pool *KernelPool
+	// модель ориентации Земли
+	earthOrientation *EarthOrientation
+	// абсолютные пути мета-ядер, загружаемых в данный момент (для обнаружения циклов)
+	loadingMetaKernels map[string]bool
 }
 
 func NewEphemeris() *Ephemeris {
@@ -31,15 +39,40 @@ func NewEphemeris() *Ephemeris {
 		distanceScalingFactor: 1,
 		leftmostJulianDate:    -1,
 		rightmostJulianDate:   -1,
+		pool:                  NewKernelPool(),
+		earthOrientation:      NewEarthOrientation(),
+		loadingMetaKernels:    make(map[string]bool),
 	}
 }
 
+// LoadFile загружает бинарное ядро (SPK, PCK), текстовое ядро или мета-ядро с заголовком KPL
+// либо бинарные эфемериды JPL в собственном формате (см. LoadJPLBinary).
 func (e *Ephemeris) LoadFile(path string) error {
 	file, err := os.Open(path)
 	if err != nil {
 		return err
 	}
 
+	id := make([]byte, 8)
+	if n, _ := file.ReadAt(id, 0); strings.HasPrefix(string(id[:n]), "KPL/") {
+		if err := file.Close(); err != nil {
+			return err
+		}
+		if strings.HasPrefix(string(id[:n]), "KPL/MK") {
+			return e.LoadMetaKernel(path)
+		}
+		return e.LoadTextKernel(path)
+	}
+
+	if n, _ := file.ReadAt(id, 0); !strings.HasPrefix(string(id[:n]), "DAF/") && !strings.HasPrefix(string(id[:n]), "NAIF/DAF") && isJPLBinary(file) {
+		// файлы без идентификатора DAF с заголовком JPL загружаются как бинарные эфемериды JPL
+		if err := file.Close(); err != nil {
+			return err
+		}
+		_, err := e.LoadJPLBinary(path)
+		return err
+	}
+
 	daf, err := newDAF(file)
 	if err != nil {
 		return err
@@ -58,18 +91,13 @@ func (e *Ephemeris) loadDAF(daf *DAF) error {
 			theory.object = int(segment.iParameters[0])
 			theory.basis = int(segment.iParameters[1])
 			theory.representation = int(segment.iParameters[3])
-
-			e.haveMoonRefEarth = e.haveMoonRefEarth || (theory.object == EphemerisMoon && theory.basis == EphemerisEarth)
-			e.haveMoonRefEarthMoon = e.haveMoonRefEarthMoon || (theory.object == EphemerisMoon && theory.basis == EphemerisEarthMoon)
-			e.haveEarthRefEarthMoon = e.haveEarthRefEarthMoon || (theory.object == EphemerisEarth && theory.basis == EphemerisEarthMoon)
-			e.haveEarthRefSunSystem = e.haveEarthRefSunSystem || (theory.object == EphemerisEarth && theory.basis == EphemerisSunSystem)
-			e.haveEarthMoonRefSunSystem = e.haveEarthMoonRefSunSystem || (theory.object == EphemerisEarthMoon && theory.basis == EphemerisSunSystem)
 		} else if daf.fileType == FormatPCK {
 			theory.object = int(segment.iParameters[0])
+			theory.basis = int(segment.iParameters[1])
 			theory.representation = int(segment.iParameters[2])
 		}
 
-		if theory.representation == representationPositionOnly {
+		if theory.representation == representationPositionOnly || theory.representation == representationPositionVelocity {
 			params, err := segment.readRange(int(segment.length)-4, 4)
 			if err != nil {
 				return err
@@ -79,16 +107,20 @@ func (e *Ephemeris) loadDAF(daf *DAF) error {
 			days := int(params[0] / secondsInDay)
 			theory.julianDays = julianDate2000 + float64(days)
 			theory.julianDaysMod = (params[0] - float64(days)*secondsInDay) / secondsInDay // check
-			theory.intervalLen = float64(int(params[1]) / secondsInDay)                    // check why int
+			theory.intervalLen = params[1] / secondsInDay
 			theory.rSize = int(params[2])
 			theory.nIntervals = int(params[3])
 
-			// проверить, что RSize в 3N + 2
-			if theory.rSize%3 != 2 {
+			// RSize в 3N + 2 для типа 2 (положения) и 6N + 2 для типа 3 (положения и скорости)
+			components := 3
+			if theory.representation == representationPositionVelocity {
+				components = 6
+			}
+			if theory.rSize%components != 2 {
 				return fmt.Errorf("bad rSize (%d)", theory.rSize)
 			}
 			// полиномиальный градус в N-1
-			theory.polynomialDegree = (theory.rSize-2)/3 - 1
+			theory.polynomialDegree = (theory.rSize-2)/components - 1
 			// dScale и tScale не используются в этом типе ефемерид
 			theory.dScale = 1
 			theory.tScale = 1
@@ -111,52 +143,120 @@ func (e *Ephemeris) loadDAF(daf *DAF) error {
 			}
 			// полиномиальный градус в N-2
 			theory.polynomialDegree = theory.rSize/3 - 2
+		} else if (theory.representation == representationDiscreteStates || theory.representation == representationLagrange ||
+			theory.representation == representationHermite) && daf.fileType == FormatSPK {
+			params, err := segment.readRange(int(segment.length)-2, 2)
+			if err != nil {
+				return err
+			}
+			nStates := int(params[1])
+			if nStates < 1 || 7*nStates > int(segment.length) {
+				return fmt.Errorf("bad number of states (%d)", nStates)
+			}
+			if theory.epochs, err = segment.readRange(6*nStates, nStates); err != nil {
+				return err
+			}
+			switch theory.representation {
+			case representationDiscreteStates:
+				theory.gm = params[0]
+			case representationLagrange:
+				theory.polynomialDegree = int(params[0])
+			case representationHermite:
+				// задан размер окна минус один, степень полинома Эрмита 2W-1
+				theory.polynomialDegree = 2*int(params[0]) + 1
+			}
+			if theory.polynomialDegree < 0 || theory.polynomialDegree > maxInterpolationDegree {
+				return fmt.Errorf("bad interpolation degree (%d)", theory.polynomialDegree)
+			}
+			// сегмент охватывает интервал из сводки как один интервал
+			theory.setSpan(segment.dParameters[0], segment.dParameters[1])
 		} else {
-			return fmt.Errorf("unsupported representation (%d)", theory.representation)
+			return fmt.Errorf("%w representation (%d)", ErrUnsupported, theory.representation)
 		}
 
-		if theory.polynomialDegree > maxPolynomialDegree {
+		if theory.epochs == nil && theory.polynomialDegree > maxPolynomialDegree {
 			return fmt.Errorf("polynomial degree limit (%d) exceeded in file", theory.polynomialDegree)
 		}
 		theory.cachedInterval = -1
 		theory.fileType = daf.fileType
 		theory.segment = &daf.segments[i]
-
-		if leftmostDate := theory.julianDays + theory.julianDaysMod; e.leftmostJulianDate < 0 || e.leftmostJulianDate > leftmostDate {
-			e.leftmostJulianDate = leftmostDate
-		}
-		if rightmostDate := theory.julianDays + theory.julianDaysMod + theory.intervalLen*float64(theory.nIntervals); e.rightmostJulianDate < 0 || e.rightmostJulianDate < rightmostDate {
-			e.rightmostJulianDate = rightmostDate
-		}
-		e.theories = append(e.theories, &theory)
+		e.addTheory(&theory)
 	}
 	e.dafs = append(e.dafs, daf)
 	return nil
 }
 
-// setDistanceUnits устанавливает единицы измерения расстояния.
-func (e *Ephemeris) setDistanceUnits(unit int) error {
+// addTheory добавляет теорию к загруженным; теории, добавленные позже, имеют приоритет.
+func (e *Ephemeris) addTheory(theory *Theory) {
+	if theory.fileType == FormatSPK {
+		e.haveMoonRefEarth = e.haveMoonRefEarth || (theory.object == EphemerisMoon && theory.basis == EphemerisEarth)
+		e.haveMoonRefEarthMoon = e.haveMoonRefEarthMoon || (theory.object == EphemerisMoon && theory.basis == EphemerisEarthMoon)
+		e.haveEarthRefEarthMoon = e.haveEarthRefEarthMoon || (theory.object == EphemerisEarth && theory.basis == EphemerisEarthMoon)
+		e.haveEarthRefSunSystem = e.haveEarthRefSunSystem || (theory.object == EphemerisEarth && theory.basis == EphemerisSunSystem)
+		e.haveEarthMoonRefSunSystem = e.haveEarthMoonRefSunSystem || (theory.object == EphemerisEarthMoon && theory.basis == EphemerisSunSystem)
+	}
+
+	span := theory.span()
+	if e.leftmostJulianDate < 0 || e.leftmostJulianDate > span.Start {
+		e.leftmostJulianDate = span.Start
+	}
+	if e.rightmostJulianDate < 0 || e.rightmostJulianDate < span.End {
+		e.rightmostJulianDate = span.End
+	}
+	e.theories = append(e.theories, theory)
+}
+
+// LoadTextKernel загружает переменные текстового ядра (PCK, FK, LSK).
+func (e *Ephemeris) LoadTextKernel(path string) error {
+	return e.pool.LoadFile(path)
+}
+
+// Pool возвращает хранилище переменных загруженных текстовых ядер.
+func (e *Ephemeris) Pool() *KernelPool {
+	return e.pool
+}
+
+// BodyRadii возвращает радиусы трёхосного эллипсоида тела (в километрах), заданные в текстовом PCK.
+func (e *Ephemeris) BodyRadii(body int) (Coords, error) {
+	radii, ok := e.pool.bodyFloats(bodyCode(body), "RADII")
+	if !ok || len(radii) != 3 {
+		return Coords{}, fmt.Errorf("radii for body %d %w", body, ErrNotFound)
+	}
+	return Coords{X: radii[0], Y: radii[1], Z: radii[2]}, nil
+}
+
+// BodyGM возвращает гравитационный параметр тела (в км^3/с^2), заданный в текстовом PCK.
+func (e *Ephemeris) BodyGM(body int) (float64, error) {
+	gm, ok := e.pool.bodyFloats(body, "GM")
+	if !ok || len(gm) != 1 {
+		return 0, fmt.Errorf("GM for body %d %w", body, ErrNotFound)
+	}
+	return gm[0], nil
+}
+
+// SetDistanceUnits устанавливает единицы измерения расстояния (UnitCodeKM или UnitCodeAU).
+func (e *Ephemeris) SetDistanceUnits(unit int) error {
 	if unit == UnitCodeKM {
 		// в SPK файлах уже в киллометрах
 		e.distanceScalingFactor = 1
 	} else if unit == UnitCodeAU {
 		e.distanceScalingFactor = 1 / kilometersInAU
 	} else {
-		return fmt.Errorf("unknown distance units: %d", unit)
+		return fmt.Errorf("%w distance units: %d", ErrUnknown, unit)
 	}
 	e.distanceUnits = unit
 	return nil
 }
 
-// setTimeUnits устанавливает единицы измерения времени.
-func (e *Ephemeris) setTimeUnits(unit int) error {
+// SetTimeUnits устанавливает единицы измерения времени для скоростей и разностей шкал (UnitCodeSec или UnitCodeDay).
+func (e *Ephemeris) SetTimeUnits(unit int) error {
 	if unit == UnitCodeDay {
 		// внутренние единицы измерения SPK/PCK это дни
 		e.timeScalingFactor = 1
 	} else if unit == UnitCodeSec { // в SPK бывают секунды
 		e.timeScalingFactor = secondsInDay
 	} else {
-		return fmt.Errorf("unknown time units: %d", unit)
+		return fmt.Errorf("%w time units: %d", ErrUnknown, unit)
 	}
 	return nil
 }
@@ -0,0 +1,16 @@
+package rightround
+
+import "errors"
+
+// Ошибки, которые можно распознать с помощью errors.Is.
+// Текст ошибок, возвращаемых функциями пакета, включает текст этих ошибок.
+var (
+	// ErrNotFound данные (теория, параметры тела, параметры ориентации Земли) для объекта или даты не загружены.
+	ErrNotFound = errors.New("not found")
+	// ErrUnknown неизвестный код (шкала времени, единицы измерения, тип поиска и т.п.).
+	ErrUnknown = errors.New("unknown")
+	// ErrUnsupported формат или представление данных не поддерживается.
+	ErrUnsupported = errors.New("unsupported")
+	// ErrInvalidGrid неверно задана сетка дат.
+	ErrInvalidGrid = errors.New("invalid time grid")
+)
@@ -0,0 +1,198 @@
+package rightround
+
+import (
+	"fmt"
+	"math"
+)
+
+// Matrix матрица 3x3 (по строкам).
+type Matrix [3][3]float64
+
+// Apply умножает матрицу на вектор.
+func (m Matrix) Apply(c Coords) Coords {
+	return Coords{
+		X: m[0][0]*c.X + m[0][1]*c.Y + m[0][2]*c.Z,
+		Y: m[1][0]*c.X + m[1][1]*c.Y + m[1][2]*c.Z,
+		Z: m[2][0]*c.X + m[2][1]*c.Y + m[2][2]*c.Z,
+	}
+}
+
+// Mul возвращает произведение матриц m * n.
+func (m Matrix) Mul(n Matrix) Matrix {
+	var result Matrix
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			result[i][j] = m[i][0]*n[0][j] + m[i][1]*n[1][j] + m[i][2]*n[2][j]
+		}
+	}
+	return result
+}
+
+// Add возвращает сумму матриц.
+func (m Matrix) Add(n Matrix) Matrix {
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m[i][j] += n[i][j]
+		}
+	}
+	return m
+}
+
+// Scale умножает матрицу на число.
+func (m Matrix) Scale(factor float64) Matrix {
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m[i][j] *= factor
+		}
+	}
+	return m
+}
+
+// Transpose возвращает транспонированную матрицу.
+func (m Matrix) Transpose() Matrix {
+	var result Matrix
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			result[i][j] = m[j][i]
+		}
+	}
+	return result
+}
+
+// rotationX матрица поворота системы координат вокруг оси X на угол angle (в радианах).
+func rotationX(angle float64) Matrix {
+	s, c := math.Sincos(angle)
+	return Matrix{{1, 0, 0}, {0, c, s}, {0, -s, c}}
+}
+
+// rotationY матрица поворота системы координат вокруг оси Y на угол angle (в радианах).
+func rotationY(angle float64) Matrix {
+	s, c := math.Sincos(angle)
+	return Matrix{{c, 0, -s}, {0, 1, 0}, {s, 0, c}}
+}
+
+// rotationZ матрица поворота системы координат вокруг оси Z на угол angle (в радианах).
+func rotationZ(angle float64) Matrix {
+	s, c := math.Sincos(angle)
+	return Matrix{{c, s, 0}, {-s, c, 0}, {0, 0, 1}}
+}
+
+// rotationXDerivative производная матрицы rotationX по углу.
+func rotationXDerivative(angle float64) Matrix {
+	s, c := math.Sincos(angle)
+	return Matrix{{0, 0, 0}, {0, -s, c}, {0, -c, -s}}
+}
+
+// rotationZDerivative производная матрицы rotationZ по углу.
+func rotationZDerivative(angle float64) Matrix {
+	s, c := math.Sincos(angle)
+	return Matrix{{-s, c, 0}, {-c, -s, 0}, {0, 0, 0}}
+}
+
+// Transform преобразование состояния (положения и скорости) между системами координат:
+// матрица поворота и её производная по времени.
+type Transform struct {
+	Rotation   Matrix
+	Derivative Matrix
+}
+
+// Apply преобразует координаты и скорость.
+func (t Transform) Apply(coords, velocity Coords) (Coords, Coords) {
+	rotatedVelocity := t.Rotation.Apply(velocity)
+	rate := t.Derivative.Apply(coords)
+	rotatedVelocity.X += rate.X
+	rotatedVelocity.Y += rate.Y
+	rotatedVelocity.Z += rate.Z
+	return t.Rotation.Apply(coords), rotatedVelocity
+}
+
+// Inverse возвращает обратное преобразование.
+func (t Transform) Inverse() Transform {
+	return Transform{Rotation: t.Rotation.Transpose(), Derivative: t.Derivative.Transpose()}
+}
+
+// Then возвращает композицию преобразований: сначала t, затем next.
+func (t Transform) Then(next Transform) Transform {
+	return Transform{
+		Rotation:   next.Rotation.Mul(t.Rotation),
+		Derivative: next.Derivative.Mul(t.Rotation).Add(next.Rotation.Mul(t.Derivative)),
+	}
+}
+
+// eulerTransform строит преобразование из ICRF в связанную с телом систему координат
+// по эйлеровым углам (phi, theta, psi) в последовательности осей 3-1-3 и скоростям их изменения.
+func eulerTransform(angles, rates Coords) Transform {
+	r3Phi, r1Theta, r3Psi := rotationZ(angles.X), rotationX(angles.Y), rotationZ(angles.Z)
+	rotation := r3Psi.Mul(r1Theta).Mul(r3Phi)
+	derivative := rotationZDerivative(angles.Z).Mul(r1Theta).Mul(r3Phi).Scale(rates.Z).
+		Add(r3Psi.Mul(rotationXDerivative(angles.Y)).Mul(r3Phi).Scale(rates.Y)).
+		Add(r3Psi.Mul(r1Theta).Mul(rotationZDerivative(angles.X)).Scale(rates.X))
+	return Transform{Rotation: rotation, Derivative: derivative}
+}
+
+// Коды инерциальных систем координат, относительно которых задаются углы в PCK.
+const (
+	frameJ2000         = 1  // J2000 (ICRF)
+	frameEclipticJ2000 = 17 // эклиптика и равноденствие J2000
+)
+
+// eclipticJ2000Obliquity наклон эклиптики J2000 (в угловых секундах), принятый в SPICE для ECLIPJ2000.
+const eclipticJ2000Obliquity = 84381.448
+
+// arcsecondsInRadian количество угловых секунд в радиане.
+const arcsecondsInRadian = 180 * 60 * 60 / math.Pi
+
+// moonMeanEarthAngles углы (в угловых секундах) поворота от системы главных осей инерции Луны (PA)
+// к системе "средняя Земля / полярная ось" (ME) для лунных теорий DE.
+// Последовательность осей 3-2-1, значения соответствуют фреймовым ядрам NAIF.
+var moonMeanEarthAngles = map[int][3]float64{
+	EphemerisMoonPrincipalAxesDE403: {63.8986, 79.0768, 0.1462},
+	EphemerisMoonPrincipalAxesDE421: {67.92, 78.56, 0.30},
+	EphemerisMoonPrincipalAxesDE430: {67.573, 78.580, 0.285},
+}
+
+// CalculateFrameTransform вычисляет преобразование состояния из ICRF в систему координат,
+// заданную эйлеровыми углами бинарного PCK (например, EphemerisMoonPrincipalAxesDE430).
+// Производная матрицы поворота выражается в установленных единицах времени.
+func (e *Ephemeris) CalculateFrameTransform(frame int, date1, date2 float64) (Transform, error) {
+	theory, err := e.findFrameTheory(frame, date1, date2)
+	if err != nil {
+		return Transform{}, err
+	}
+	angles, rates, err := e.calculateEulerAnglesByTheory(theory, date1, date2, true)
+	if err != nil {
+		return Transform{}, err
+	}
+	transform := eulerTransform(angles, rates)
+	switch theory.basis {
+	case frameJ2000:
+		return transform, nil
+	case frameEclipticJ2000:
+		// углы заданы относительно эклиптики J2000
+		return EclipticJ2000Transform().Then(transform), nil
+	default:
+		return Transform{}, fmt.Errorf("%w reference frame %d for frame %d", ErrUnsupported, theory.basis, frame)
+	}
+}
+
+// EclipticJ2000Transform возвращает преобразование из ICRF в систему эклиптики и равноденствия J2000 (ECLIPJ2000).
+func EclipticJ2000Transform() Transform {
+	return Transform{Rotation: rotationX(eclipticJ2000Obliquity / arcsecondsInRadian)}
+}
+
+// CalculateMoonMeanEarthTransform вычисляет преобразование состояния из ICRF в лунную систему координат
+// "средняя Земля / полярная ось" (ME), используя углы системы главных осей frame.
+func (e *Ephemeris) CalculateMoonMeanEarthTransform(frame int, date1, date2 float64) (Transform, error) {
+	angles, ok := moonMeanEarthAngles[frame]
+	if !ok {
+		return Transform{}, fmt.Errorf("mean earth frame for %d is %w", frame, ErrUnknown)
+	}
+	transform, err := e.CalculateFrameTransform(frame, date1, date2)
+	if err != nil {
+		return Transform{}, err
+	}
+	meanEarth := rotationX(angles[2] / arcsecondsInRadian).
+		Mul(rotationY(angles[1] / arcsecondsInRadian)).
+		Mul(rotationZ(angles[0] / arcsecondsInRadian))
+	return transform.Then(Transform{Rotation: meanEarth}), nil
+}
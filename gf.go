@@ -0,0 +1,238 @@
+package rightround
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Условия поиска событий.
+const (
+	SearchCodeEquals   = 1 // величина равна заданному значению
+	SearchCodeLess     = 2 // величина меньше заданного значения
+	SearchCodeGreater  = 3 // величина больше заданного значения
+	SearchCodeLocalMin = 4 // локальный минимум
+	SearchCodeLocalMax = 5 // локальный максимум
+	SearchCodeAbsMin   = 6 // абсолютный минимум
+	SearchCodeAbsMax   = 7 // абсолютный максимум
+)
+
+// Quantity скалярная величина как функция юлианской даты TDB.
+type Quantity func(date float64) (float64, error)
+
+// SearchOptions параметры поиска. Шаг должен быть меньше наименьшего интервала,
+// на котором величина (или её производная для поиска экстремумов) монотонна.
+type SearchOptions struct {
+	Step      float64                    // шаг поиска, сутки (0 - один час)
+	StepFunc  func(date float64) float64 // переменный шаг поиска, сутки; если задан, Step не используется
+	Tolerance float64                    // точность моментов, сутки (0 - около 0.01 секунды)
+}
+
+// derivativeStep шаг численного дифференцирования величины, сутки.
+const derivativeStep = 1e-4
+
+// Search находит моменты или интервалы внутри окна confine, в которых величина удовлетворяет условию relation.
+// Для условий равенства и экстремумов результат состоит из интервалов нулевой длины.
+func Search(quantity Quantity, relation int, value float64, confine Window, options SearchOptions) (Window, error) {
+	step := options.StepFunc
+	if step == nil {
+		if options.Step > 0 {
+			step = constantStep(options.Step)
+		} else {
+			step = constantStep(defaultSearchStep)
+		}
+	}
+	tolerance := options.Tolerance
+	if tolerance <= 0 {
+		tolerance = defaultSearchTolerance
+	}
+
+	difference := func(date float64) (float64, error) {
+		result, err := quantity(date)
+		return result - value, err
+	}
+	// derivative возвращает производную величины внутри интервала: у границ интервала
+	// разность берётся односторонней, чтобы не выходить за пределы окна
+	derivative := func(interval Interval) func(float64) (float64, error) {
+		return func(date float64) (float64, error) {
+			from := math.Max(date-derivativeStep, interval.Start)
+			to := math.Min(date+derivativeStep, interval.End)
+			if to <= from {
+				return 0, nil
+			}
+			after, err := quantity(to)
+			if err != nil {
+				return 0, err
+			}
+			before, err := quantity(from)
+			if err != nil {
+				return 0, err
+			}
+			return (after - before) / (to - from), nil
+		}
+	}
+
+	for _, interval := range confine {
+		if interval.End < interval.Start {
+			return nil, errors.New("bad confinement window")
+		}
+	}
+
+	var result Window
+	bestValue, bestDate := math.NaN(), 0.0
+	for _, interval := range NewWindow(confine...) {
+		switch relation {
+		case SearchCodeEquals:
+			roots, err := findRoots(difference, interval.Start, interval.End, step, tolerance)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range roots {
+				result = append(result, Interval{Start: r.date, End: r.date})
+			}
+
+		case SearchCodeLess, SearchCodeGreater:
+			roots, err := findRoots(difference, interval.Start, interval.End, step, tolerance)
+			if err != nil {
+				return nil, err
+			}
+			startValue, err := difference(interval.Start)
+			if err != nil {
+				return nil, err
+			}
+			// условие выполняется, когда знак разности соответствует отношению
+			inside := (startValue < 0) == (relation == SearchCodeLess) && startValue != 0
+			if startValue == 0 && len(roots) > 0 && roots[0].date == interval.Start {
+				inside = roots[0].increasing == (relation == SearchCodeGreater)
+				roots = roots[1:]
+			}
+			begin := interval.Start
+			for _, r := range roots {
+				entering := r.increasing == (relation == SearchCodeGreater)
+				if entering && !inside {
+					begin, inside = r.date, true
+				} else if !entering && inside {
+					result = append(result, Interval{Start: begin, End: r.date})
+					inside = false
+				}
+			}
+			if inside {
+				result = append(result, Interval{Start: begin, End: interval.End})
+			}
+
+		case SearchCodeLocalMin, SearchCodeLocalMax, SearchCodeAbsMin, SearchCodeAbsMax:
+			roots, err := findRoots(derivative(interval), interval.Start, interval.End, step, tolerance)
+			if err != nil {
+				return nil, err
+			}
+			minimum := relation == SearchCodeLocalMin || relation == SearchCodeAbsMin
+			candidates := []float64{}
+			for _, r := range roots {
+				// у минимума производная возрастает, у максимума - убывает
+				if r.increasing == minimum {
+					candidates = append(candidates, r.date)
+				}
+			}
+			if relation == SearchCodeLocalMin || relation == SearchCodeLocalMax {
+				for _, date := range candidates {
+					result = append(result, Interval{Start: date, End: date})
+				}
+				continue
+			}
+			// абсолютный экстремум может достигаться на границах интервала
+			candidates = append(candidates, interval.Start, interval.End)
+			for _, date := range candidates {
+				current, err := quantity(date)
+				if err != nil {
+					return nil, err
+				}
+				if math.IsNaN(bestValue) || (minimum && current < bestValue) || (!minimum && current > bestValue) {
+					bestValue, bestDate = current, date
+				}
+			}
+
+		default:
+			return nil, fmt.Errorf("%w search relation: %d", ErrUnknown, relation)
+		}
+	}
+	if (relation == SearchCodeAbsMin || relation == SearchCodeAbsMax) && !math.IsNaN(bestValue) {
+		result = Window{{Start: bestDate, End: bestDate}}
+	}
+	return NewWindow(result...), nil
+}
+
+// DistanceQuantity возвращает величину - расстояние между объектом и наблюдателем (геометрическое).
+func (e *Ephemeris) DistanceQuantity(object, observer int) Quantity {
+	return func(date float64) (float64, error) {
+		coords, _, err := e.CalculateRectangularCoords(object, observer, date, 0, false)
+		return coords.length(), err
+	}
+}
+
+// RangeRateQuantity возвращает величину - скорость изменения расстояния между объектом и наблюдателем
+// в установленных единицах измерения.
+func (e *Ephemeris) RangeRateQuantity(object, observer int) Quantity {
+	return func(date float64) (float64, error) {
+		coords, velocity, err := e.CalculateRectangularCoordsAndScaleVelocity(object, observer, date, 0, true)
+		if err != nil {
+			return 0, err
+		}
+		return (coords.X*velocity.X + coords.Y*velocity.Y + coords.Z*velocity.Z) / coords.length(), nil
+	}
+}
+
+// angleBetween возвращает угол между векторами (в радианах).
+func angleBetween(a, b Coords) float64 {
+	cross := Coords{X: a.Y*b.Z - a.Z*b.Y, Y: a.Z*b.X - a.X*b.Z, Z: a.X*b.Y - a.Y*b.X}
+	return math.Atan2(cross.length(), a.X*b.X+a.Y*b.Y+a.Z*b.Z)
+}
+
+// AngularSeparationQuantity возвращает величину - угловое расстояние (в радианах) между двумя объектами
+// при наблюдении из центра тела observer (по видимым положениям, если наблюдатель - Земля).
+func (e *Ephemeris) AngularSeparationQuantity(object1, object2, observer int) Quantity {
+	return func(date float64) (float64, error) {
+		coords1, err := e.calculateObserved(object1, observer, date)
+		if err != nil {
+			return 0, err
+		}
+		coords2, err := e.calculateObserved(object2, observer, date)
+		if err != nil {
+			return 0, err
+		}
+		return angleBetween(coords1, coords2), nil
+	}
+}
+
+// calculateObserved возвращает положение объекта относительно тела-наблюдателя:
+// видимое для Земли и геометрическое для остальных тел.
+func (e *Ephemeris) calculateObserved(object, observer int, date float64) (Coords, error) {
+	if observer == EphemerisEarth {
+		return e.CalculateGeocentricApparentCoords(object, date, 0)
+	}
+	coords, _, err := e.CalculateRectangularCoords(object, observer, date, 0, false)
+	return coords, err
+}
+
+// PhaseAngleQuantity возвращает величину - фазовый угол (в радианах) объекта, освещаемого телом illuminator,
+// при наблюдении из центра тела observer (геометрические положения).
+func (e *Ephemeris) PhaseAngleQuantity(object, illuminator, observer int) Quantity {
+	return func(date float64) (float64, error) {
+		toObserver, _, err := e.CalculateRectangularCoords(observer, object, date, 0, false)
+		if err != nil {
+			return 0, err
+		}
+		toIlluminator, _, err := e.CalculateRectangularCoords(illuminator, object, date, 0, false)
+		if err != nil {
+			return 0, err
+		}
+		return angleBetween(toObserver, toIlluminator), nil
+	}
+}
+
+// ElevationQuantity возвращает величину - высоту объекта над горизонтом наблюдателя (в радианах).
+func (e *Ephemeris) ElevationQuantity(object int, observer Observer, atmosphere *Atmosphere) Quantity {
+	return func(date float64) (float64, error) {
+		coords, err := e.CalculateHorizontalCoords(object, observer, date, 0, atmosphere)
+		return coords.Elevation, err
+	}
+}
@@ -0,0 +1,49 @@
+package rightround
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestSearchCoverageWindow(t *testing.T) {
+	path := filepath.Join(tempDir(t), "orbit.bsp")
+	writer := NewSPKWriter(path, "test orbit")
+	const start, end = julianDate2000 + 85, julianDate2000 + 95
+	spec := SegmentSpec{Object: 1000, Center: EphemerisSunSystem, Representation: representationPositionOnly, Start: start, End: end}
+	if err := writer.AddChebyshevSegment(spec, testOrbit); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Save(); err != nil {
+		t.Fatal(err)
+	}
+	ephemeris := NewEphemeris()
+	if err := ephemeris.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// расстояние минимально, когда объект пересекает плоскость XY (через четверть периода),
+	// и максимально в пределах окна на его левой границе
+	coverage := ephemeris.Coverage(1000)
+	quantity := ephemeris.DistanceQuantity(1000, EphemerisSunSystem)
+	for _, test := range []struct {
+		relation int
+		date     float64
+	}{
+		{SearchCodeLocalMin, julianDate2000 + 365.25/4},
+		{SearchCodeAbsMin, julianDate2000 + 365.25/4},
+		{SearchCodeAbsMax, start},
+	} {
+		result, err := Search(quantity, test.relation, 0, coverage, SearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 1 || math.Abs(result[0].Start-test.date) > 1e-5 {
+			t.Fatalf("relation %d: %v, expected %v", test.relation, result, test.date)
+		}
+	}
+	result, err := Search(quantity, SearchCodeLocalMax, 0, coverage, SearchOptions{})
+	if err != nil || len(result) != 0 {
+		t.Fatalf("local maximum %v, %v", result, err)
+	}
+}
@@ -0,0 +1,1145 @@
+// Схема gRPC-сервиса расчёта эфемерид.
+// Даты задаются юлианскими датами в шкале TDB, если не указано иное.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: ephemeris.proto
+
+package ephemerispb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Шкалы времени; значения совпадают с кодами TimeScaleCode* библиотеки.
+type TimeScale int32
+
+const (
+	TimeScale_TIME_SCALE_UNSPECIFIED TimeScale = 0
+	TimeScale_TIME_SCALE_TDB         TimeScale = 1
+	TimeScale_TIME_SCALE_TT          TimeScale = 2
+	TimeScale_TIME_SCALE_UTC         TimeScale = 3
+	TimeScale_TIME_SCALE_UT1         TimeScale = 4
+)
+
+// Enum value maps for TimeScale.
+var (
+	TimeScale_name = map[int32]string{
+		0: "TIME_SCALE_UNSPECIFIED",
+		1: "TIME_SCALE_TDB",
+		2: "TIME_SCALE_TT",
+		3: "TIME_SCALE_UTC",
+		4: "TIME_SCALE_UT1",
+	}
+	TimeScale_value = map[string]int32{
+		"TIME_SCALE_UNSPECIFIED": 0,
+		"TIME_SCALE_TDB":         1,
+		"TIME_SCALE_TT":          2,
+		"TIME_SCALE_UTC":         3,
+		"TIME_SCALE_UT1":         4,
+	}
+)
+
+func (x TimeScale) Enum() *TimeScale {
+	p := new(TimeScale)
+	*p = x
+	return p
+}
+
+func (x TimeScale) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TimeScale) Descriptor() protoreflect.EnumDescriptor {
+	return file_ephemeris_proto_enumTypes[0].Descriptor()
+}
+
+func (TimeScale) Type() protoreflect.EnumType {
+	return &file_ephemeris_proto_enumTypes[0]
+}
+
+func (x TimeScale) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TimeScale.Descriptor instead.
+func (TimeScale) EnumDescriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{0}
+}
+
+// Набор дат: список dates либо равномерная сетка от start до end включительно с шагом step в сутках.
+// Если end и step не заданы, используется одна дата start.
+type TimeGrid struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dates         []float64              `protobuf:"fixed64,1,rep,packed,name=dates,proto3" json:"dates,omitempty"`
+	Start         float64                `protobuf:"fixed64,2,opt,name=start,proto3" json:"start,omitempty"`
+	End           float64                `protobuf:"fixed64,3,opt,name=end,proto3" json:"end,omitempty"`
+	Step          float64                `protobuf:"fixed64,4,opt,name=step,proto3" json:"step,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TimeGrid) Reset() {
+	*x = TimeGrid{}
+	mi := &file_ephemeris_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeGrid) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeGrid) ProtoMessage() {}
+
+func (x *TimeGrid) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeGrid.ProtoReflect.Descriptor instead.
+func (*TimeGrid) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TimeGrid) GetDates() []float64 {
+	if x != nil {
+		return x.Dates
+	}
+	return nil
+}
+
+func (x *TimeGrid) GetStart() float64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *TimeGrid) GetEnd() float64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *TimeGrid) GetStep() float64 {
+	if x != nil {
+		return x.Step
+	}
+	return 0
+}
+
+type Vector struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             float64                `protobuf:"fixed64,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             float64                `protobuf:"fixed64,2,opt,name=y,proto3" json:"y,omitempty"`
+	Z             float64                `protobuf:"fixed64,3,opt,name=z,proto3" json:"z,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Vector) Reset() {
+	*x = Vector{}
+	mi := &file_ephemeris_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Vector) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Vector) ProtoMessage() {}
+
+func (x *Vector) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Vector.ProtoReflect.Descriptor instead.
+func (*Vector) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Vector) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Vector) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *Vector) GetZ() float64 {
+	if x != nil {
+		return x.Z
+	}
+	return 0
+}
+
+type StateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Object        int32                  `protobuf:"varint,1,opt,name=object,proto3" json:"object,omitempty"`
+	Basis         int32                  `protobuf:"varint,2,opt,name=basis,proto3" json:"basis,omitempty"`
+	Grid          *TimeGrid              `protobuf:"bytes,3,opt,name=grid,proto3" json:"grid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StateRequest) Reset() {
+	*x = StateRequest{}
+	mi := &file_ephemeris_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateRequest) ProtoMessage() {}
+
+func (x *StateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateRequest.ProtoReflect.Descriptor instead.
+func (*StateRequest) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StateRequest) GetObject() int32 {
+	if x != nil {
+		return x.Object
+	}
+	return 0
+}
+
+func (x *StateRequest) GetBasis() int32 {
+	if x != nil {
+		return x.Basis
+	}
+	return 0
+}
+
+func (x *StateRequest) GetGrid() *TimeGrid {
+	if x != nil {
+		return x.Grid
+	}
+	return nil
+}
+
+// Положение и скорость в единицах, установленных для эфемерид (по умолчанию км и км/с).
+type State struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Date          float64                `protobuf:"fixed64,1,opt,name=date,proto3" json:"date,omitempty"`
+	Position      *Vector                `protobuf:"bytes,2,opt,name=position,proto3" json:"position,omitempty"`
+	Velocity      *Vector                `protobuf:"bytes,3,opt,name=velocity,proto3" json:"velocity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *State) Reset() {
+	*x = State{}
+	mi := &file_ephemeris_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *State) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*State) ProtoMessage() {}
+
+func (x *State) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use State.ProtoReflect.Descriptor instead.
+func (*State) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *State) GetDate() float64 {
+	if x != nil {
+		return x.Date
+	}
+	return 0
+}
+
+func (x *State) GetPosition() *Vector {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+func (x *State) GetVelocity() *Vector {
+	if x != nil {
+		return x.Velocity
+	}
+	return nil
+}
+
+type StateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Object        int32                  `protobuf:"varint,1,opt,name=object,proto3" json:"object,omitempty"`
+	Basis         int32                  `protobuf:"varint,2,opt,name=basis,proto3" json:"basis,omitempty"`
+	States        []*State               `protobuf:"bytes,3,rep,name=states,proto3" json:"states,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StateResponse) Reset() {
+	*x = StateResponse{}
+	mi := &file_ephemeris_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateResponse) ProtoMessage() {}
+
+func (x *StateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateResponse.ProtoReflect.Descriptor instead.
+func (*StateResponse) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StateResponse) GetObject() int32 {
+	if x != nil {
+		return x.Object
+	}
+	return 0
+}
+
+func (x *StateResponse) GetBasis() int32 {
+	if x != nil {
+		return x.Basis
+	}
+	return 0
+}
+
+func (x *StateResponse) GetStates() []*State {
+	if x != nil {
+		return x.States
+	}
+	return nil
+}
+
+type OrientationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Frame         int32                  `protobuf:"varint,1,opt,name=frame,proto3" json:"frame,omitempty"`
+	Grid          *TimeGrid              `protobuf:"bytes,2,opt,name=grid,proto3" json:"grid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrientationRequest) Reset() {
+	*x = OrientationRequest{}
+	mi := &file_ephemeris_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrientationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrientationRequest) ProtoMessage() {}
+
+func (x *OrientationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrientationRequest.ProtoReflect.Descriptor instead.
+func (*OrientationRequest) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *OrientationRequest) GetFrame() int32 {
+	if x != nil {
+		return x.Frame
+	}
+	return 0
+}
+
+func (x *OrientationRequest) GetGrid() *TimeGrid {
+	if x != nil {
+		return x.Grid
+	}
+	return nil
+}
+
+// Эйлеровы углы (в радианах) в последовательности осей 3-1-3 и скорости их изменения.
+type Orientation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Date          float64                `protobuf:"fixed64,1,opt,name=date,proto3" json:"date,omitempty"`
+	Angles        *Vector                `protobuf:"bytes,2,opt,name=angles,proto3" json:"angles,omitempty"`
+	Rates         *Vector                `protobuf:"bytes,3,opt,name=rates,proto3" json:"rates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Orientation) Reset() {
+	*x = Orientation{}
+	mi := &file_ephemeris_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Orientation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Orientation) ProtoMessage() {}
+
+func (x *Orientation) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Orientation.ProtoReflect.Descriptor instead.
+func (*Orientation) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Orientation) GetDate() float64 {
+	if x != nil {
+		return x.Date
+	}
+	return 0
+}
+
+func (x *Orientation) GetAngles() *Vector {
+	if x != nil {
+		return x.Angles
+	}
+	return nil
+}
+
+func (x *Orientation) GetRates() *Vector {
+	if x != nil {
+		return x.Rates
+	}
+	return nil
+}
+
+type OrientationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Frame         int32                  `protobuf:"varint,1,opt,name=frame,proto3" json:"frame,omitempty"`
+	Orientations  []*Orientation         `protobuf:"bytes,2,rep,name=orientations,proto3" json:"orientations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrientationResponse) Reset() {
+	*x = OrientationResponse{}
+	mi := &file_ephemeris_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrientationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrientationResponse) ProtoMessage() {}
+
+func (x *OrientationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrientationResponse.ProtoReflect.Descriptor instead.
+func (*OrientationResponse) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *OrientationResponse) GetFrame() int32 {
+	if x != nil {
+		return x.Frame
+	}
+	return 0
+}
+
+func (x *OrientationResponse) GetOrientations() []*Orientation {
+	if x != nil {
+		return x.Orientations
+	}
+	return nil
+}
+
+type TimeDiffRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// код разности шкал; 0 - TT - TDB
+	Code          int32     `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Grid          *TimeGrid `protobuf:"bytes,2,opt,name=grid,proto3" json:"grid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TimeDiffRequest) Reset() {
+	*x = TimeDiffRequest{}
+	mi := &file_ephemeris_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeDiffRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeDiffRequest) ProtoMessage() {}
+
+func (x *TimeDiffRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeDiffRequest.ProtoReflect.Descriptor instead.
+func (*TimeDiffRequest) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TimeDiffRequest) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *TimeDiffRequest) GetGrid() *TimeGrid {
+	if x != nil {
+		return x.Grid
+	}
+	return nil
+}
+
+type TimeDiff struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Date          float64                `protobuf:"fixed64,1,opt,name=date,proto3" json:"date,omitempty"`
+	Diff          float64                `protobuf:"fixed64,2,opt,name=diff,proto3" json:"diff,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TimeDiff) Reset() {
+	*x = TimeDiff{}
+	mi := &file_ephemeris_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeDiff) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeDiff) ProtoMessage() {}
+
+func (x *TimeDiff) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeDiff.ProtoReflect.Descriptor instead.
+func (*TimeDiff) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TimeDiff) GetDate() float64 {
+	if x != nil {
+		return x.Date
+	}
+	return 0
+}
+
+func (x *TimeDiff) GetDiff() float64 {
+	if x != nil {
+		return x.Diff
+	}
+	return 0
+}
+
+type TimeDiffResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Diffs         []*TimeDiff            `protobuf:"bytes,2,rep,name=diffs,proto3" json:"diffs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TimeDiffResponse) Reset() {
+	*x = TimeDiffResponse{}
+	mi := &file_ephemeris_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeDiffResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeDiffResponse) ProtoMessage() {}
+
+func (x *TimeDiffResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeDiffResponse.ProtoReflect.Descriptor instead.
+func (*TimeDiffResponse) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TimeDiffResponse) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *TimeDiffResponse) GetDiffs() []*TimeDiff {
+	if x != nil {
+		return x.Diffs
+	}
+	return nil
+}
+
+type ConvertTimeScaleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dates         []float64              `protobuf:"fixed64,1,rep,packed,name=dates,proto3" json:"dates,omitempty"`
+	From          TimeScale              `protobuf:"varint,2,opt,name=from,proto3,enum=rightround.v1.TimeScale" json:"from,omitempty"`
+	To            TimeScale              `protobuf:"varint,3,opt,name=to,proto3,enum=rightround.v1.TimeScale" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertTimeScaleRequest) Reset() {
+	*x = ConvertTimeScaleRequest{}
+	mi := &file_ephemeris_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertTimeScaleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertTimeScaleRequest) ProtoMessage() {}
+
+func (x *ConvertTimeScaleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertTimeScaleRequest.ProtoReflect.Descriptor instead.
+func (*ConvertTimeScaleRequest) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ConvertTimeScaleRequest) GetDates() []float64 {
+	if x != nil {
+		return x.Dates
+	}
+	return nil
+}
+
+func (x *ConvertTimeScaleRequest) GetFrom() TimeScale {
+	if x != nil {
+		return x.From
+	}
+	return TimeScale_TIME_SCALE_UNSPECIFIED
+}
+
+func (x *ConvertTimeScaleRequest) GetTo() TimeScale {
+	if x != nil {
+		return x.To
+	}
+	return TimeScale_TIME_SCALE_UNSPECIFIED
+}
+
+type ConvertTimeScaleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dates         []float64              `protobuf:"fixed64,1,rep,packed,name=dates,proto3" json:"dates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertTimeScaleResponse) Reset() {
+	*x = ConvertTimeScaleResponse{}
+	mi := &file_ephemeris_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertTimeScaleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertTimeScaleResponse) ProtoMessage() {}
+
+func (x *ConvertTimeScaleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertTimeScaleResponse.ProtoReflect.Descriptor instead.
+func (*ConvertTimeScaleResponse) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ConvertTimeScaleResponse) GetDates() []float64 {
+	if x != nil {
+		return x.Dates
+	}
+	return nil
+}
+
+type CoverageRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Object int32                  `protobuf:"varint,1,opt,name=object,proto3" json:"object,omitempty"`
+	// центр; если не задан, учитываются сегменты относительно любого центра
+	Basis         *int32 `protobuf:"varint,2,opt,name=basis,proto3,oneof" json:"basis,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CoverageRequest) Reset() {
+	*x = CoverageRequest{}
+	mi := &file_ephemeris_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CoverageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CoverageRequest) ProtoMessage() {}
+
+func (x *CoverageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CoverageRequest.ProtoReflect.Descriptor instead.
+func (*CoverageRequest) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CoverageRequest) GetObject() int32 {
+	if x != nil {
+		return x.Object
+	}
+	return 0
+}
+
+func (x *CoverageRequest) GetBasis() int32 {
+	if x != nil && x.Basis != nil {
+		return *x.Basis
+	}
+	return 0
+}
+
+type Interval struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         float64                `protobuf:"fixed64,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           float64                `protobuf:"fixed64,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Interval) Reset() {
+	*x = Interval{}
+	mi := &file_ephemeris_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Interval) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Interval) ProtoMessage() {}
+
+func (x *Interval) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Interval.ProtoReflect.Descriptor instead.
+func (*Interval) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Interval) GetStart() float64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *Interval) GetEnd() float64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type CoverageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Object        int32                  `protobuf:"varint,1,opt,name=object,proto3" json:"object,omitempty"`
+	Intervals     []*Interval            `protobuf:"bytes,2,rep,name=intervals,proto3" json:"intervals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CoverageResponse) Reset() {
+	*x = CoverageResponse{}
+	mi := &file_ephemeris_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CoverageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CoverageResponse) ProtoMessage() {}
+
+func (x *CoverageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ephemeris_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CoverageResponse.ProtoReflect.Descriptor instead.
+func (*CoverageResponse) Descriptor() ([]byte, []int) {
+	return file_ephemeris_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CoverageResponse) GetObject() int32 {
+	if x != nil {
+		return x.Object
+	}
+	return 0
+}
+
+func (x *CoverageResponse) GetIntervals() []*Interval {
+	if x != nil {
+		return x.Intervals
+	}
+	return nil
+}
+
+var File_ephemeris_proto protoreflect.FileDescriptor
+
+const file_ephemeris_proto_rawDesc = "" +
+	"\n" +
+	"\x0fephemeris.proto\x12\rrightround.v1\"\\\n" +
+	"\bTimeGrid\x12\x14\n" +
+	"\x05dates\x18\x01 \x03(\x01R\x05dates\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\x01R\x05start\x12\x10\n" +
+	"\x03end\x18\x03 \x01(\x01R\x03end\x12\x12\n" +
+	"\x04step\x18\x04 \x01(\x01R\x04step\"2\n" +
+	"\x06Vector\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x01R\x01y\x12\f\n" +
+	"\x01z\x18\x03 \x01(\x01R\x01z\"i\n" +
+	"\fStateRequest\x12\x16\n" +
+	"\x06object\x18\x01 \x01(\x05R\x06object\x12\x14\n" +
+	"\x05basis\x18\x02 \x01(\x05R\x05basis\x12+\n" +
+	"\x04grid\x18\x03 \x01(\v2\x17.rightround.v1.TimeGridR\x04grid\"\x81\x01\n" +
+	"\x05State\x12\x12\n" +
+	"\x04date\x18\x01 \x01(\x01R\x04date\x121\n" +
+	"\bposition\x18\x02 \x01(\v2\x15.rightround.v1.VectorR\bposition\x121\n" +
+	"\bvelocity\x18\x03 \x01(\v2\x15.rightround.v1.VectorR\bvelocity\"k\n" +
+	"\rStateResponse\x12\x16\n" +
+	"\x06object\x18\x01 \x01(\x05R\x06object\x12\x14\n" +
+	"\x05basis\x18\x02 \x01(\x05R\x05basis\x12,\n" +
+	"\x06states\x18\x03 \x03(\v2\x14.rightround.v1.StateR\x06states\"W\n" +
+	"\x12OrientationRequest\x12\x14\n" +
+	"\x05frame\x18\x01 \x01(\x05R\x05frame\x12+\n" +
+	"\x04grid\x18\x02 \x01(\v2\x17.rightround.v1.TimeGridR\x04grid\"}\n" +
+	"\vOrientation\x12\x12\n" +
+	"\x04date\x18\x01 \x01(\x01R\x04date\x12-\n" +
+	"\x06angles\x18\x02 \x01(\v2\x15.rightround.v1.VectorR\x06angles\x12+\n" +
+	"\x05rates\x18\x03 \x01(\v2\x15.rightround.v1.VectorR\x05rates\"k\n" +
+	"\x13OrientationResponse\x12\x14\n" +
+	"\x05frame\x18\x01 \x01(\x05R\x05frame\x12>\n" +
+	"\forientations\x18\x02 \x03(\v2\x1a.rightround.v1.OrientationR\forientations\"R\n" +
+	"\x0fTimeDiffRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12+\n" +
+	"\x04grid\x18\x02 \x01(\v2\x17.rightround.v1.TimeGridR\x04grid\"2\n" +
+	"\bTimeDiff\x12\x12\n" +
+	"\x04date\x18\x01 \x01(\x01R\x04date\x12\x12\n" +
+	"\x04diff\x18\x02 \x01(\x01R\x04diff\"U\n" +
+	"\x10TimeDiffResponse\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12-\n" +
+	"\x05diffs\x18\x02 \x03(\v2\x17.rightround.v1.TimeDiffR\x05diffs\"\x87\x01\n" +
+	"\x17ConvertTimeScaleRequest\x12\x14\n" +
+	"\x05dates\x18\x01 \x03(\x01R\x05dates\x12,\n" +
+	"\x04from\x18\x02 \x01(\x0e2\x18.rightround.v1.TimeScaleR\x04from\x12(\n" +
+	"\x02to\x18\x03 \x01(\x0e2\x18.rightround.v1.TimeScaleR\x02to\"0\n" +
+	"\x18ConvertTimeScaleResponse\x12\x14\n" +
+	"\x05dates\x18\x01 \x03(\x01R\x05dates\"N\n" +
+	"\x0fCoverageRequest\x12\x16\n" +
+	"\x06object\x18\x01 \x01(\x05R\x06object\x12\x19\n" +
+	"\x05basis\x18\x02 \x01(\x05H\x00R\x05basis\x88\x01\x01B\b\n" +
+	"\x06_basis\"2\n" +
+	"\bInterval\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x01R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x01R\x03end\"a\n" +
+	"\x10CoverageResponse\x12\x16\n" +
+	"\x06object\x18\x01 \x01(\x05R\x06object\x125\n" +
+	"\tintervals\x18\x02 \x03(\v2\x17.rightround.v1.IntervalR\tintervals*v\n" +
+	"\tTimeScale\x12\x1a\n" +
+	"\x16TIME_SCALE_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eTIME_SCALE_TDB\x10\x01\x12\x11\n" +
+	"\rTIME_SCALE_TT\x10\x02\x12\x12\n" +
+	"\x0eTIME_SCALE_UTC\x10\x03\x12\x12\n" +
+	"\x0eTIME_SCALE_UT1\x10\x042\xdc\x04\n" +
+	"\tEphemeris\x12F\n" +
+	"\tGetStates\x12\x1b.rightround.v1.StateRequest\x1a\x1c.rightround.v1.StateResponse\x12K\n" +
+	"\fStreamStates\x12\x1b.rightround.v1.StateRequest\x1a\x1c.rightround.v1.StateResponse0\x01\x12W\n" +
+	"\x0eGetOrientation\x12!.rightround.v1.OrientationRequest\x1a\".rightround.v1.OrientationResponse\x12\\\n" +
+	"\x11StreamOrientation\x12!.rightround.v1.OrientationRequest\x1a\".rightround.v1.OrientationResponse0\x01\x12N\n" +
+	"\vGetTimeDiff\x12\x1e.rightround.v1.TimeDiffRequest\x1a\x1f.rightround.v1.TimeDiffResponse\x12c\n" +
+	"\x10ConvertTimeScale\x12&.rightround.v1.ConvertTimeScaleRequest\x1a'.rightround.v1.ConvertTimeScaleResponse\x12N\n" +
+	"\vGetCoverage\x12\x1e.rightround.v1.CoverageRequest\x1a\x1f.rightround.v1.CoverageResponseB6Z4github.com/dvoeglazyi/rightround/grpcapi/ephemerispbb\x06proto3"
+
+var (
+	file_ephemeris_proto_rawDescOnce sync.Once
+	file_ephemeris_proto_rawDescData []byte
+)
+
+func file_ephemeris_proto_rawDescGZIP() []byte {
+	file_ephemeris_proto_rawDescOnce.Do(func() {
+		file_ephemeris_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ephemeris_proto_rawDesc), len(file_ephemeris_proto_rawDesc)))
+	})
+	return file_ephemeris_proto_rawDescData
+}
+
+var file_ephemeris_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_ephemeris_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_ephemeris_proto_goTypes = []any{
+	(TimeScale)(0),                   // 0: rightround.v1.TimeScale
+	(*TimeGrid)(nil),                 // 1: rightround.v1.TimeGrid
+	(*Vector)(nil),                   // 2: rightround.v1.Vector
+	(*StateRequest)(nil),             // 3: rightround.v1.StateRequest
+	(*State)(nil),                    // 4: rightround.v1.State
+	(*StateResponse)(nil),            // 5: rightround.v1.StateResponse
+	(*OrientationRequest)(nil),       // 6: rightround.v1.OrientationRequest
+	(*Orientation)(nil),              // 7: rightround.v1.Orientation
+	(*OrientationResponse)(nil),      // 8: rightround.v1.OrientationResponse
+	(*TimeDiffRequest)(nil),          // 9: rightround.v1.TimeDiffRequest
+	(*TimeDiff)(nil),                 // 10: rightround.v1.TimeDiff
+	(*TimeDiffResponse)(nil),         // 11: rightround.v1.TimeDiffResponse
+	(*ConvertTimeScaleRequest)(nil),  // 12: rightround.v1.ConvertTimeScaleRequest
+	(*ConvertTimeScaleResponse)(nil), // 13: rightround.v1.ConvertTimeScaleResponse
+	(*CoverageRequest)(nil),          // 14: rightround.v1.CoverageRequest
+	(*Interval)(nil),                 // 15: rightround.v1.Interval
+	(*CoverageResponse)(nil),         // 16: rightround.v1.CoverageResponse
+}
+var file_ephemeris_proto_depIdxs = []int32{
+	1,  // 0: rightround.v1.StateRequest.grid:type_name -> rightround.v1.TimeGrid
+	2,  // 1: rightround.v1.State.position:type_name -> rightround.v1.Vector
+	2,  // 2: rightround.v1.State.velocity:type_name -> rightround.v1.Vector
+	4,  // 3: rightround.v1.StateResponse.states:type_name -> rightround.v1.State
+	1,  // 4: rightround.v1.OrientationRequest.grid:type_name -> rightround.v1.TimeGrid
+	2,  // 5: rightround.v1.Orientation.angles:type_name -> rightround.v1.Vector
+	2,  // 6: rightround.v1.Orientation.rates:type_name -> rightround.v1.Vector
+	7,  // 7: rightround.v1.OrientationResponse.orientations:type_name -> rightround.v1.Orientation
+	1,  // 8: rightround.v1.TimeDiffRequest.grid:type_name -> rightround.v1.TimeGrid
+	10, // 9: rightround.v1.TimeDiffResponse.diffs:type_name -> rightround.v1.TimeDiff
+	0,  // 10: rightround.v1.ConvertTimeScaleRequest.from:type_name -> rightround.v1.TimeScale
+	0,  // 11: rightround.v1.ConvertTimeScaleRequest.to:type_name -> rightround.v1.TimeScale
+	15, // 12: rightround.v1.CoverageResponse.intervals:type_name -> rightround.v1.Interval
+	3,  // 13: rightround.v1.Ephemeris.GetStates:input_type -> rightround.v1.StateRequest
+	3,  // 14: rightround.v1.Ephemeris.StreamStates:input_type -> rightround.v1.StateRequest
+	6,  // 15: rightround.v1.Ephemeris.GetOrientation:input_type -> rightround.v1.OrientationRequest
+	6,  // 16: rightround.v1.Ephemeris.StreamOrientation:input_type -> rightround.v1.OrientationRequest
+	9,  // 17: rightround.v1.Ephemeris.GetTimeDiff:input_type -> rightround.v1.TimeDiffRequest
+	12, // 18: rightround.v1.Ephemeris.ConvertTimeScale:input_type -> rightround.v1.ConvertTimeScaleRequest
+	14, // 19: rightround.v1.Ephemeris.GetCoverage:input_type -> rightround.v1.CoverageRequest
+	5,  // 20: rightround.v1.Ephemeris.GetStates:output_type -> rightround.v1.StateResponse
+	5,  // 21: rightround.v1.Ephemeris.StreamStates:output_type -> rightround.v1.StateResponse
+	8,  // 22: rightround.v1.Ephemeris.GetOrientation:output_type -> rightround.v1.OrientationResponse
+	8,  // 23: rightround.v1.Ephemeris.StreamOrientation:output_type -> rightround.v1.OrientationResponse
+	11, // 24: rightround.v1.Ephemeris.GetTimeDiff:output_type -> rightround.v1.TimeDiffResponse
+	13, // 25: rightround.v1.Ephemeris.ConvertTimeScale:output_type -> rightround.v1.ConvertTimeScaleResponse
+	16, // 26: rightround.v1.Ephemeris.GetCoverage:output_type -> rightround.v1.CoverageResponse
+	20, // [20:27] is the sub-list for method output_type
+	13, // [13:20] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_ephemeris_proto_init() }
+func file_ephemeris_proto_init() {
+	if File_ephemeris_proto != nil {
+		return
+	}
+	file_ephemeris_proto_msgTypes[13].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ephemeris_proto_rawDesc), len(file_ephemeris_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ephemeris_proto_goTypes,
+		DependencyIndexes: file_ephemeris_proto_depIdxs,
+		EnumInfos:         file_ephemeris_proto_enumTypes,
+		MessageInfos:      file_ephemeris_proto_msgTypes,
+	}.Build()
+	File_ephemeris_proto = out.File
+	file_ephemeris_proto_goTypes = nil
+	file_ephemeris_proto_depIdxs = nil
+}
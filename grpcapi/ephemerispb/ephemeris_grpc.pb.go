@@ -0,0 +1,373 @@
+// Схема gRPC-сервиса расчёта эфемерид.
+// Даты задаются юлианскими датами в шкале TDB, если не указано иное.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: ephemeris.proto
+
+package ephemerispb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Ephemeris_GetStates_FullMethodName         = "/rightround.v1.Ephemeris/GetStates"
+	Ephemeris_StreamStates_FullMethodName      = "/rightround.v1.Ephemeris/StreamStates"
+	Ephemeris_GetOrientation_FullMethodName    = "/rightround.v1.Ephemeris/GetOrientation"
+	Ephemeris_StreamOrientation_FullMethodName = "/rightround.v1.Ephemeris/StreamOrientation"
+	Ephemeris_GetTimeDiff_FullMethodName       = "/rightround.v1.Ephemeris/GetTimeDiff"
+	Ephemeris_ConvertTimeScale_FullMethodName  = "/rightround.v1.Ephemeris/ConvertTimeScale"
+	Ephemeris_GetCoverage_FullMethodName       = "/rightround.v1.Ephemeris/GetCoverage"
+)
+
+// EphemerisClient is the client API for Ephemeris service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EphemerisClient interface {
+	// GetStates возвращает положения и скорости объекта относительно центра на датах сетки.
+	GetStates(ctx context.Context, in *StateRequest, opts ...grpc.CallOption) (*StateResponse, error)
+	// StreamStates возвращает положения и скорости частями для длинных сеток дат.
+	StreamStates(ctx context.Context, in *StateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StateResponse], error)
+	// GetOrientation возвращает эйлеровы углы системы координат PCK на датах сетки.
+	GetOrientation(ctx context.Context, in *OrientationRequest, opts ...grpc.CallOption) (*OrientationResponse, error)
+	// StreamOrientation возвращает эйлеровы углы частями для длинных сеток дат.
+	StreamOrientation(ctx context.Context, in *OrientationRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrientationResponse], error)
+	// GetTimeDiff возвращает разность шкал времени (по умолчанию TT - TDB) на датах сетки.
+	GetTimeDiff(ctx context.Context, in *TimeDiffRequest, opts ...grpc.CallOption) (*TimeDiffResponse, error)
+	// ConvertTimeScale переводит даты из одной шкалы времени в другую.
+	ConvertTimeScale(ctx context.Context, in *ConvertTimeScaleRequest, opts ...grpc.CallOption) (*ConvertTimeScaleResponse, error)
+	// GetCoverage возвращает интервалы дат, на которых определён объект.
+	GetCoverage(ctx context.Context, in *CoverageRequest, opts ...grpc.CallOption) (*CoverageResponse, error)
+}
+
+type ephemerisClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEphemerisClient(cc grpc.ClientConnInterface) EphemerisClient {
+	return &ephemerisClient{cc}
+}
+
+func (c *ephemerisClient) GetStates(ctx context.Context, in *StateRequest, opts ...grpc.CallOption) (*StateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StateResponse)
+	err := c.cc.Invoke(ctx, Ephemeris_GetStates_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ephemerisClient) StreamStates(ctx context.Context, in *StateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StateResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Ephemeris_ServiceDesc.Streams[0], Ephemeris_StreamStates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StateRequest, StateResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ephemeris_StreamStatesClient = grpc.ServerStreamingClient[StateResponse]
+
+func (c *ephemerisClient) GetOrientation(ctx context.Context, in *OrientationRequest, opts ...grpc.CallOption) (*OrientationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrientationResponse)
+	err := c.cc.Invoke(ctx, Ephemeris_GetOrientation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ephemerisClient) StreamOrientation(ctx context.Context, in *OrientationRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrientationResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Ephemeris_ServiceDesc.Streams[1], Ephemeris_StreamOrientation_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[OrientationRequest, OrientationResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ephemeris_StreamOrientationClient = grpc.ServerStreamingClient[OrientationResponse]
+
+func (c *ephemerisClient) GetTimeDiff(ctx context.Context, in *TimeDiffRequest, opts ...grpc.CallOption) (*TimeDiffResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TimeDiffResponse)
+	err := c.cc.Invoke(ctx, Ephemeris_GetTimeDiff_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ephemerisClient) ConvertTimeScale(ctx context.Context, in *ConvertTimeScaleRequest, opts ...grpc.CallOption) (*ConvertTimeScaleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConvertTimeScaleResponse)
+	err := c.cc.Invoke(ctx, Ephemeris_ConvertTimeScale_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ephemerisClient) GetCoverage(ctx context.Context, in *CoverageRequest, opts ...grpc.CallOption) (*CoverageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CoverageResponse)
+	err := c.cc.Invoke(ctx, Ephemeris_GetCoverage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EphemerisServer is the server API for Ephemeris service.
+// All implementations must embed UnimplementedEphemerisServer
+// for forward compatibility.
+type EphemerisServer interface {
+	// GetStates возвращает положения и скорости объекта относительно центра на датах сетки.
+	GetStates(context.Context, *StateRequest) (*StateResponse, error)
+	// StreamStates возвращает положения и скорости частями для длинных сеток дат.
+	StreamStates(*StateRequest, grpc.ServerStreamingServer[StateResponse]) error
+	// GetOrientation возвращает эйлеровы углы системы координат PCK на датах сетки.
+	GetOrientation(context.Context, *OrientationRequest) (*OrientationResponse, error)
+	// StreamOrientation возвращает эйлеровы углы частями для длинных сеток дат.
+	StreamOrientation(*OrientationRequest, grpc.ServerStreamingServer[OrientationResponse]) error
+	// GetTimeDiff возвращает разность шкал времени (по умолчанию TT - TDB) на датах сетки.
+	GetTimeDiff(context.Context, *TimeDiffRequest) (*TimeDiffResponse, error)
+	// ConvertTimeScale переводит даты из одной шкалы времени в другую.
+	ConvertTimeScale(context.Context, *ConvertTimeScaleRequest) (*ConvertTimeScaleResponse, error)
+	// GetCoverage возвращает интервалы дат, на которых определён объект.
+	GetCoverage(context.Context, *CoverageRequest) (*CoverageResponse, error)
+	mustEmbedUnimplementedEphemerisServer()
+}
+
+// UnimplementedEphemerisServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEphemerisServer struct{}
+
+func (UnimplementedEphemerisServer) GetStates(context.Context, *StateRequest) (*StateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStates not implemented")
+}
+func (UnimplementedEphemerisServer) StreamStates(*StateRequest, grpc.ServerStreamingServer[StateResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamStates not implemented")
+}
+func (UnimplementedEphemerisServer) GetOrientation(context.Context, *OrientationRequest) (*OrientationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrientation not implemented")
+}
+func (UnimplementedEphemerisServer) StreamOrientation(*OrientationRequest, grpc.ServerStreamingServer[OrientationResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamOrientation not implemented")
+}
+func (UnimplementedEphemerisServer) GetTimeDiff(context.Context, *TimeDiffRequest) (*TimeDiffResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTimeDiff not implemented")
+}
+func (UnimplementedEphemerisServer) ConvertTimeScale(context.Context, *ConvertTimeScaleRequest) (*ConvertTimeScaleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConvertTimeScale not implemented")
+}
+func (UnimplementedEphemerisServer) GetCoverage(context.Context, *CoverageRequest) (*CoverageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCoverage not implemented")
+}
+func (UnimplementedEphemerisServer) mustEmbedUnimplementedEphemerisServer() {}
+func (UnimplementedEphemerisServer) testEmbeddedByValue()                   {}
+
+// UnsafeEphemerisServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EphemerisServer will
+// result in compilation errors.
+type UnsafeEphemerisServer interface {
+	mustEmbedUnimplementedEphemerisServer()
+}
+
+func RegisterEphemerisServer(s grpc.ServiceRegistrar, srv EphemerisServer) {
+	// If the following call pancis, it indicates UnimplementedEphemerisServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Ephemeris_ServiceDesc, srv)
+}
+
+func _Ephemeris_GetStates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EphemerisServer).GetStates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ephemeris_GetStates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EphemerisServer).GetStates(ctx, req.(*StateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ephemeris_StreamStates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EphemerisServer).StreamStates(m, &grpc.GenericServerStream[StateRequest, StateResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ephemeris_StreamStatesServer = grpc.ServerStreamingServer[StateResponse]
+
+func _Ephemeris_GetOrientation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrientationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EphemerisServer).GetOrientation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ephemeris_GetOrientation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EphemerisServer).GetOrientation(ctx, req.(*OrientationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ephemeris_StreamOrientation_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(OrientationRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EphemerisServer).StreamOrientation(m, &grpc.GenericServerStream[OrientationRequest, OrientationResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ephemeris_StreamOrientationServer = grpc.ServerStreamingServer[OrientationResponse]
+
+func _Ephemeris_GetTimeDiff_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TimeDiffRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EphemerisServer).GetTimeDiff(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ephemeris_GetTimeDiff_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EphemerisServer).GetTimeDiff(ctx, req.(*TimeDiffRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ephemeris_ConvertTimeScale_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertTimeScaleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EphemerisServer).ConvertTimeScale(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ephemeris_ConvertTimeScale_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EphemerisServer).ConvertTimeScale(ctx, req.(*ConvertTimeScaleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ephemeris_GetCoverage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CoverageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EphemerisServer).GetCoverage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ephemeris_GetCoverage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EphemerisServer).GetCoverage(ctx, req.(*CoverageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Ephemeris_ServiceDesc is the grpc.ServiceDesc for Ephemeris service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Ephemeris_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rightround.v1.Ephemeris",
+	HandlerType: (*EphemerisServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStates",
+			Handler:    _Ephemeris_GetStates_Handler,
+		},
+		{
+			MethodName: "GetOrientation",
+			Handler:    _Ephemeris_GetOrientation_Handler,
+		},
+		{
+			MethodName: "GetTimeDiff",
+			Handler:    _Ephemeris_GetTimeDiff_Handler,
+		},
+		{
+			MethodName: "ConvertTimeScale",
+			Handler:    _Ephemeris_ConvertTimeScale_Handler,
+		},
+		{
+			MethodName: "GetCoverage",
+			Handler:    _Ephemeris_GetCoverage_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamStates",
+			Handler:       _Ephemeris_StreamStates_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamOrientation",
+			Handler:       _Ephemeris_StreamOrientation_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ephemeris.proto",
+}
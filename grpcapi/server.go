@@ -0,0 +1,319 @@
+// Package grpcapi реализует gRPC-сервис rightround.v1.Ephemeris (схема в ephemerispb/ephemeris.proto)
+// поверх загруженных эфемерид.
+//
+// Пакет вынесен в отдельный модуль, чтобы основная библиотека не зависела от gRPC.
+// Код ephemerispb создаётся командой
+//
+//	protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative ephemeris.proto
+//
+// в каталоге ephemerispb.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dvoeglazyi/rightround"
+	"github.com/dvoeglazyi/rightround/grpcapi/ephemerispb"
+)
+
+const (
+	// defaultMaxDates ограничение количества дат в одном запросе по умолчанию.
+	defaultMaxDates = 1000000
+	// defaultChunkSize количество значений в одном сообщении потока по умолчанию.
+	defaultChunkSize = 1000
+)
+
+// Server реализация сервиса эфемерид.
+// Ephemeris не допускает одновременного использования, поэтому расчёты выполняются последовательно;
+// потоковые методы передают части по мере расчёта, не накапливая сетку в памяти; блокировка
+// снимается на время передачи каждой части, чтобы медленный клиент не задерживал другие вызовы.
+type Server struct {
+	ephemerispb.UnimplementedEphemerisServer
+
+	// MaxDates ограничение количества дат в одном запросе.
+	MaxDates int
+	// ChunkSize количество значений в одном сообщении потоковых методов.
+	ChunkSize int
+
+	ephemeris *rightround.Ephemeris
+	mutex     sync.Mutex
+}
+
+// NewServer создаёт сервис для загруженных эфемерид.
+// После создания сервиса эфемериды не должны использоваться напрямую без синхронизации.
+func NewServer(ephemeris *rightround.Ephemeris) *Server {
+	return &Server{MaxDates: defaultMaxDates, ChunkSize: defaultChunkSize, ephemeris: ephemeris}
+}
+
+// timeGrid проверяет набор дат запроса и возвращает соответствующую сетку.
+func (s *Server) timeGrid(grid *ephemerispb.TimeGrid) (rightround.TimeGrid, error) {
+	if grid == nil {
+		return rightround.TimeGrid{}, status.Error(codes.InvalidArgument, "grid is required")
+	}
+	result := rightround.TimeGrid{Dates: grid.GetDates()}
+	if len(result.Dates) == 0 {
+		result = rightround.TimeGrid{Start: grid.GetStart(), End: grid.GetStart(), Step: 1}
+		if grid.GetEnd() != 0 || grid.GetStep() != 0 {
+			result.End, result.Step = grid.GetEnd(), grid.GetStep()
+		}
+	}
+	count, err := result.Len()
+	if err != nil {
+		return rightround.TimeGrid{}, toStatus(err)
+	}
+	if count > s.MaxDates {
+		return rightround.TimeGrid{}, status.Errorf(codes.InvalidArgument, "too many dates (%d > %d)", count, s.MaxDates)
+	}
+	return result, nil
+}
+
+func (s *Server) states(request *ephemerispb.StateRequest) ([]*ephemerispb.State, error) {
+	grid, err := s.timeGrid(request.GetGrid())
+	if err != nil {
+		return nil, err
+	}
+	var states []*ephemerispb.State
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err = s.ephemeris.EvaluateStates(int(request.GetObject()), int(request.GetBasis()), grid, func(sample rightround.StateSample) error {
+		states = append(states, &ephemerispb.State{
+			Date:     sample.Date,
+			Position: vector(sample.Coords),
+			Velocity: vector(sample.Velocity),
+		})
+		return nil
+	})
+	return states, toStatus(err)
+}
+
+// GetStates возвращает положения и скорости объекта относительно центра на датах сетки.
+func (s *Server) GetStates(ctx context.Context, request *ephemerispb.StateRequest) (*ephemerispb.StateResponse, error) {
+	states, err := s.states(request)
+	if err != nil {
+		return nil, err
+	}
+	return &ephemerispb.StateResponse{Object: request.GetObject(), Basis: request.GetBasis(), States: states}, nil
+}
+
+// StreamStates возвращает положения и скорости частями по ChunkSize значений.
+func (s *Server) StreamStates(request *ephemerispb.StateRequest, stream ephemerispb.Ephemeris_StreamStatesServer) error {
+	grid, err := s.timeGrid(request.GetGrid())
+	if err != nil {
+		return err
+	}
+	response := &ephemerispb.StateResponse{Object: request.GetObject(), Basis: request.GetBasis()}
+	sender := s.newChunkSender(stream.Context(), func() error {
+		err := stream.Send(response)
+		response = &ephemerispb.StateResponse{Object: request.GetObject(), Basis: request.GetBasis()}
+		return err
+	})
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err = s.ephemeris.EvaluateStates(int(request.GetObject()), int(request.GetBasis()), grid, func(sample rightround.StateSample) error {
+		response.States = append(response.States, &ephemerispb.State{
+			Date:     sample.Date,
+			Position: vector(sample.Coords),
+			Velocity: vector(sample.Velocity),
+		})
+		return sender.add()
+	})
+	return sender.finish(err)
+}
+
+func (s *Server) orientations(request *ephemerispb.OrientationRequest) ([]*ephemerispb.Orientation, error) {
+	grid, err := s.timeGrid(request.GetGrid())
+	if err != nil {
+		return nil, err
+	}
+	var orientations []*ephemerispb.Orientation
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err = s.ephemeris.EvaluateEulerAngles(int(request.GetFrame()), grid, func(sample rightround.AnglesSample) error {
+		orientations = append(orientations, &ephemerispb.Orientation{
+			Date:   sample.Date,
+			Angles: vector(sample.Angles),
+			Rates:  vector(sample.Rates),
+		})
+		return nil
+	})
+	return orientations, toStatus(err)
+}
+
+// GetOrientation возвращает эйлеровы углы системы координат PCK на датах сетки.
+func (s *Server) GetOrientation(ctx context.Context, request *ephemerispb.OrientationRequest) (*ephemerispb.OrientationResponse, error) {
+	orientations, err := s.orientations(request)
+	if err != nil {
+		return nil, err
+	}
+	return &ephemerispb.OrientationResponse{Frame: request.GetFrame(), Orientations: orientations}, nil
+}
+
+// StreamOrientation возвращает эйлеровы углы частями по ChunkSize значений.
+func (s *Server) StreamOrientation(request *ephemerispb.OrientationRequest, stream ephemerispb.Ephemeris_StreamOrientationServer) error {
+	grid, err := s.timeGrid(request.GetGrid())
+	if err != nil {
+		return err
+	}
+	response := &ephemerispb.OrientationResponse{Frame: request.GetFrame()}
+	sender := s.newChunkSender(stream.Context(), func() error {
+		err := stream.Send(response)
+		response = &ephemerispb.OrientationResponse{Frame: request.GetFrame()}
+		return err
+	})
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err = s.ephemeris.EvaluateEulerAngles(int(request.GetFrame()), grid, func(sample rightround.AnglesSample) error {
+		response.Orientations = append(response.Orientations, &ephemerispb.Orientation{
+			Date:   sample.Date,
+			Angles: vector(sample.Angles),
+			Rates:  vector(sample.Rates),
+		})
+		return sender.add()
+	})
+	return sender.finish(err)
+}
+
+// GetTimeDiff возвращает разность шкал времени на датах сетки.
+func (s *Server) GetTimeDiff(ctx context.Context, request *ephemerispb.TimeDiffRequest) (*ephemerispb.TimeDiffResponse, error) {
+	grid, err := s.timeGrid(request.GetGrid())
+	if err != nil {
+		return nil, err
+	}
+	code := request.GetCode()
+	if code == 0 {
+		code = rightround.EphemerisCodeMinusTDB
+	}
+	response := &ephemerispb.TimeDiffResponse{Code: code}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err = s.ephemeris.EvaluateTimeDiff(int(code), grid, func(sample rightround.TimeDiffSample) error {
+		response.Diffs = append(response.Diffs, &ephemerispb.TimeDiff{Date: sample.Date, Diff: sample.Diff})
+		return nil
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return response, nil
+}
+
+// ConvertTimeScale переводит даты из одной шкалы времени в другую.
+func (s *Server) ConvertTimeScale(ctx context.Context, request *ephemerispb.ConvertTimeScaleRequest) (*ephemerispb.ConvertTimeScaleResponse, error) {
+	if len(request.GetDates()) > s.MaxDates {
+		return nil, status.Errorf(codes.InvalidArgument, "too many dates (%d > %d)", len(request.GetDates()), s.MaxDates)
+	}
+	response := &ephemerispb.ConvertTimeScaleResponse{Dates: make([]float64, len(request.GetDates()))}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, date := range request.GetDates() {
+		converted, err := s.ephemeris.ConvertTimeScale(date, int(request.GetFrom()), int(request.GetTo()))
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		response.Dates[i] = converted
+	}
+	return response, nil
+}
+
+// GetCoverage возвращает интервалы дат, на которых определён объект.
+func (s *Server) GetCoverage(ctx context.Context, request *ephemerispb.CoverageRequest) (*ephemerispb.CoverageResponse, error) {
+	s.mutex.Lock()
+	window := s.ephemeris.Coverage(int(request.GetObject()))
+	if request.Basis != nil {
+		window = s.ephemeris.CoverageRelative(int(request.GetObject()), int(request.GetBasis()))
+	}
+	s.mutex.Unlock()
+	response := &ephemerispb.CoverageResponse{Object: request.GetObject()}
+	for _, interval := range window {
+		response.Intervals = append(response.Intervals, &ephemerispb.Interval{Start: interval.Start, End: interval.End})
+	}
+	return response, nil
+}
+
+// chunkSender передаёт значения потока частями по мере их расчёта. Части рассчитываются
+// под блокировкой mutex, которая снимается на время передачи.
+type chunkSender struct {
+	ctx     context.Context
+	mutex   *sync.Mutex
+	size    int
+	send    func() error // передаёт накопленную часть и начинает новую
+	pending int          // количество значений в накопленной части
+	sent    bool
+	err     error // ошибка передачи или отмены запроса
+}
+
+func (s *Server) newChunkSender(ctx context.Context, send func() error) *chunkSender {
+	size := s.ChunkSize
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	return &chunkSender{ctx: ctx, mutex: &s.mutex, size: size, send: send}
+}
+
+// add учитывает очередное значение и передаёт часть, когда в ней набирается size значений.
+func (c *chunkSender) add() error {
+	c.pending++
+	if c.pending < c.size {
+		return nil
+	}
+	return c.flush()
+}
+
+// flush передаёт накопленную часть без блокировки. Вызывается под блокировкой между расчётами
+// соседних значений, когда эфемериды не используются.
+func (c *chunkSender) flush() error {
+	c.mutex.Unlock()
+	defer c.mutex.Lock()
+	err := c.ctx.Err()
+	if err == nil {
+		err = c.send()
+	}
+	if err == nil {
+		// запрос мог быть отменён во время передачи
+		err = c.ctx.Err()
+	}
+	if err != nil {
+		c.err = err
+		return err
+	}
+	c.pending, c.sent = 0, true
+	return nil
+}
+
+// finish завершает поток после расчёта с ошибкой err: передаёт оставшиеся значения
+// (пустой результат передаётся одним сообщением) либо возвращает ошибку.
+func (c *chunkSender) finish(err error) error {
+	if c.err != nil {
+		return c.err
+	}
+	if err != nil {
+		return toStatus(err)
+	}
+	if c.pending > 0 || !c.sent {
+		return c.flush()
+	}
+	return nil
+}
+
+// vector преобразует вектор библиотеки в сообщение.
+func vector(c rightround.Coords) *ephemerispb.Vector {
+	return &ephemerispb.Vector{X: c.X, Y: c.Y, Z: c.Z}
+}
+
+// toStatus преобразует ошибку библиотеки в статус gRPC.
+func toStatus(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, rightround.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, rightround.ErrInvalidGrid), errors.Is(err, rightround.ErrUnknown), errors.Is(err, rightround.ErrUnsupported):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,208 @@
+package grpcapi
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/dvoeglazyi/rightround"
+	"github.com/dvoeglazyi/rightround/grpcapi/ephemerispb"
+)
+
+// testStart и testEnd интервал дат тестовых эфемерид.
+const testStart, testEnd = 2451536.5, 2451600.5
+
+// testServer запускает сервис для файла SPK, в котором X Солнца равна 1000 км + 10 км в сутки от testStart,
+// и возвращает сервис и функцию подключения клиента.
+func testServer(t *testing.T) (*Server, func() ephemerispb.EphemerisClient) {
+	dir, err := ioutil.TempDir("", "grpcapi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	path := filepath.Join(dir, "test.bsp")
+	writer := rightround.NewSPKWriter(path, "grpcapi test")
+	spec := rightround.SegmentSpec{Object: rightround.EphemerisSun, Center: rightround.EphemerisSunSystem, Representation: 2, Start: testStart, End: testEnd}
+	if err := writer.AddChebyshevSegment(spec, func(date1, date2 float64) (rightround.Coords, rightround.Coords, error) {
+		days := (date1 - testStart) + date2
+		return rightround.Coords{X: 1000 + 10*days}, rightround.Coords{X: 10 / 86400.0}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Save(); err != nil {
+		t.Fatal(err)
+	}
+	ephemeris := rightround.NewEphemeris()
+	if err := ephemeris.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(ephemeris)
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	ephemerispb.RegisterEphemerisServer(grpcServer, server)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	connect := func() ephemerispb.EphemerisClient {
+		connection, err := grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = connection.Close() })
+		return ephemerispb.NewEphemerisClient(connection)
+	}
+	return server, connect
+}
+
+// receiveStates читает поток до конца и возвращает размеры сообщений и даты.
+func receiveStates(stream ephemerispb.Ephemeris_StreamStatesClient) ([]int, []float64, error) {
+	var sizes []int
+	var dates []float64
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			return sizes, dates, nil
+		}
+		if err != nil {
+			return sizes, dates, err
+		}
+		sizes = append(sizes, len(response.GetStates()))
+		for _, state := range response.GetStates() {
+			dates = append(dates, state.GetDate())
+		}
+	}
+}
+
+func TestStreamStatesChunks(t *testing.T) {
+	server, connect := testServer(t)
+	server.ChunkSize = 3
+	client := connect()
+	for _, test := range []struct {
+		grid  *ephemerispb.TimeGrid
+		sizes []int
+	}{
+		{&ephemerispb.TimeGrid{Start: 2451540, End: 2451547, Step: 1}, []int{3, 3, 2}},
+		{&ephemerispb.TimeGrid{Start: 2451540, End: 2451545, Step: 1}, []int{3, 3}},
+		{&ephemerispb.TimeGrid{Dates: []float64{2451540, 2451541}}, []int{2}},
+		{&ephemerispb.TimeGrid{Start: 2451540}, []int{1}},
+	} {
+		stream, err := client.StreamStates(context.Background(), &ephemerispb.StateRequest{Object: 10, Grid: test.grid})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes, dates, err := receiveStates(stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sizes) != len(test.sizes) {
+			t.Fatalf("grid %v: sizes %v, expected %v", test.grid, sizes, test.sizes)
+		}
+		for i := range sizes {
+			if sizes[i] != test.sizes[i] {
+				t.Fatalf("grid %v: sizes %v, expected %v", test.grid, sizes, test.sizes)
+			}
+		}
+		for i, date := range dates {
+			if date != 2451540+float64(i) {
+				t.Fatalf("grid %v: dates %v", test.grid, dates)
+			}
+		}
+	}
+}
+
+func TestChunkSenderEmpty(t *testing.T) {
+	// результат без значений передаётся одним пустым сообщением
+	server := NewServer(rightround.NewEphemeris())
+	messages := 0
+	server.mutex.Lock()
+	sender := server.newChunkSender(context.Background(), func() error {
+		messages++
+		return nil
+	})
+	err := sender.finish(nil)
+	server.mutex.Unlock()
+	if err != nil || messages != 1 {
+		t.Fatalf("%d messages, error %v", messages, err)
+	}
+}
+
+func TestStatusCodes(t *testing.T) {
+	_, connect := testServer(t)
+	client := connect()
+	ctx := context.Background()
+	for _, test := range []struct {
+		request *ephemerispb.StateRequest
+		code    codes.Code
+	}{
+		{&ephemerispb.StateRequest{Object: 10}, codes.InvalidArgument},
+		{&ephemerispb.StateRequest{Object: 10, Grid: &ephemerispb.TimeGrid{Start: 2451541, End: 2451540, Step: 1}}, codes.InvalidArgument},
+		{&ephemerispb.StateRequest{Object: 10, Grid: &ephemerispb.TimeGrid{Start: 2451540, End: 2451550, Step: 1e-6}}, codes.InvalidArgument},
+		{&ephemerispb.StateRequest{Object: 499, Grid: &ephemerispb.TimeGrid{Start: 2451540}}, codes.NotFound},
+		{&ephemerispb.StateRequest{Object: 10, Grid: &ephemerispb.TimeGrid{Start: 2451700}}, codes.NotFound},
+	} {
+		if _, err := client.GetStates(ctx, test.request); status.Code(err) != test.code {
+			t.Errorf("GetStates %v: %v, expected %v", test.request, err, test.code)
+		}
+		stream, err := client.StreamStates(ctx, test.request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := receiveStates(stream); status.Code(err) != test.code {
+			t.Errorf("StreamStates %v: %v, expected %v", test.request, err, test.code)
+		}
+	}
+	if _, err := client.GetOrientation(ctx, &ephemerispb.OrientationRequest{Frame: 31006, Grid: &ephemerispb.TimeGrid{Start: 2451540}}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetOrientation: %v", err)
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	server, connect := testServer(t)
+	server.ChunkSize = 10
+	client := connect()
+	grid := &ephemerispb.TimeGrid{Start: testStart, End: testEnd, Step: 1e-4}
+
+	// клиент, не читающий поток, не задерживает другие вызовы
+	stalled, cancelStalled := context.WithCancel(context.Background())
+	defer cancelStalled()
+	if _, err := client.StreamStates(stalled, &ephemerispb.StateRequest{Object: 10, Grid: grid}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := connect().GetStates(ctx, &ephemerispb.StateRequest{Object: 10, Grid: &ephemerispb.TimeGrid{Start: 2451540}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// после отмены поток завершается с кодом Canceled
+	streamCtx, cancelStream := context.WithCancel(context.Background())
+	stream, err := client.StreamStates(streamCtx, &ephemerispb.StateRequest{Object: 10, Grid: grid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	cancelStream()
+	if _, _, err := receiveStates(stream); status.Code(err) != codes.Canceled {
+		t.Fatalf("error %v", err)
+	}
+	cancelStalled()
+	if _, err := connect().GetStates(ctx, &ephemerispb.StateRequest{Object: 10, Grid: &ephemerispb.TimeGrid{Start: 2451540}}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,140 @@
+package rightround
+
+import (
+	"fmt"
+	"math"
+)
+
+// Модели атмосферной рефракции.
+const (
+	RefractionCodeSaemundsson = 1 // формула Сэмундссона (по истинной высоте)
+	RefractionCodeBennett     = 2 // формула Беннетта (по видимой высоте), обращается итерациями
+)
+
+// Atmosphere параметры атмосферы в месте наблюдения для учёта рефракции.
+type Atmosphere struct {
+	Model       int     // модель рефракции
+	Pressure    float64 // давление, гПа
+	Temperature float64 // температура, градусы Цельсия
+}
+
+// StandardAtmosphere стандартные условия, для которых выведены формулы рефракции.
+var StandardAtmosphere = Atmosphere{Model: RefractionCodeSaemundsson, Pressure: 1010, Temperature: 10}
+
+// Ниже высоты minRefractionElevation (в градусах) формулы рефракции неприменимы: рефракция линейно
+// убывает до нуля на высоте zeroRefractionElevation, чтобы высота оставалась непрерывной.
+const (
+	minRefractionElevation  = -1.0
+	zeroRefractionElevation = -2.0
+)
+
+// Refraction возвращает рефракцию (в радианах) для истинной высоты elevation (в радианах).
+func (a Atmosphere) Refraction(elevation float64) (float64, error) {
+	const radiansInDegree = math.Pi / 180
+	h := elevation / radiansInDegree
+	if h <= zeroRefractionElevation {
+		return 0, nil
+	}
+	taper := 1.0
+	if h < minRefractionElevation {
+		taper = (h - zeroRefractionElevation) / (minRefractionElevation - zeroRefractionElevation)
+		h = minRefractionElevation
+	}
+	// поправка за давление и температуру
+	factor := a.Pressure / 1010 * 283 / (273 + a.Temperature)
+
+	var arcminutes float64
+	switch a.Model {
+	case RefractionCodeSaemundsson:
+		arcminutes = 1.02 / math.Tan((h+10.3/(h+5.11))*radiansInDegree)
+	case RefractionCodeBennett:
+		// видимая высота h0 находится из h0 - R(h0) = h
+		apparent := h
+		for i := 0; i < 10; i++ {
+			arcminutes = 1 / math.Tan((apparent+7.31/(apparent+4.4))*radiansInDegree)
+			apparent = h + arcminutes*factor/60
+		}
+	default:
+		return 0, fmt.Errorf("%w refraction model: %d", ErrUnknown, a.Model)
+	}
+	// у зенита формулы дают малые отрицательные значения
+	return math.Max(0, taper*arcminutes*factor/60*radiansInDegree), nil
+}
+
+// HorizontalCoords горизонтальные и местные экваториальные координаты объекта.
+type HorizontalCoords struct {
+	Azimuth     float64 // азимут, отсчитываемый от севера к востоку, радианы
+	Elevation   float64 // высота над горизонтом, радианы
+	HourAngle   float64 // часовой угол от -π до π (положительный к западу), радианы
+	Declination float64 // склонение относительно истинного экватора даты, радианы
+	Distance    float64 // расстояние в установленных единицах
+}
+
+// localBasis возвращает векторы направлений на восток, север и в зенит в земной системе координат.
+func (o Observer) localBasis() (Coords, Coords, Coords) {
+	sinLat, cosLat := math.Sincos(o.Latitude)
+	sinLon, cosLon := math.Sincos(o.Longitude)
+	east := Coords{X: -sinLon, Y: cosLon}
+	north := Coords{X: -sinLat * cosLon, Y: -sinLat * sinLon, Z: cosLat}
+	up := Coords{X: cosLat * cosLon, Y: cosLat * sinLon, Z: sinLat}
+	return east, north, up
+}
+
+// CalculateHorizontalCoords вычисляет азимут, высоту, часовой угол и склонение объекта для наблюдателя
+// по видимому положению. Если atmosphere не nil, высота, часовой угол и склонение исправляются за рефракцию.
+func (e *Ephemeris) CalculateHorizontalCoords(object int, observer Observer, date1, date2 float64, atmosphere *Atmosphere) (HorizontalCoords, error) {
+	apparent, err := e.CalculateApparentCoords(object, observer, date1, date2)
+	if err != nil {
+		return HorizontalCoords{}, err
+	}
+	transform, err := e.CalculateEarthTransform(date1, date2)
+	if err != nil {
+		return HorizontalCoords{}, err
+	}
+	return observer.horizontalCoords(transform.Rotation.Apply(apparent), atmosphere)
+}
+
+// horizontalCoords вычисляет горизонтальные координаты по положению объекта относительно наблюдателя
+// в земной системе координат.
+func (o Observer) horizontalCoords(coords Coords, atmosphere *Atmosphere) (HorizontalCoords, error) {
+	distance := coords.length()
+	east, north, up := o.localBasis()
+	e := (coords.X*east.X + coords.Y*east.Y + coords.Z*east.Z) / distance
+	n := (coords.X*north.X + coords.Y*north.Y + coords.Z*north.Z) / distance
+	u := (coords.X*up.X + coords.Y*up.Y + coords.Z*up.Z) / distance
+
+	azimuth := math.Atan2(e, n)
+	if azimuth < 0 {
+		azimuth += 2 * math.Pi
+	}
+	elevation := math.Asin(math.Max(-1, math.Min(1, u)))
+	if atmosphere != nil {
+		refraction, err := atmosphere.Refraction(elevation)
+		if err != nil {
+			return HorizontalCoords{}, err
+		}
+		elevation += refraction
+		// направление с исправленной высотой
+		sinEl, cosEl := math.Sincos(elevation)
+		sinAz, cosAz := math.Sincos(azimuth)
+		e, n, u = cosEl*sinAz, cosEl*cosAz, sinEl
+	}
+
+	// направление в земной системе координат, повёрнутой к меридиану наблюдателя
+	direction := Coords{
+		X: e*east.X + n*north.X + u*up.X,
+		Y: e*east.Y + n*north.Y + u*up.Y,
+		Z: e*east.Z + n*north.Z + u*up.Z,
+	}
+	sinLon, cosLon := math.Sincos(o.Longitude)
+	meridianX := direction.X*cosLon + direction.Y*sinLon
+	meridianY := -direction.X*sinLon + direction.Y*cosLon
+
+	return HorizontalCoords{
+		Azimuth:     azimuth,
+		Elevation:   elevation,
+		HourAngle:   math.Atan2(-meridianY, meridianX),
+		Declination: math.Asin(math.Max(-1, math.Min(1, direction.Z))),
+		Distance:    distance,
+	}, nil
+}
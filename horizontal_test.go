@@ -0,0 +1,38 @@
+package rightround
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRefraction(t *testing.T) {
+	const radiansInDegree = math.Pi / 180
+	for _, model := range []int{RefractionCodeSaemundsson, RefractionCodeBennett} {
+		atmosphere := StandardAtmosphere
+		atmosphere.Model = model
+		refraction := func(degrees float64) float64 {
+			value, err := atmosphere.Refraction(degrees * radiansInDegree)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return value / radiansInDegree * 60
+		}
+		// у горизонта около 34', на высоте 45 градусов около 1'
+		if r := refraction(0); r < 28 || r > 36 {
+			t.Fatalf("model %d: refraction at horizon %v'", model, r)
+		}
+		if r := refraction(45); math.Abs(r-1) > 0.05 {
+			t.Fatalf("model %d: refraction at 45 degrees %v'", model, r)
+		}
+		// ниже горизонта рефракция убывает до нуля непрерывно
+		if r := refraction(-18); r != 0 {
+			t.Fatalf("model %d: refraction at -18 degrees %v'", model, r)
+		}
+		if a, b := refraction(-1), refraction(-1-1e-9); math.Abs(a-b) > 1e-6 {
+			t.Fatalf("model %d: refraction is discontinuous at -1 degree: %v' %v'", model, a, b)
+		}
+		if r := refraction(-1.5); math.Abs(r-refraction(-1)/2) > 1e-9 {
+			t.Fatalf("model %d: refraction at -1.5 degrees %v'", model, r)
+		}
+	}
+}
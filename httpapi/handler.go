@@ -0,0 +1,375 @@
+// Package httpapi предоставляет HTTP/JSON интерфейс к загруженным эфемеридам.
+//
+// Методы:
+//
+//	POST /state     StateRequest     -> StateResponse
+//	POST /angles    AnglesRequest    -> AnglesResponse
+//	POST /timediff  TimeDiffRequest  -> TimeDiffResponse
+//	GET  /coverage?object=301[&basis=399] -> CoverageResponse
+//	POST /coverage  CoverageRequest  -> CoverageResponse
+//	POST /batch     BatchRequest     -> BatchResponse
+//
+// Даты задаются юлианскими датами в шкале TDB. При ошибке возвращается ErrorResponse
+// с кодом ошибки и соответствующим статусом HTTP.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dvoeglazyi/rightround"
+)
+
+const (
+	// defaultMaxDates ограничение количества дат в одном запросе по умолчанию.
+	defaultMaxDates = 100000
+	// maxBatchSize ограничение количества элементов пакетного запроса.
+	maxBatchSize = 1000
+	// maxRequestSize ограничение размера тела запроса в байтах.
+	maxRequestSize = 8 << 20
+)
+
+// Коды ошибок в ответах.
+const (
+	ErrorCodeInvalidRequest   = "invalid_request"
+	ErrorCodeUnsupported      = "unsupported"
+	ErrorCodeNotFound         = "not_found"
+	ErrorCodeMethodNotAllowed = "method_not_allowed"
+	ErrorCodeInternal         = "internal"
+)
+
+// errInvalidRequest ошибка проверки запроса.
+var errInvalidRequest = errors.New("invalid request")
+
+// errNotFound неизвестный путь запроса.
+var errNotFound = errors.New("unknown method")
+
+// Handler обработчик HTTP-запросов к эфемеридам.
+// Ephemeris не допускает одновременного использования, поэтому расчёты выполняются последовательно.
+type Handler struct {
+	// MaxDates ограничение количества дат в одном запросе (для пакетного запроса - суммарно по всем элементам).
+	MaxDates int
+
+	ephemeris *rightround.Ephemeris
+	mutex     sync.Mutex
+}
+
+// NewHandler создаёт обработчик для загруженных эфемерид.
+// После создания обработчика эфемериды не должны использоваться напрямую без синхронизации.
+func NewHandler(ephemeris *rightround.Ephemeris) *Handler {
+	return &Handler{MaxDates: defaultMaxDates, ephemeris: ephemeris}
+}
+
+// ServeHTTP обрабатывает запрос; путь сопоставляется по последнему элементу,
+// поэтому обработчик можно подключать с префиксом.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	if method == "coverage" && r.Method == http.MethodGet {
+		request, err := coverageQuery(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		h.respond(w, func() (interface{}, error) {
+			return h.coverage(request)
+		})
+		return
+	}
+
+	call, ok := h.methods(&dateBudget{remaining: h.MaxDates})[method]
+	if !ok && method != "batch" {
+		writeError(w, fmt.Errorf("%w %q", errNotFound, method))
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: Error{
+			Code:    ErrorCodeMethodNotAllowed,
+			Message: fmt.Sprintf("method %s is not allowed", r.Method),
+		}})
+		return
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+	if method == "batch" {
+		var request BatchRequest
+		if err := decoder.Decode(&request); err != nil {
+			writeError(w, fmt.Errorf("%w: %v", errInvalidRequest, err))
+			return
+		}
+		h.respond(w, func() (interface{}, error) {
+			return h.batch(request)
+		})
+		return
+	}
+	var params json.RawMessage
+	if err := decoder.Decode(&params); err != nil {
+		writeError(w, fmt.Errorf("%w: %v", errInvalidRequest, err))
+		return
+	}
+	h.respond(w, func() (interface{}, error) {
+		return call(params)
+	})
+}
+
+// respond выполняет расчёт под блокировкой и записывает результат или ошибку.
+func (h *Handler) respond(w http.ResponseWriter, calculate func() (interface{}, error)) {
+	h.mutex.Lock()
+	result, err := calculate()
+	h.mutex.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// dateBudget количество дат, которое ещё можно рассчитать в запросе.
+type dateBudget struct {
+	remaining int
+}
+
+// take резервирует count дат.
+func (b *dateBudget) take(count int) error {
+	if count > b.remaining {
+		return fmt.Errorf("%w: too many dates (%d > %d)", errInvalidRequest, count, b.remaining)
+	}
+	b.remaining -= count
+	return nil
+}
+
+// methods возвращает методы, принимающие тело запроса в формате JSON;
+// количество дат всех вызовов ограничено общим budget.
+func (h *Handler) methods(budget *dateBudget) map[string]func(params json.RawMessage) (interface{}, error) {
+	return map[string]func(params json.RawMessage) (interface{}, error){
+		"state": func(params json.RawMessage) (interface{}, error) {
+			var request StateRequest
+			if err := decode(params, &request); err != nil {
+				return nil, err
+			}
+			return h.state(request, budget)
+		},
+		"angles": func(params json.RawMessage) (interface{}, error) {
+			var request AnglesRequest
+			if err := decode(params, &request); err != nil {
+				return nil, err
+			}
+			return h.angles(request, budget)
+		},
+		"timediff": func(params json.RawMessage) (interface{}, error) {
+			var request TimeDiffRequest
+			if err := decode(params, &request); err != nil {
+				return nil, err
+			}
+			return h.timeDiff(request, budget)
+		},
+		"coverage": func(params json.RawMessage) (interface{}, error) {
+			var request CoverageRequest
+			if err := decode(params, &request); err != nil {
+				return nil, err
+			}
+			return h.coverage(request)
+		},
+	}
+}
+
+// decode разбирает параметры запроса, не допуская неизвестных полей.
+func decode(params json.RawMessage, request interface{}) error {
+	if len(params) == 0 {
+		return fmt.Errorf("%w: params are required", errInvalidRequest)
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(params)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(request); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidRequest, err)
+	}
+	return nil
+}
+
+// timeGrid проверяет набор дат запроса, резервирует их в budget и возвращает соответствующую сетку.
+func (h *Handler) timeGrid(grid Grid, budget *dateBudget) (rightround.TimeGrid, error) {
+	if len(grid.Dates) > 0 {
+		if grid.Start != nil || grid.End != nil || grid.Step != nil {
+			return rightround.TimeGrid{}, fmt.Errorf("%w: dates and start/end/step are mutually exclusive", errInvalidRequest)
+		}
+		if err := budget.take(len(grid.Dates)); err != nil {
+			return rightround.TimeGrid{}, err
+		}
+		return rightround.TimeGrid{Dates: grid.Dates}, nil
+	}
+	if grid.Start == nil {
+		return rightround.TimeGrid{}, fmt.Errorf("%w: dates or start are required", errInvalidRequest)
+	}
+	if grid.End == nil && grid.Step != nil {
+		return rightround.TimeGrid{}, fmt.Errorf("%w: end is required with step", errInvalidRequest)
+	}
+	result := rightround.TimeGrid{Start: *grid.Start, End: *grid.Start, Step: 1}
+	if grid.End != nil {
+		if grid.Step == nil {
+			return rightround.TimeGrid{}, fmt.Errorf("%w: step is required with end", errInvalidRequest)
+		}
+		result.End, result.Step = *grid.End, *grid.Step
+	}
+	count, err := result.Len()
+	if err != nil {
+		return rightround.TimeGrid{}, err
+	}
+	if err := budget.take(count); err != nil {
+		return rightround.TimeGrid{}, err
+	}
+	return result, nil
+}
+
+func (h *Handler) state(request StateRequest, budget *dateBudget) (StateResponse, error) {
+	if request.Object == nil {
+		return StateResponse{}, fmt.Errorf("%w: object is required", errInvalidRequest)
+	}
+	grid, err := h.timeGrid(request.Grid, budget)
+	if err != nil {
+		return StateResponse{}, err
+	}
+	response := StateResponse{Object: *request.Object, Basis: request.Basis, States: []State{}}
+	err = h.ephemeris.EvaluateStates(*request.Object, request.Basis, grid, func(sample rightround.StateSample) error {
+		response.States = append(response.States, State{
+			Date:     sample.Date,
+			Position: [3]float64{sample.Coords.X, sample.Coords.Y, sample.Coords.Z},
+			Velocity: [3]float64{sample.Velocity.X, sample.Velocity.Y, sample.Velocity.Z},
+		})
+		return nil
+	})
+	return response, err
+}
+
+func (h *Handler) angles(request AnglesRequest, budget *dateBudget) (AnglesResponse, error) {
+	if request.Frame == nil {
+		return AnglesResponse{}, fmt.Errorf("%w: frame is required", errInvalidRequest)
+	}
+	grid, err := h.timeGrid(request.Grid, budget)
+	if err != nil {
+		return AnglesResponse{}, err
+	}
+	response := AnglesResponse{Frame: *request.Frame, Angles: []Angles{}}
+	err = h.ephemeris.EvaluateEulerAngles(*request.Frame, grid, func(sample rightround.AnglesSample) error {
+		response.Angles = append(response.Angles, Angles{
+			Date:   sample.Date,
+			Angles: [3]float64{sample.Angles.X, sample.Angles.Y, sample.Angles.Z},
+			Rates:  [3]float64{sample.Rates.X, sample.Rates.Y, sample.Rates.Z},
+		})
+		return nil
+	})
+	return response, err
+}
+
+func (h *Handler) timeDiff(request TimeDiffRequest, budget *dateBudget) (TimeDiffResponse, error) {
+	code := rightround.EphemerisCodeMinusTDB
+	if request.Code != nil {
+		code = *request.Code
+	}
+	grid, err := h.timeGrid(request.Grid, budget)
+	if err != nil {
+		return TimeDiffResponse{}, err
+	}
+	response := TimeDiffResponse{Code: code, Diffs: []TimeDiff{}}
+	err = h.ephemeris.EvaluateTimeDiff(code, grid, func(sample rightround.TimeDiffSample) error {
+		response.Diffs = append(response.Diffs, TimeDiff{Date: sample.Date, Diff: sample.Diff})
+		return nil
+	})
+	return response, err
+}
+
+func (h *Handler) coverage(request CoverageRequest) (CoverageResponse, error) {
+	if request.Object == nil {
+		return CoverageResponse{}, fmt.Errorf("%w: object is required", errInvalidRequest)
+	}
+	window := h.ephemeris.Coverage(*request.Object)
+	if request.Basis != nil {
+		window = h.ephemeris.CoverageRelative(*request.Object, *request.Basis)
+	}
+	response := CoverageResponse{Object: *request.Object, Intervals: []Interval{}}
+	for _, interval := range window {
+		response.Intervals = append(response.Intervals, Interval{Start: interval.Start, End: interval.End})
+	}
+	return response, nil
+}
+
+// coverageQuery разбирает параметры запроса покрытия, заданные в строке запроса.
+func coverageQuery(r *http.Request) (CoverageRequest, error) {
+	var request CoverageRequest
+	query := r.URL.Query()
+	for name, target := range map[string]**int{"object": &request.Object, "basis": &request.Basis} {
+		text := query.Get(name)
+		if text == "" {
+			continue
+		}
+		value, err := strconv.Atoi(text)
+		if err != nil {
+			return CoverageRequest{}, fmt.Errorf("%w: bad %s %q", errInvalidRequest, name, text)
+		}
+		*target = &value
+	}
+	return request, nil
+}
+
+// batch выполняет элементы пакетного запроса; ошибки элементов возвращаются в их результатах.
+// Ограничение MaxDates действует на все элементы вместе: элементы, превышающие остаток, не выполняются.
+func (h *Handler) batch(request BatchRequest) (BatchResponse, error) {
+	if len(request.Requests) > maxBatchSize {
+		return BatchResponse{}, fmt.Errorf("%w: too many requests (%d > %d)", errInvalidRequest, len(request.Requests), maxBatchSize)
+	}
+	methods := h.methods(&dateBudget{remaining: h.MaxDates})
+	response := BatchResponse{Responses: make([]BatchResult, len(request.Requests))}
+	for i, item := range request.Requests {
+		call, ok := methods[item.Method]
+		if !ok {
+			_, e := errorStatus(fmt.Errorf("%w %q", errNotFound, item.Method))
+			response.Responses[i].Error = &e
+			continue
+		}
+		result, err := call(item.Params)
+		if err != nil {
+			_, e := errorStatus(err)
+			response.Responses[i].Error = &e
+			continue
+		}
+		response.Responses[i].Result = result
+	}
+	return response, nil
+}
+
+// errorStatus возвращает статус HTTP и описание ошибки по её типу.
+func errorStatus(err error) (int, Error) {
+	switch {
+	case errors.Is(err, errInvalidRequest), errors.Is(err, rightround.ErrInvalidGrid):
+		return http.StatusBadRequest, Error{Code: ErrorCodeInvalidRequest, Message: err.Error()}
+	case errors.Is(err, rightround.ErrUnknown), errors.Is(err, rightround.ErrUnsupported):
+		return http.StatusBadRequest, Error{Code: ErrorCodeUnsupported, Message: err.Error()}
+	case errors.Is(err, rightround.ErrNotFound), errors.Is(err, errNotFound):
+		return http.StatusNotFound, Error{Code: ErrorCodeNotFound, Message: err.Error()}
+	default:
+		return http.StatusInternalServerError, Error{Code: ErrorCodeInternal, Message: err.Error()}
+	}
+}
+
+// writeError записывает ответ с ошибкой.
+func writeError(w http.ResponseWriter, err error) {
+	status, e := errorStatus(err)
+	writeJSON(w, status, ErrorResponse{Error: e})
+}
+
+// writeJSON записывает ответ в формате JSON.
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		status = http.StatusInternalServerError
+		data, _ = json.Marshal(ErrorResponse{Error: Error{Code: ErrorCodeInternal, Message: err.Error()}})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(data, '\n'))
+}
@@ -0,0 +1,212 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dvoeglazyi/rightround"
+)
+
+// testStart и testEnd интервал дат тестовых эфемерид.
+const testStart, testEnd = 2451536.5, 2451600.5
+
+// testLinear возвращает функцию состояний, компоненты которых линейно зависят от времени:
+// значения value на дату testStart и скорости изменения rate в сутки.
+func testLinear(value, rate rightround.Coords) rightround.StateFunc {
+	return func(date1, date2 float64) (rightround.Coords, rightround.Coords, error) {
+		days := (date1 - testStart) + date2
+		coords := rightround.Coords{X: value.X + rate.X*days, Y: value.Y + rate.Y*days, Z: value.Z + rate.Z*days}
+		velocity := rightround.Coords{X: rate.X / 86400, Y: rate.Y / 86400, Z: rate.Z / 86400}
+		return coords, velocity, nil
+	}
+}
+
+// writeTestSPK записывает файл SPK с положением Солнца, углами системы главных осей Луны DE421 и TT-TDB,
+// линейно зависящими от времени на интервале [testStart, testEnd]. Углы записываются сегментом
+// с кодом системы координат: теория системы координат ищется по коду независимо от типа файла.
+func writeTestSPK(t *testing.T, path string) {
+	writer := rightround.NewSPKWriter(path, "httpapi test")
+	for _, segment := range []struct {
+		object, center int
+		states         rightround.StateFunc
+	}{
+		{rightround.EphemerisSun, rightround.EphemerisSunSystem, testLinear(rightround.Coords{X: 1000, Y: 2000, Z: 3000}, rightround.Coords{X: 10})},
+		{rightround.EphemerisMoonPrincipalAxesDE421, 1, testLinear(rightround.Coords{X: 0.1, Y: 0.2, Z: 0.3}, rightround.Coords{X: 0.01})},
+		{rightround.EphemerisCodeMinusTDB, 1000000000, testLinear(rightround.Coords{X: 0.001}, rightround.Coords{X: 0.0001})},
+	} {
+		spec := rightround.SegmentSpec{Object: segment.object, Center: segment.center, Representation: 2, Start: testStart, End: testEnd}
+		if err := writer.AddChebyshevSegment(spec, segment.states); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Save(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testEphemeris записывает тестовый файл SPK во временный каталог и загружает его.
+func testEphemeris(t *testing.T) (*rightround.Ephemeris, string) {
+	dir, err := ioutil.TempDir("", "httpapi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	path := filepath.Join(dir, "test.bsp")
+	writeTestSPK(t, path)
+	ephemeris := rightround.NewEphemeris()
+	if err := ephemeris.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return ephemeris, path
+}
+
+func testHandler(t *testing.T) *Handler {
+	ephemeris, _ := testEphemeris(t)
+	return NewHandler(ephemeris)
+}
+
+// serve выполняет запрос и разбирает ответ в result.
+func serve(t *testing.T, handler http.Handler, method, target, body string, result interface{}) int {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if content := recorder.Header().Get("Content-Type"); content != "application/json" {
+		t.Fatalf("%s %s: content type %q", method, target, content)
+	}
+	if result != nil {
+		if err := json.Unmarshal(recorder.Body.Bytes(), result); err != nil {
+			t.Fatalf("%s %s: %v", method, target, err)
+		}
+	}
+	return recorder.Code
+}
+
+func TestState(t *testing.T) {
+	var response StateResponse
+	status := serve(t, testHandler(t), http.MethodPost, "/v1/state", `{"object": 10, "basis": 0, "start": 2451540.5, "end": 2451541.5, "step": 0.5}`, &response)
+	if status != http.StatusOK || len(response.States) != 3 {
+		t.Fatalf("status %d, %d states", status, len(response.States))
+	}
+	// X = 1000 + 10 км в сутки через 4 суток от начала
+	if state := response.States[0]; math.Abs(state.Position[0]-1040) > 1e-9 || math.Abs(state.Velocity[0]-10/86400.0) > 1e-15 {
+		t.Fatalf("state %+v", state)
+	}
+}
+
+func TestAngles(t *testing.T) {
+	var response AnglesResponse
+	status := serve(t, testHandler(t), http.MethodPost, "/angles", `{"frame": 31006, "dates": [2451552.5]}`, &response)
+	if status != http.StatusOK || len(response.Angles) != 1 {
+		t.Fatalf("status %d, %d angles", status, len(response.Angles))
+	}
+	if angles := response.Angles[0].Angles; math.Abs(angles[0]-0.26) > 1e-12 || math.Abs(angles[2]-0.3) > 1e-12 {
+		t.Fatalf("angles %v", angles)
+	}
+}
+
+func TestTimeDiff(t *testing.T) {
+	var response TimeDiffResponse
+	status := serve(t, testHandler(t), http.MethodPost, "/timediff", `{"dates": [2451552.5, 2451600.5]}`, &response)
+	if status != http.StatusOK || response.Code != rightround.EphemerisCodeMinusTDB || len(response.Diffs) != 2 {
+		t.Fatalf("status %d, response %+v", status, response)
+	}
+	if diff := response.Diffs[1].Diff; math.Abs(diff-0.0074) > 1e-15 {
+		t.Fatalf("diff %v", diff)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	handler := testHandler(t)
+	for _, request := range []struct{ method, body string }{
+		{http.MethodGet, ""},
+		{http.MethodPost, `{"object": 10}`},
+	} {
+		var response CoverageResponse
+		status := serve(t, handler, request.method, "/coverage?object=10", request.body, &response)
+		if status != http.StatusOK || len(response.Intervals) != 1 ||
+			response.Intervals[0].Start != testStart || response.Intervals[0].End != testEnd {
+			t.Fatalf("%s: status %d, response %+v", request.method, status, response)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	handler := testHandler(t)
+	for _, test := range []struct {
+		method, target, body string
+		status               int
+		code                 string
+	}{
+		{http.MethodPost, "/state", `{"basis": 0, "start": 2451540.5}`, http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{http.MethodPost, "/state", `{"object": 10, "start": 2451540.5, "unknown": 1}`, http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{http.MethodPost, "/state", `{"object": 10`, http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{http.MethodPost, "/state", `{"object": 10, "start": 2451541.5, "end": 2451540.5, "step": 1}`, http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{http.MethodPost, "/state", `{"object": 10, "start": 2451540.5, "end": 2451541.5}`, http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{http.MethodPost, "/state", `{"object": 10, "start": 2451540.5, "step": 1}`, http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{http.MethodGet, "/coverage?object=sun", "", http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{http.MethodPost, "/state", `{"object": 499, "start": 2451540.5}`, http.StatusNotFound, ErrorCodeNotFound},
+		{http.MethodPost, "/angles", `{"frame": 31006, "dates": [2451700.5]}`, http.StatusNotFound, ErrorCodeNotFound},
+		{http.MethodPost, "/elements", `{}`, http.StatusNotFound, ErrorCodeNotFound},
+		{http.MethodGet, "/state", "", http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed},
+	} {
+		var response ErrorResponse
+		status := serve(t, handler, test.method, test.target, test.body, &response)
+		if status != test.status || response.Error.Code != test.code || response.Error.Message == "" {
+			t.Errorf("%s %s %s: status %d, error %+v", test.method, test.target, test.body, status, response.Error)
+		}
+	}
+}
+
+func TestInternalError(t *testing.T) {
+	ephemeris, path := testEphemeris(t)
+	// данные сегментов становятся недоступны после загрузки
+	if err := os.Truncate(path, 1024); err != nil {
+		t.Fatal(err)
+	}
+
+	var response ErrorResponse
+	status := serve(t, NewHandler(ephemeris), http.MethodPost, "/state", `{"object": 10, "start": 2451550}`, &response)
+	if status != http.StatusInternalServerError || response.Error.Code != ErrorCodeInternal {
+		t.Fatalf("status %d, error %+v", status, response.Error)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	handler := testHandler(t)
+	handler.MaxDates = 5
+	body := `{"requests": [
+		{"method": "state", "params": {"object": 10, "dates": [2451540.5, 2451541.5]}},
+		{"method": "state", "params": {"object": 499, "dates": [2451540.5]}},
+		{"method": "elements", "params": {}},
+		{"method": "timediff", "params": {"dates": [2451540.5, 2451541.5, 2451542.5]}},
+		{"method": "coverage", "params": {"object": 10}}
+	]}`
+	var response struct {
+		Responses []struct {
+			Result json.RawMessage `json:"result"`
+			Error  *Error          `json:"error"`
+		} `json:"responses"`
+	}
+	status := serve(t, handler, http.MethodPost, "/batch", body, &response)
+	if status != http.StatusOK || len(response.Responses) != 5 {
+		t.Fatalf("status %d, %d responses", status, len(response.Responses))
+	}
+	// третий элемент с датами превышает общее ограничение: 2 + 1 + 3 > 5
+	expected := []string{"", ErrorCodeNotFound, ErrorCodeNotFound, ErrorCodeInvalidRequest, ""}
+	for i, code := range expected {
+		result := response.Responses[i]
+		if code == "" && (result.Error != nil || len(result.Result) == 0) {
+			t.Errorf("item %d: unexpected error %+v", i, result.Error)
+		}
+		if code != "" && (result.Error == nil || result.Error.Code != code) {
+			t.Errorf("item %d: error %+v, expected %s", i, result.Error, code)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package httpapi
+
+import "encoding/json"
+
+// Grid набор дат TDB (юлианских дат): либо список Dates, либо равномерная сетка
+// от Start до End включительно с шагом Step в сутках. Если End не задан, используется одна дата Start.
+type Grid struct {
+	Dates []float64 `json:"dates,omitempty"`
+	Start *float64  `json:"start,omitempty"`
+	End   *float64  `json:"end,omitempty"`
+	Step  *float64  `json:"step,omitempty"`
+}
+
+// StateRequest запрос положений и скоростей объекта относительно центра.
+type StateRequest struct {
+	Object *int `json:"object"`
+	Basis  int  `json:"basis"`
+	Grid
+}
+
+// State положение и скорость на дату.
+type State struct {
+	Date     float64    `json:"date"`
+	Position [3]float64 `json:"position"`
+	Velocity [3]float64 `json:"velocity"`
+}
+
+// StateResponse положения и скорости в единицах, установленных для эфемерид (по умолчанию км и км/с).
+type StateResponse struct {
+	Object int     `json:"object"`
+	Basis  int     `json:"basis"`
+	States []State `json:"states"`
+}
+
+// AnglesRequest запрос эйлеровых углов системы координат PCK.
+type AnglesRequest struct {
+	Frame *int `json:"frame"`
+	Grid
+}
+
+// Angles эйлеровы углы (в радианах) и скорости их изменения на дату.
+type Angles struct {
+	Date   float64    `json:"date"`
+	Angles [3]float64 `json:"angles"`
+	Rates  [3]float64 `json:"rates"`
+}
+
+// AnglesResponse эйлеровы углы на датах запроса.
+type AnglesResponse struct {
+	Frame  int      `json:"frame"`
+	Angles []Angles `json:"angles"`
+}
+
+// TimeDiffRequest запрос разности шкал времени; по умолчанию TT - TDB.
+type TimeDiffRequest struct {
+	Code *int `json:"code,omitempty"`
+	Grid
+}
+
+// TimeDiff разность шкал времени на дату.
+type TimeDiff struct {
+	Date float64 `json:"date"`
+	Diff float64 `json:"diff"`
+}
+
+// TimeDiffResponse разности шкал времени в единицах времени, установленных для эфемерид (по умолчанию секунды).
+type TimeDiffResponse struct {
+	Code  int        `json:"code"`
+	Diffs []TimeDiff `json:"diffs"`
+}
+
+// CoverageRequest запрос интервалов дат, на которых определён объект (относительно центра Basis, если он задан).
+type CoverageRequest struct {
+	Object *int `json:"object"`
+	Basis  *int `json:"basis,omitempty"`
+}
+
+// Interval интервал дат TDB.
+type Interval struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// CoverageResponse интервалы дат, на которых определён объект.
+type CoverageResponse struct {
+	Object    int        `json:"object"`
+	Intervals []Interval `json:"intervals"`
+}
+
+// BatchRequest набор запросов, выполняемых за одно обращение.
+// Method - имя метода ("state", "angles", "timediff", "coverage"), Params - тело соответствующего запроса.
+type BatchRequest struct {
+	Requests []BatchItem `json:"requests"`
+}
+
+// BatchItem элемент пакетного запроса.
+type BatchItem struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// BatchResult результат элемента пакетного запроса: Result либо Error.
+type BatchResult struct {
+	Result interface{} `json:"result,omitempty"`
+	Error  *Error      `json:"error,omitempty"`
+}
+
+// BatchResponse результаты пакетного запроса в порядке запросов.
+type BatchResponse struct {
+	Responses []BatchResult `json:"responses"`
+}
+
+// Error описание ошибки.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse тело ответа с ошибкой.
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
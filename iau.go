@@ -0,0 +1,224 @@
+package rightround
+
+import (
+	"fmt"
+	"math"
+)
+
+// RotationalElements элементы вращения тела по модели рабочей группы IAU WGCCRE.
+// Представление совпадает с принятым в текстовых PCK NAIF.
+type RotationalElements struct {
+	PoleRA        []float64   // прямое восхождение полюса: градусы, градусы/столетие, градусы/столетие^2
+	PoleDec       []float64   // склонение полюса: градусы, градусы/столетие, градусы/столетие^2
+	PrimeMeridian []float64   // положение нулевого меридиана: градусы, градусы/сутки, градусы/сутки^2
+	NutPrecRA     []float64   // амплитуды (градусы) при синусах нутационно-прецессионных углов
+	NutPrecDec    []float64   // амплитуды (градусы) при косинусах нутационно-прецессионных углов
+	NutPrecPM     []float64   // амплитуды (градусы) при синусах нутационно-прецессионных углов
+	NutPrecAngles [][]float64 // нутационно-прецессионные углы: полиномы от времени в столетиях (градусы, градусы/столетие, ...)
+}
+
+// iauRotationalElements встроенные элементы вращения (отчёт IAU WGCCRE 2009, соответствует pck00010.tpc).
+var iauRotationalElements = map[int]*RotationalElements{
+	EphemerisSun: {
+		PoleRA:        []float64{286.13},
+		PoleDec:       []float64{63.87},
+		PrimeMeridian: []float64{84.176, 14.1844000},
+	},
+	EphemerisMercuryBody: {
+		PoleRA:        []float64{281.0097, -0.0328},
+		PoleDec:       []float64{61.4143, -0.0049},
+		PrimeMeridian: []float64{329.5469, 6.1385025},
+		NutPrecPM:     []float64{0.00993822, -0.00104581, -0.00010280, -0.00002364, -0.00000532},
+		NutPrecAngles: [][]float64{
+			{174.791086, 4.092335 * daysInCentury},
+			{349.582171, 8.184670 * daysInCentury},
+			{164.373257, 12.277005 * daysInCentury},
+			{339.164343, 16.369340 * daysInCentury},
+			{153.955429, 20.461675 * daysInCentury},
+		},
+	},
+	EphemerisVenusBody: {
+		PoleRA:        []float64{272.76},
+		PoleDec:       []float64{67.16},
+		PrimeMeridian: []float64{160.20, -1.4813688},
+	},
+	EphemerisEarth: {
+		PoleRA:        []float64{0, -0.641},
+		PoleDec:       []float64{90, -0.557},
+		PrimeMeridian: []float64{190.147, 360.9856235},
+	},
+	EphemerisMoon: {
+		PoleRA:        []float64{269.9949, 0.0031},
+		PoleDec:       []float64{66.5392, 0.0130},
+		PrimeMeridian: []float64{38.3213, 13.17635815, -1.4e-12},
+		NutPrecRA:     []float64{-3.8787, -0.1204, 0.0700, -0.0172, 0, 0.0072, 0, 0, 0, -0.0052, 0, 0, 0.0043},
+		NutPrecDec:    []float64{1.5419, 0.0239, -0.0278, 0.0068, 0, -0.0029, 0.0009, 0, 0, 0.0008, 0, 0, -0.0009},
+		NutPrecPM:     []float64{3.5610, 0.1208, -0.0642, 0.0158, 0.0252, -0.0066, -0.0047, -0.0046, 0.0028, 0.0052, 0.0040, 0.0019, -0.0044},
+		NutPrecAngles: [][]float64{
+			{125.045, -0.0529921 * daysInCentury},
+			{250.089, -0.1059842 * daysInCentury},
+			{260.008, 13.0120009 * daysInCentury},
+			{176.625, 13.3407154 * daysInCentury},
+			{357.529, 0.9856003 * daysInCentury},
+			{311.589, 26.4057084 * daysInCentury},
+			{134.963, 13.0649930 * daysInCentury},
+			{276.617, 0.3287146 * daysInCentury},
+			{34.226, 1.7484877 * daysInCentury},
+			{15.134, -0.1589763 * daysInCentury},
+			{119.743, 0.0036096 * daysInCentury},
+			{239.961, 0.1643573 * daysInCentury},
+			{25.053, 12.9590088 * daysInCentury},
+		},
+	},
+	EphemerisMarsBody: {
+		PoleRA:        []float64{317.68143, -0.1061},
+		PoleDec:       []float64{52.88650, -0.0609},
+		PrimeMeridian: []float64{176.630, 350.89198226},
+	},
+	EphemerisJupiterBody: {
+		PoleRA:        []float64{268.056595, -0.006499},
+		PoleDec:       []float64{64.495303, 0.002413},
+		PrimeMeridian: []float64{284.95, 870.5360000},
+		NutPrecRA:     []float64{0.000117, 0.000938, 0.001432, 0.000030, 0.002150},
+		NutPrecDec:    []float64{0.000050, 0.000404, 0.000617, -0.000013, 0.000926},
+		NutPrecAngles: [][]float64{
+			{99.360714, 4850.4046},
+			{175.895369, 1191.9605},
+			{300.323162, 262.5475},
+			{114.012305, 6070.2476},
+			{49.511251, 64.3000},
+		},
+	},
+	EphemerisSaturnBody: {
+		PoleRA:        []float64{40.589, -0.036},
+		PoleDec:       []float64{83.537, -0.004},
+		PrimeMeridian: []float64{38.90, 810.7939024},
+	},
+	EphemerisUranusBody: {
+		PoleRA:        []float64{257.311},
+		PoleDec:       []float64{-15.175},
+		PrimeMeridian: []float64{203.81, -501.1600928},
+	},
+	EphemerisNeptuneBody: {
+		PoleRA:        []float64{299.36},
+		PoleDec:       []float64{43.46},
+		PrimeMeridian: []float64{249.978, 541.1397757},
+		NutPrecRA:     []float64{0.70},
+		NutPrecDec:    []float64{-0.51},
+		NutPrecPM:     []float64{-0.48},
+		NutPrecAngles: [][]float64{{357.85, 52.316}},
+	},
+	EphemerisPlutoBody: {
+		PoleRA:        []float64{132.993},
+		PoleDec:       []float64{-6.163},
+		PrimeMeridian: []float64{302.695, 56.3625225},
+	},
+}
+
+// bodyCode возвращает код центра тела для кода барицентра планетной системы.
+func bodyCode(object int) int {
+	if object >= EphemerisMercury && object <= EphemerisPluto {
+		return object*100 + 99
+	}
+	return object
+}
+
+// calcPolynomial вычисляет значение полинома и его производной.
+func calcPolynomial(coefficients []float64, x float64) (float64, float64) {
+	value, derivative := 0.0, 0.0
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		derivative = derivative*x + value
+		value = value*x + coefficients[i]
+	}
+	return value, derivative
+}
+
+// Angles вычисляет прямое восхождение и склонение полюса, положение нулевого меридиана (в градусах)
+// и скорости их изменения (в градусах в сутки) на заданную дату (TDB).
+func (r *RotationalElements) Angles(date1, date2 float64) (Coords, Coords) {
+	days := (date1 - julianDate2000) + date2
+	centuries := days / daysInCentury
+
+	var angles, rates Coords
+	var centuryRate float64
+	angles.X, centuryRate = calcPolynomial(r.PoleRA, centuries)
+	rates.X = centuryRate / daysInCentury
+	angles.Y, centuryRate = calcPolynomial(r.PoleDec, centuries)
+	rates.Y = centuryRate / daysInCentury
+	angles.Z, rates.Z = calcPolynomial(r.PrimeMeridian, days)
+
+	for i, angle := range r.NutPrecAngles {
+		value, rate := calcPolynomial(angle, centuries)
+		rate *= math.Pi / 180 / daysInCentury
+		sin, cos := math.Sincos(value * math.Pi / 180)
+		if i < len(r.NutPrecRA) {
+			angles.X += r.NutPrecRA[i] * sin
+			rates.X += r.NutPrecRA[i] * cos * rate
+		}
+		if i < len(r.NutPrecDec) {
+			angles.Y += r.NutPrecDec[i] * cos
+			rates.Y -= r.NutPrecDec[i] * sin * rate
+		}
+		if i < len(r.NutPrecPM) {
+			angles.Z += r.NutPrecPM[i] * sin
+			rates.Z += r.NutPrecPM[i] * cos * rate
+		}
+	}
+	return angles, rates
+}
+
+// Transform вычисляет преобразование состояния из ICRF в связанную с телом систему координат IAU
+// на заданную дату (TDB). Производная матрицы поворота выражается в сутках.
+func (r *RotationalElements) Transform(date1, date2 float64) Transform {
+	const radiansInDegree = math.Pi / 180
+	angles, rates := r.Angles(date1, date2)
+	return eulerTransform(
+		Coords{X: (angles.X + 90) * radiansInDegree, Y: (90 - angles.Y) * radiansInDegree, Z: angles.Z * radiansInDegree},
+		Coords{X: rates.X * radiansInDegree, Y: -rates.Y * radiansInDegree, Z: rates.Z * radiansInDegree},
+	)
+}
+
+// RotationalElements возвращает элементы вращения тела: из загруженных текстовых PCK, а при их отсутствии - встроенные.
+func (e *Ephemeris) RotationalElements(body int) (*RotationalElements, error) {
+	elements, err := e.pool.rotationalElements(bodyCode(body))
+	if err != nil {
+		return nil, err
+	}
+	if elements != nil {
+		return elements, nil
+	}
+	if elements, ok := iauRotationalElements[bodyCode(body)]; ok {
+		return elements, nil
+	}
+	return nil, fmt.Errorf("rotational elements for body %d %w", body, ErrNotFound)
+}
+
+// CalculateIAUTransform вычисляет преобразование состояния из ICRF в связанную с телом систему координат IAU.
+// Производная матрицы поворота выражается в установленных единицах времени.
+func (e *Ephemeris) CalculateIAUTransform(body int, date1, date2 float64) (Transform, error) {
+	elements, err := e.RotationalElements(body)
+	if err != nil {
+		return Transform{}, err
+	}
+	transform := elements.Transform(date1, date2)
+	transform.Derivative = transform.Derivative.Scale(1 / e.timeScalingFactor)
+	return transform, nil
+}
+
+// CalculateBodyFixedCoords вычисляет координаты и скорость объекта относительно тела
+// в связанной с телом системе координат IAU.
+func (e *Ephemeris) CalculateBodyFixedCoords(object, body int, date1, date2 float64, withVelocity bool) (Coords, Coords, error) {
+	transform, err := e.CalculateIAUTransform(body, date1, date2)
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+	coords, velocity, err := e.CalculateRectangularCoordsAndScaleVelocity(object, body, date1, date2, withVelocity)
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+	if !withVelocity {
+		return transform.Rotation.Apply(coords), Coords{}, nil
+	}
+	coords, velocity = transform.Apply(coords, velocity)
+	return coords, velocity, nil
+}
@@ -0,0 +1,121 @@
+package rightround
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// testMarsPCK фрагмент pck00011.tpc для Марса: углы заданы полиномами второй степени.
+const testMarsPCK = `
+\begindata
+
+BODY499_POLE_RA          = (  317.269202  -0.10927547        0.  )
+BODY499_POLE_DEC         = (   54.432516  -0.05827105        0.  )
+BODY499_PM               = (  176.049863  +350.891982443297  0.  )
+
+BODY499_NUT_PREC_RA      = (  0     0     0     0     0
+                              0     0     0     0     0
+                              0.000068
+                              0.000238
+                              0.000052
+                              0.000009
+                              0.419057                  )
+
+BODY499_NUT_PREC_DEC     = (  0     0     0     0     0
+                              0     0     0     0     0
+                              0     0     0     0     0
+                              0.000051
+                              0.000141
+                              0.000031
+                              0.000005
+                              1.591274                  )
+
+BODY499_NUT_PREC_PM      = (  0     0     0     0     0
+                              0     0     0     0     0
+                              0     0     0     0     0
+                              0     0     0     0     0
+                              0.000145
+                              0.000157
+                              0.000040
+                              0.000001
+                              0.000001
+                              0.584542                  )
+
+BODY4_NUT_PREC_ANGLES  = (
+     190.72646643      15917.10818695   0
+      21.46892470      31834.27934054   0
+     332.86082793      19139.89694742   0
+     394.93256437      38280.79631835   0
+     189.63271560   41215158.18420050  12.711923222
+     121.46893664        660.22803474   0
+     231.05028581        660.99123540   0
+     251.37314025       1320.50145245   0
+     217.98635955      38279.96125550   0
+     196.19729402      19139.83628608   0
+     198.991226        19139.4819985    0
+     226.292679        38280.8511281    0
+     249.663391        57420.7251593    0
+     266.183510        76560.6367950    0
+      79.398797            0.5042615    0
+     122.433576        19139.9407476    0
+      43.058401        38280.8753272    0
+      57.663379        57420.7517205    0
+      79.476401        76560.6495004    0
+     166.325722            0.5042615    0
+     129.071773        19140.0328244    0
+      36.352167        38281.0473591    0
+      56.668646        57420.9295360    0
+      67.364003        76560.2552215    0
+     104.792680        95700.4387578    0
+      95.391654            0.5042615    0 )
+
+BODY4_MAX_PHASE_DEGREE = 2
+
+\begintext
+`
+
+func TestRotationalElementsPhaseDegree(t *testing.T) {
+	ephemeris := NewEphemeris()
+	if err := ephemeris.pool.Load(strings.NewReader(testMarsPCK)); err != nil {
+		t.Fatal(err)
+	}
+	elements, err := ephemeris.RotationalElements(EphemerisMarsBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elements.NutPrecAngles) != 26 || elements.NutPrecAngles[4][2] != 12.711923222 || elements.NutPrecAngles[25][0] != 95.391654 {
+		t.Fatalf("angles %v", elements.NutPrecAngles)
+	}
+
+	const centuries = 0.1
+	degrees := func(x float64) float64 { return x * math.Pi / 180 }
+	// в NUT_PREC_RA, NUT_PREC_DEC и NUT_PREC_PM отличны от нуля последние члены
+	ra := 317.269202 - 0.10927547*centuries
+	for i, amplitude := range []float64{0.000068, 0.000238, 0.000052, 0.000009, 0.419057} {
+		angle := elements.NutPrecAngles[10+i]
+		ra += amplitude * math.Sin(degrees(angle[0]+angle[1]*centuries))
+	}
+	pm := 176.049863 + 350.891982443297*3652.5
+	for i, amplitude := range []float64{0.000145, 0.000157, 0.000040, 0.000001, 0.000001, 0.584542} {
+		angle := elements.NutPrecAngles[20+i]
+		pm += amplitude * math.Sin(degrees(angle[0]+angle[1]*centuries))
+	}
+	angles, rates := elements.Angles(julianDate2000, 3652.5)
+	if math.Abs(angles.X-ra) > 1e-9 || math.Abs(angles.Z-pm) > 1e-6 {
+		t.Fatalf("angles %+v, expected RA %v, PM %v", angles, ra, pm)
+	}
+
+	// скорость с учётом квадратичного члена угла сравнивается с разностной производной
+	const step = 1e-3
+	before, _ := elements.Angles(julianDate2000, 3652.5-step)
+	after, _ := elements.Angles(julianDate2000, 3652.5+step)
+	if math.Abs(rates.X-(after.X-before.X)/(2*step)) > 1e-9 || math.Abs(rates.Z-(after.Z-before.Z)/(2*step)) > 1e-6 {
+		t.Fatalf("rates %+v", rates)
+	}
+
+	ephemeris.pool.SetFloats("BODY4_MAX_PHASE_DEGREE", []float64{3})
+	if _, err := ephemeris.RotationalElements(EphemerisMarsBody); err == nil {
+		t.Fatal("misaligned angles are accepted")
+	}
+}
@@ -0,0 +1,125 @@
+package rightround
+
+import "sort"
+
+// interpolationWindow возвращает номер первого состояния и количество состояний окна интерполяции
+// для момента seconds (секунды от J2000). Как и в SPICE, окно выбирается так, чтобы момент
+// находился в его середине, а у границ сегмента окно сдвигается внутрь.
+func (t *Theory) interpolationWindow(seconds float64) (int, int) {
+	size := t.polynomialDegree + 1
+	if t.representation == representationHermite {
+		size = (t.polynomialDegree + 1) / 2
+	}
+	n := len(t.epochs)
+	if size > n {
+		size = n
+	}
+	// количество моментов, не превышающих seconds
+	i := sort.Search(n, func(k int) bool {
+		return t.epochs[k] > seconds
+	})
+	first := i - (size+1)/2
+	if first < 0 {
+		first = 0
+	}
+	if first > n-size {
+		first = n - size
+	}
+	return first, size
+}
+
+// lagrange вычисляет значение интерполяционного полинома Лагранжа по узлам x и значениям y в точке at.
+func lagrange(x, y []float64, at float64) float64 {
+	result := 0.0
+	for i := range x {
+		term := y[i]
+		for j := range x {
+			if j != i {
+				term *= (at - x[j]) / (x[i] - x[j])
+			}
+		}
+		result += term
+	}
+	return result
+}
+
+// hermite вычисляет значение интерполяционного полинома Эрмита и его производную в точке at
+// по узлам x, значениям y и производным dy (метод разделённых разностей с кратными узлами).
+func hermite(x, y, dy []float64, at float64) (float64, float64) {
+	m := 2 * len(x)
+	z := make([]float64, m)
+	q := make([]float64, m)
+	for i := range x {
+		z[2*i], z[2*i+1] = x[i], x[i]
+		q[2*i], q[2*i+1] = y[i], y[i]
+	}
+	// coefficients[k] - разделённая разность по первым k+1 узлам
+	coefficients := make([]float64, m)
+	coefficients[0] = q[0]
+	for order := 1; order < m; order++ {
+		for i := m - 1; i >= order; i-- {
+			if order == 1 && i%2 == 1 {
+				q[i] = dy[i/2]
+			} else {
+				q[i] = (q[i] - q[i-1]) / (z[i] - z[i-order])
+			}
+		}
+		coefficients[order] = q[order]
+	}
+	value, derivative := coefficients[m-1], 0.0
+	for k := m - 2; k >= 0; k-- {
+		derivative = derivative*(at-z[k]) + value
+		value = value*(at-z[k]) + coefficients[k]
+	}
+	return value, derivative
+}
+
+// calculateByInterpolation вычисляет положение и скорость (в сутках) по дискретным состояниям
+// интерполяцией Лагранжа (тип 9: положения и скорости интерполируются независимо)
+// или Эрмита (тип 13: скорость - производная полинома положения).
+func (e *Ephemeris) calculateByInterpolation(theory *Theory, date1, date2 float64, scaleDistance, withVelocity bool) (Coords, Coords, error) {
+	seconds := ((date1 - julianDate2000) + date2) * secondsInDay
+	first, size := theory.interpolationWindow(seconds)
+	states, err := theory.readStates(first, size)
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+
+	// узлы отсчитываются от первого момента окна для устойчивости вычислений
+	origin := theory.epochs[first]
+	x := make([]float64, size)
+	for i := range x {
+		x[i] = theory.epochs[first+i] - origin
+	}
+	at := seconds - origin
+	component := func(k int) []float64 {
+		values := make([]float64, size)
+		for i := range values {
+			values[i] = states[6*i+k]
+		}
+		return values
+	}
+
+	var position, velocity [3]float64
+	for k := 0; k < 3; k++ {
+		if theory.representation == representationHermite {
+			position[k], velocity[k] = hermite(x, component(k), component(k+3), at)
+		} else {
+			position[k] = lagrange(x, component(k), at)
+			if withVelocity {
+				velocity[k] = lagrange(x, component(k+3), at)
+			}
+		}
+	}
+
+	distanceScale := 1.0
+	if scaleDistance {
+		distanceScale = e.distanceScalingFactor
+	}
+	coords := Coords{X: position[0], Y: position[1], Z: position[2]}.scale(distanceScale)
+	if !withVelocity {
+		return coords, Coords{}, nil
+	}
+	// скорость в сутках, как и для остальных представлений
+	return coords, Coords{X: velocity[0], Y: velocity[1], Z: velocity[2]}.scale(distanceScale * secondsInDay), nil
+}
@@ -0,0 +1,41 @@
+package rightround
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLagrange(t *testing.T) {
+	// полином степени 3 восстанавливается по четырём узлам точно
+	polynomial := func(x float64) float64 { return 2 - 3*x + 0.5*x*x + 0.25*x*x*x }
+	x := []float64{-1, 0.5, 2, 4}
+	y := make([]float64, len(x))
+	for i := range x {
+		y[i] = polynomial(x[i])
+	}
+	for _, at := range []float64{-1, -0.3, 1.7, 3.2, 5} {
+		if value := lagrange(x, y, at); math.Abs(value-polynomial(at)) > 1e-12 {
+			t.Fatalf("at %v: %v, expected %v", at, value, polynomial(at))
+		}
+	}
+}
+
+func TestHermite(t *testing.T) {
+	// полином степени 5 восстанавливается по значениям и производным в трёх узлах точно
+	polynomial := func(x float64) (float64, float64) {
+		return 1 + x - 2*x*x + 0.5*x*x*x*x - 0.1*x*x*x*x*x, 1 - 4*x + 2*x*x*x - 0.5*x*x*x*x
+	}
+	x := []float64{-1, 0.5, 2}
+	y := make([]float64, len(x))
+	dy := make([]float64, len(x))
+	for i := range x {
+		y[i], dy[i] = polynomial(x[i])
+	}
+	for _, at := range []float64{-1, -0.4, 0.5, 1.3, 2.5} {
+		value, derivative := hermite(x, y, dy, at)
+		expected, expectedDerivative := polynomial(at)
+		if math.Abs(value-expected) > 1e-12 || math.Abs(derivative-expectedDerivative) > 1e-12 {
+			t.Fatalf("at %v: %v %v, expected %v %v", at, value, derivative, expected, expectedDerivative)
+		}
+	}
+}
@@ -0,0 +1,588 @@
+package rightround
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// jplItemsNumber количество величин в таблице указателей эфемерид JPL
+	// (11 тел, нутации, либрации, угловая скорость мантии Луны, TT-TDB)
+	jplItemsNumber    = 15
+	jplItemMoon       = 9
+	jplItemLibrations = 12
+	jplItemTimeDiff   = 14
+	// jplHeaderSize размер фиксированной части первой записи бинарного файла (до дополнительных имён констант)
+	jplHeaderSize = 2856
+	// jplNamesNumber количество имён констант, помещающихся в основную часть заголовка
+	jplNamesNumber = 400
+)
+
+// jplComponents количество компонент каждой величины таблицы указателей.
+var jplComponents = [jplItemsNumber]int{3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 2, 3, 3, 1}
+
+// jplBodies величины эфемерид JPL, загружаемые как теории SPK: номер величины, объект и центр.
+// Луна задана относительно Земли, остальные тела - относительно барицентра Солнечной системы.
+var jplBodies = []struct {
+	item, object, basis int
+}{
+	{0, EphemerisMercury, EphemerisSunSystem},
+	{1, EphemerisVenus, EphemerisSunSystem},
+	{2, EphemerisEarthMoon, EphemerisSunSystem},
+	{3, EphemerisMars, EphemerisSunSystem},
+	{4, EphemerisJupiter, EphemerisSunSystem},
+	{5, EphemerisSaturn, EphemerisSunSystem},
+	{6, EphemerisUranus, EphemerisSunSystem},
+	{7, EphemerisNeptune, EphemerisSunSystem},
+	{8, EphemerisPluto, EphemerisSunSystem},
+	{jplItemMoon, EphemerisMoon, EphemerisEarth},
+	{10, EphemerisSun, EphemerisSunSystem},
+}
+
+// jplLibrationFrames системы координат главных осей Луны, в которых заданы либрации эфемерид DE.
+var jplLibrationFrames = map[int]int{
+	403: EphemerisMoonPrincipalAxesDE403,
+	405: EphemerisMoonPrincipalAxesDE403,
+	421: EphemerisMoonPrincipalAxesDE421,
+	430: EphemerisMoonPrincipalAxesDE430,
+	431: EphemerisMoonPrincipalAxesDE430,
+	440: EphemerisMoonPrincipalAxesDE440,
+	441: EphemerisMoonPrincipalAxesDE440,
+}
+
+// JPLHeader заголовок эфемерид JPL в собственном формате (DE).
+type JPLHeader struct {
+	Title     []string           // строки заголовка
+	Version   int                // номер эфемерид (DENUM)
+	Start     float64            // начало интервала (юлианская дата TDB)
+	End       float64            // конец интервала (юлианская дата TDB)
+	Step      float64            // длина записи в сутках
+	AU        float64            // астрономическая единица в км
+	EMRAT     float64            // отношение масс Земли и Луны
+	Constants map[string]float64 // константы заголовка по именам
+}
+
+// jplEphemeris загруженные эфемериды JPL: заголовок, таблица указателей и чтение записей.
+type jplEphemeris struct {
+	header JPLHeader
+	// смещение коэффициентов в записи (с единицы), количество коэффициентов и подынтервалов каждой величины
+	pointers [jplItemsNumber][3]int
+	records  int
+	// read возвращает коэффициенты записи с номером record (первые два числа - даты начала и конца)
+	read func(record int) ([]float64, error)
+}
+
+// LoadJPLBinary загружает эфемериды JPL в собственном бинарном формате (файлы lnxp/unxp, например lnxp1600p2200.405).
+// Порядок байтов определяется автоматически. Тела загружаются как теории SPK, либрации Луны - как теория PCK
+// в системе главных осей из jplLibrationFrames (для других версий DE либрации не загружаются),
+// разность TT-TDB (при наличии) - с кодом EphemerisCodeMinusTDB; гравитационные параметры тел
+// заголовка добавляются в переменные ядер (BODYn_GM).
+func (e *Ephemeris) LoadJPLBinary(path string) (*JPLHeader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	d, err := readJPLBinary(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := e.addJPLTheories(d); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &d.header, nil
+}
+
+// LoadJPLASCII загружает эфемериды JPL в текстовом формате: файл заголовка (header.405)
+// и файлы блоков коэффициентов (ascp1600.405 и т.д.) в порядке возрастания дат.
+// Повторяющиеся на стыках файлов блоки пропускаются.
+func (e *Ephemeris) LoadJPLASCII(headerPath string, dataPaths ...string) (*JPLHeader, error) {
+	file, err := os.Open(headerPath)
+	if err != nil {
+		return nil, err
+	}
+	d, ncoeff, err := readJPLASCIIHeader(file)
+	_ = file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", headerPath, err)
+	}
+
+	var records [][]float64
+	for _, path := range dataPaths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		records, err = readJPLASCIIBlocks(file, ncoeff, records)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if len(records) == 0 {
+		return nil, errors.New("no coefficient blocks")
+	}
+	d.records = len(records)
+	d.header.Start = records[0][0]
+	d.header.End = records[len(records)-1][1]
+	d.read = func(record int) ([]float64, error) {
+		if record < 0 || record >= len(records) {
+			return nil, errors.New("record is out of data range")
+		}
+		return records[record], nil
+	}
+	if err := e.addJPLTheories(d); err != nil {
+		return nil, err
+	}
+	return &d.header, nil
+}
+
+// readJPLBinary читает заголовок бинарного файла эфемерид JPL.
+func readJPLBinary(file *os.File) (*jplEphemeris, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	buffer := make([]byte, jplHeaderSize)
+	if _, err := file.ReadAt(buffer, 0); err != nil {
+		return nil, fmt.Errorf("%w format", ErrUnsupported)
+	}
+	order := jplByteOrder(buffer)
+	if order == nil {
+		return nil, fmt.Errorf("%w format", ErrUnsupported)
+	}
+	float := func(b []byte) float64 {
+		return math.Float64frombits(order.Uint64(b))
+	}
+	integer := func(b []byte) int {
+		return int(int32(order.Uint32(b)))
+	}
+
+	var d jplEphemeris
+	for i := 0; i < 3; i++ {
+		if line := strings.TrimSpace(string(buffer[84*i : 84*(i+1)])); line != "" {
+			d.header.Title = append(d.header.Title, line)
+		}
+	}
+	d.header.Start = float(buffer[2652:])
+	d.header.End = float(buffer[2660:])
+	d.header.Step = float(buffer[2668:])
+	ncon := integer(buffer[2676:])
+	d.header.AU = float(buffer[2680:])
+	d.header.EMRAT = float(buffer[2688:])
+	for i := 0; i < 12; i++ {
+		for j := 0; j < 3; j++ {
+			d.pointers[i][j] = integer(buffer[2696+12*i+4*j:])
+		}
+	}
+	d.header.Version = integer(buffer[2840:])
+	for j := 0; j < 3; j++ {
+		d.pointers[jplItemLibrations][j] = integer(buffer[2844+4*j:])
+	}
+
+	// имена констант сверх 400 и указатели величин 13 и 14 (с DE430) следуют за основной частью заголовка
+	extra := 0
+	if ncon > jplNamesNumber {
+		extra = (ncon - jplNamesNumber) * 6
+	}
+	tail := make([]byte, extra+24)
+	n, _ := file.ReadAt(tail, jplHeaderSize)
+	if n < extra {
+		return nil, errors.New("unexpected eof")
+	}
+	if n == len(tail) && d.header.Version >= 430 {
+		for i, item := range []int{13, jplItemTimeDiff} {
+			var pointer [3]int
+			for j := range pointer {
+				pointer[j] = integer(tail[extra+12*i+4*j:])
+			}
+			if pointer[0] >= 3 && pointer[1] > 0 && pointer[1] < 100 && pointer[2] > 0 && pointer[2] < 100 {
+				d.pointers[item] = pointer
+			}
+		}
+	}
+
+	ncoeff := d.coefficientsNumber()
+	if ncoeff < 2 {
+		return nil, errors.New("bad pointer table")
+	}
+	recordSize := int64(ncoeff) * 8
+	if recordSize < jplHeaderSize+int64(extra) || int64(ncon)*8 > recordSize {
+		return nil, fmt.Errorf("bad record size (%d)", recordSize)
+	}
+	names := string(buffer[252:2652])
+	if extra > 0 {
+		names += string(tail[:extra])
+	}
+	values := make([]byte, ncon*8)
+	if _, err := file.ReadAt(values, recordSize); err != nil {
+		return nil, err
+	}
+	d.header.Constants = make(map[string]float64, ncon)
+	for i := 0; i < ncon; i++ {
+		d.header.Constants[strings.TrimSpace(names[6*i:6*i+6])] = float(values[8*i:])
+	}
+
+	// записи коэффициентов начинаются с третьей; лишние записи за концом интервала заголовка не используются
+	d.records = int(info.Size()/recordSize) - 2
+	if expected := int(math.Round((d.header.End - d.header.Start) / d.header.Step)); d.records > expected {
+		d.records = expected
+	}
+	if d.records < 1 {
+		return nil, errors.New("no coefficient records")
+	}
+	d.read = func(record int) ([]float64, error) {
+		if record < 0 || record >= d.records {
+			return nil, errors.New("record is out of file range")
+		}
+		buffer := make([]byte, recordSize)
+		if _, err := file.ReadAt(buffer, (int64(record)+2)*recordSize); err != nil {
+			return nil, err
+		}
+		result := make([]float64, ncoeff)
+		for i := range result {
+			result[i] = float(buffer[8*i:])
+		}
+		return result, nil
+	}
+	first, err := d.read(0)
+	if err != nil {
+		return nil, err
+	}
+	if math.Abs(first[0]-d.header.Start) > 1e-6 {
+		return nil, fmt.Errorf("first record starts at %v instead of %v", first[0], d.header.Start)
+	}
+	d.header.End = d.header.Start + float64(d.records)*d.header.Step
+	return &d, nil
+}
+
+// isJPLBinary проверяет, что файл начинается с заголовка бинарных эфемерид JPL.
+func isJPLBinary(file *os.File) bool {
+	buffer := make([]byte, jplHeaderSize)
+	if _, err := file.ReadAt(buffer, 0); err != nil {
+		return false
+	}
+	return jplByteOrder(buffer) != nil
+}
+
+// jplByteOrder возвращает порядок байтов, при котором заголовок бинарного файла эфемерид JPL правдоподобен:
+// номер эфемерид (DENUM) и количество констант (NCON) находятся в допустимых пределах,
+// а интервал дат и длина записи (SS) упорядочены. Если такого порядка нет, возвращается nil.
+func jplByteOrder(buffer []byte) binary.ByteOrder {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		version := int32(order.Uint32(buffer[2840:]))
+		ncon := int32(order.Uint32(buffer[2676:]))
+		if version <= 0 || version >= 10000 || ncon <= 0 || ncon >= 10000 {
+			continue
+		}
+		start := math.Float64frombits(order.Uint64(buffer[2652:]))
+		end := math.Float64frombits(order.Uint64(buffer[2660:]))
+		step := math.Float64frombits(order.Uint64(buffer[2668:]))
+		if start < end && step > 0 && step <= end-start && !math.IsInf(end-start, 0) {
+			return order
+		}
+	}
+	return nil
+}
+
+// readJPLASCIIHeader читает файл заголовка текстового формата эфемерид JPL
+// и возвращает количество коэффициентов в блоке.
+func readJPLASCIIHeader(reader io.Reader) (*jplEphemeris, int, error) {
+	var d jplEphemeris
+	ncoeff := 0
+	groups := make(map[int][]string)
+	group := 0
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case strings.HasPrefix(line, "KSIZE="):
+			for i := 0; i+1 < len(fields); i++ {
+				if fields[i] == "NCOEFF=" {
+					ncoeff, _ = strconv.Atoi(fields[i+1])
+				}
+			}
+		case fields[0] == "GROUP" && len(fields) == 2:
+			group, _ = strconv.Atoi(fields[1])
+		case group == 1010:
+			d.header.Title = append(d.header.Title, line)
+		default:
+			groups[group] = append(groups[group], fields...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+	if ncoeff < 2 {
+		return nil, 0, fmt.Errorf("%w format: NCOEFF not found", ErrUnsupported)
+	}
+
+	span, err := parseJPLNumbers(groups[1030])
+	if err != nil || len(span) != 3 {
+		return nil, 0, errors.New("bad group 1030")
+	}
+	d.header.Start, d.header.End, d.header.Step = span[0], span[1], span[2]
+
+	names := groups[1040]
+	values, err := parseJPLNumbers(groups[1041])
+	if err != nil || len(names) == 0 || len(values) == 0 || len(names)-1 != int(values[0]) || len(values) != len(names) {
+		return nil, 0, errors.New("bad groups 1040 and 1041")
+	}
+	d.header.Constants = make(map[string]float64, len(names)-1)
+	for i, name := range names[1:] {
+		d.header.Constants[name] = values[i+1]
+	}
+	d.header.AU = d.header.Constants["AU"]
+	d.header.EMRAT = d.header.Constants["EMRAT"]
+	d.header.Version = int(math.Round(d.header.Constants["DENUM"]))
+
+	// таблица указателей: три строки по 13 или 15 столбцов
+	pointers := groups[1050]
+	columns := len(pointers) / 3
+	if len(pointers)%3 != 0 || columns < jplItemLibrations+1 || columns > jplItemsNumber {
+		return nil, 0, errors.New("bad group 1050")
+	}
+	for i := 0; i < columns; i++ {
+		for j := 0; j < 3; j++ {
+			if d.pointers[i][j], err = strconv.Atoi(pointers[j*columns+i]); err != nil {
+				return nil, 0, errors.New("bad group 1050")
+			}
+		}
+	}
+	if required := d.coefficientsNumber(); required > ncoeff {
+		return nil, 0, fmt.Errorf("pointer table requires %d coefficients, NCOEFF is %d", required, ncoeff)
+	}
+	return &d, ncoeff, nil
+}
+
+// readJPLASCIIBlocks читает блоки коэффициентов текстового формата и добавляет их к records.
+// Блок состоит из строки с номером блока и количеством коэффициентов и строк по три числа.
+func readJPLASCIIBlocks(reader io.Reader, ncoeff int, records [][]float64) ([][]float64, error) {
+	scanner := bufio.NewScanner(reader)
+	var block []float64
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if block == nil {
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("bad block header %q", scanner.Text())
+			}
+			if n, err := strconv.Atoi(fields[1]); err != nil || n != ncoeff {
+				return nil, fmt.Errorf("bad block header %q", scanner.Text())
+			}
+			block = make([]float64, 0, ncoeff)
+			continue
+		}
+		values, err := parseJPLNumbers(fields)
+		if err != nil {
+			return nil, err
+		}
+		// последняя строка блока дополняется нулями до трёх чисел
+		if rest := ncoeff - len(block); len(values) > rest {
+			values = values[:rest]
+		}
+		block = append(block, values...)
+		if len(block) < ncoeff {
+			continue
+		}
+
+		if len(records) > 0 {
+			last := records[len(records)-1]
+			if block[0] < last[1] {
+				// блок уже загружен из предыдущего файла
+				block = nil
+				continue
+			}
+			if block[0] != last[1] {
+				return nil, fmt.Errorf("gap between blocks at %v", last[1])
+			}
+		}
+		records = append(records, block)
+		block = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if block != nil {
+		return nil, errors.New("incomplete block")
+	}
+	return records, nil
+}
+
+// parseJPLNumbers разбирает числа в формате Фортрана (с экспонентой D).
+func parseJPLNumbers(fields []string) ([]float64, error) {
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		value, err := strconv.ParseFloat(strings.NewReplacer("D", "E", "d", "e").Replace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// coefficientsNumber возвращает количество коэффициентов в записи по таблице указателей.
+func (d *jplEphemeris) coefficientsNumber() int {
+	result := 2
+	for i, pointer := range d.pointers {
+		if pointer[1] <= 0 || pointer[2] <= 0 {
+			continue
+		}
+		if end := pointer[0] - 1 + pointer[1]*pointer[2]*jplComponents[i]; end > result {
+			result = end
+		}
+	}
+	return result
+}
+
+// addJPLTheories добавляет теории загруженных эфемерид JPL и гравитационные параметры тел.
+func (e *Ephemeris) addJPLTheories(d *jplEphemeris) error {
+	if d.header.EMRAT <= 0 {
+		return errors.New("EMRAT not found")
+	}
+	var theories []*Theory
+	for _, body := range jplBodies {
+		if d.pointers[body.item][1] > 0 {
+			theories = append(theories, d.theory(body.item, body.object, body.basis, FormatSPK, 1))
+		}
+	}
+	// Земля и Луна относительно барицентра системы Земля-Луна по геоцентрическим коэффициентам Луны
+	if d.pointers[jplItemMoon][1] > 0 {
+		theories = append(theories,
+			d.theory(jplItemMoon, EphemerisEarth, EphemerisEarthMoon, FormatSPK, -1/(1+d.header.EMRAT)),
+			d.theory(jplItemMoon, EphemerisMoon, EphemerisEarthMoon, FormatSPK, d.header.EMRAT/(1+d.header.EMRAT)))
+	}
+	if d.pointers[jplItemLibrations][1] > 0 {
+		// либрации версий, система главных осей которых неизвестна, пропускаются
+		if frame, ok := jplLibrationFrames[d.header.Version]; ok {
+			theories = append(theories, d.theory(jplItemLibrations, frame, frameJ2000, FormatPCK, 1))
+		}
+	}
+	if d.pointers[jplItemTimeDiff][1] > 0 {
+		// центр 1000000000, как в сегментах SPK с разностью TT-TDB
+		theories = append(theories, d.theory(jplItemTimeDiff, EphemerisCodeMinusTDB, 1000000000, FormatSPK, 1))
+	}
+	if len(theories) == 0 {
+		return errors.New("no bodies in pointer table")
+	}
+	for _, theory := range theories {
+		e.addTheory(theory)
+	}
+	e.setJPLConstants(&d.header)
+	return nil
+}
+
+// setJPLConstants добавляет гравитационные параметры тел заголовка (а.е.^3/сут^2) в переменные ядер в км^3/с^2.
+func (e *Ephemeris) setJPLConstants(header *JPLHeader) {
+	if header.AU <= 0 {
+		return
+	}
+	scale := header.AU * header.AU * header.AU / (secondsInDay * secondsInDay)
+	set := func(body int, gm float64) {
+		e.pool.SetFloats(fmt.Sprintf("BODY%d_GM", body), []float64{gm * scale})
+	}
+	for i := EphemerisMercury; i <= EphemerisPluto; i++ {
+		if gm, ok := header.Constants[fmt.Sprintf("GM%d", i)]; ok {
+			set(i, gm)
+		}
+	}
+	// у Меркурия и Венеры нет спутников, масса барицентра совпадает с массой планеты
+	for _, body := range []int{EphemerisMercury, EphemerisVenus} {
+		if gm, ok := header.Constants[fmt.Sprintf("GM%d", body)]; ok {
+			set(100*body+99, gm)
+		}
+	}
+	if gm, ok := header.Constants["GMS"]; ok {
+		set(EphemerisSun, gm)
+	}
+	if gm, ok := header.Constants["GMB"]; ok {
+		set(EphemerisEarthMoon, gm)
+		set(EphemerisEarth, gm*header.EMRAT/(1+header.EMRAT))
+		set(EphemerisMoon, gm/(1+header.EMRAT))
+	}
+}
+
+// theory создаёт теорию величины item. Данные теории располагаются так же, как в сегменте SPK типа 2
+// (подынтервал записи соответствует интервалу сегмента), коэффициенты умножаются на scale.
+func (d *jplEphemeris) theory(item, object, basis, fileType int, scale float64) *Theory {
+	offset, ncoef, nsub := d.pointers[item][0]-1, d.pointers[item][1], d.pointers[item][2]
+	components := jplComponents[item]
+	intervalLen := d.header.Step / float64(nsub)
+	start := (d.header.Start - julianDate2000) * secondsInDay
+	end := (d.header.End - julianDate2000) * secondsInDay
+
+	theory := &Theory{
+		object:           object,
+		basis:            basis,
+		representation:   representationPositionOnly,
+		intervalLen:      intervalLen,
+		rSize:            3*ncoef + 2,
+		nIntervals:       d.records * nsub,
+		polynomialDegree: ncoef - 1,
+		dScale:           1,
+		tScale:           1,
+		cachedInterval:   -1,
+		fileType:         fileType,
+	}
+	days := int(start / secondsInDay)
+	theory.julianDays = julianDate2000 + float64(days)
+	theory.julianDaysMod = (start - float64(days)*secondsInDay) / secondsInDay
+
+	segment := &DAFSegment{
+		length:      int32(theory.nIntervals*theory.rSize + 4),
+		dParameters: []float64{start, end},
+		iParameters: []int32{int32(object), int32(basis), frameJ2000, representationPositionOnly},
+	}
+	if fileType == FormatPCK {
+		segment.iParameters = []int32{int32(object), int32(basis), representationPositionOnly}
+	}
+	theory.segment = segment
+
+	trailer := []float64{start, intervalLen * secondsInDay, float64(theory.rSize), float64(theory.nIntervals)}
+	cachedRecord, cached := -1, []float64(nil)
+	theory.reader = func(from, length int) ([]float64, error) {
+		if from < 0 || from+length > int(segment.length) {
+			return nil, errors.New("segment is out of data range")
+		}
+		result := make([]float64, length)
+		for i := range result {
+			interval, k := (from+i)/theory.rSize, (from+i)%theory.rSize
+			if interval == theory.nIntervals {
+				result[i] = trailer[k]
+				continue
+			}
+			record, sub := interval/nsub, interval%nsub
+			if record != cachedRecord {
+				data, err := d.read(record)
+				if err != nil {
+					return nil, err
+				}
+				cachedRecord, cached = record, data
+			}
+			switch {
+			case k == 0:
+				// середина подынтервала в секундах от J2000
+				result[i] = ((cached[0] - julianDate2000) + (float64(sub)+0.5)*intervalLen) * secondsInDay
+			case k == 1:
+				result[i] = intervalLen * secondsInDay / 2
+			case (k-2)/ncoef < components:
+				result[i] = cached[offset+(sub*components+(k-2)/ncoef)*ncoef+(k-2)%ncoef] * scale
+			}
+		}
+		return result, nil
+	}
+	return theory
+}
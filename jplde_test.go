@@ -0,0 +1,252 @@
+package rightround
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeJPLBinary записывает бинарный файл эфемерид DE405 с порядком байтов order: две записи по 32 суток
+// от JD 2451536.5, в которых X Солнца задана на двух подынтервалах полиномами 1000 + 10x и 2000 + 10x.
+func writeJPLBinary(t *testing.T, path string, order binary.ByteOrder) {
+	const ncoeff = 398
+	const recordSize = ncoeff * 8
+	data := make([]byte, 4*recordSize)
+	putFloat := func(offset int, value float64) {
+		order.PutUint64(data[offset:], math.Float64bits(value))
+	}
+	putInt := func(offset, value int) {
+		order.PutUint32(data[offset:], uint32(int32(value)))
+	}
+	copy(data, "JPL Planetary Ephemeris DE405/LE405")
+	copy(data[252:], "AU    EMRAT ")
+	putFloat(2652, 2451536.5)
+	putFloat(2660, 2451600.5)
+	putFloat(2668, 32)
+	putInt(2676, 2)
+	putFloat(2680, 149597870.691)
+	putFloat(2688, 81.30056)
+	// Солнце (величина 10): смещение 3, два коэффициента, два подынтервала
+	putInt(2696+12*10, 3)
+	putInt(2696+12*10+4, 2)
+	putInt(2696+12*10+8, 2)
+	// Меркурий (величина 0) с нулевыми коэффициентами дополняет запись до размера заголовка
+	putInt(2696, 15)
+	putInt(2696+4, 64)
+	putInt(2696+8, 2)
+	putInt(2840, 405)
+	putFloat(recordSize, 149597870.691)
+	putFloat(recordSize+8, 81.30056)
+	for record := 0; record < 2; record++ {
+		offset := (record + 2) * recordSize
+		putFloat(offset, 2451536.5+32*float64(record))
+		putFloat(offset+8, 2451568.5+32*float64(record))
+		for sub := 0; sub < 2; sub++ {
+			putFloat(offset+8*(2+6*sub), 1000*float64(2*record+sub+1))
+			putFloat(offset+8*(3+6*sub), 10)
+		}
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadJPLBinary(t *testing.T) {
+	dir := tempDir(t)
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		path := filepath.Join(dir, "lnxp2000.405")
+		writeJPLBinary(t, path, order)
+		ephemeris := NewEphemeris()
+		if err := ephemeris.LoadFile(path); err != nil {
+			t.Fatal(err)
+		}
+		// середины подынтервалов: 2451544.5, 2451560.5, 2451576.5, 2451592.5
+		for _, test := range []struct{ date, x float64 }{
+			{2451540.5, 995},
+			{2451560.5, 2000},
+			{2451580.5, 3005},
+			{2451600.5, 4010},
+		} {
+			coords, velocity, err := ephemeris.CalculateRectangularCoordsAndScaleVelocity(EphemerisSun, EphemerisSunSystem, test.date, 0, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(coords.X-test.x) > 1e-9 || math.Abs(velocity.X-10/8.0/secondsInDay) > 1e-15 {
+				t.Fatalf("%v at %v: %+v %+v", order, test.date, coords, velocity)
+			}
+		}
+	}
+}
+
+func TestLoadFileUnsupported(t *testing.T) {
+	path := filepath.Join(tempDir(t), "unknown.bin")
+	if err := ioutil.WriteFile(path, make([]byte, 4*jplHeaderSize), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// без правдоподобного заголовка JPL файл разбирается как DAF
+	err := NewEphemeris().LoadFile(path)
+	if !errors.Is(err, ErrUnsupported) || err.Error() != fmt.Errorf("%w format", ErrUnsupported).Error() {
+		t.Fatalf("error %v", err)
+	}
+}
+
+const testJPLASCIIHeader = `KSIZE=     40    NCOEFF=     16
+
+GROUP   1010
+
+JPL Planetary Ephemeris DE421/LE421
+Start Epoch: JED=  2451536.5 1999 DEC 20 00:00:00
+Final Epoch: JED=  2451600.5 2000 FEB 22 00:00:00
+
+GROUP   1030
+
+  0.24515365000000000D+07  0.24516005000000000D+07  0.32000000000000000D+02
+
+GROUP   1040
+
+     3
+  DENUM   AU      EMRAT
+
+GROUP   1041
+
+     3
+  0.421000000000000000D+03  0.149597870699626200D+09  0.813005690000000000D+02
+
+GROUP   1050
+
+     3     3     3     3     3     3     3     3     3     3     3     3     9
+     0     0     0     0     0     0     0     0     0     0     2     0     2
+     0     0     0     0     0     0     0     0     0     0     1     0     1
+
+GROUP   1070
+
+`
+
+// testJPLASCIIBlock возвращает блок коэффициентов с началом start, в котором X Солнца равна x0 + 10x,
+// а углы либрации - 0.1 + 0.01x, 0.2, 0.3.
+func testJPLASCIIBlock(number int, start, x0 float64) string {
+	values := []float64{start, start + 32, x0, 10, 0, 0, 0, 0, 0.1, 0.01, 0.2, 0, 0.3, 0, 0, 0}
+	var lines []string
+	lines = append(lines, fmt.Sprintf("%6d%6d", number, len(values)))
+	for i := 0; i < len(values); i += 3 {
+		var fields []string
+		for j := i; j < i+3; j++ {
+			value := 0.0
+			if j < len(values) {
+				value = values[j]
+			}
+			fields = append(fields, strings.Replace(fmt.Sprintf("%.17E", value), "E", "D", 1))
+		}
+		lines = append(lines, "  "+strings.Join(fields, "  "))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestReadJPLASCII(t *testing.T) {
+	d, ncoeff, err := readJPLASCIIHeader(strings.NewReader(testJPLASCIIHeader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ncoeff != 16 || d.header.Version != 421 || d.header.EMRAT != 81.300569 || len(d.header.Title) != 3 ||
+		d.header.Start != 2451536.5 || d.header.End != 2451600.5 || d.header.Step != 32 {
+		t.Fatalf("header %+v, ncoeff %d", d.header, ncoeff)
+	}
+	if d.pointers[10] != [3]int{3, 2, 1} || d.pointers[jplItemLibrations] != [3]int{9, 2, 1} {
+		t.Fatalf("pointers %v", d.pointers)
+	}
+
+	// повторяющийся на стыке файлов блок пропускается, разрыв между блоками - ошибка
+	records, err := readJPLASCIIBlocks(strings.NewReader(testJPLASCIIBlock(1, 2451536.5, 1000)), ncoeff, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := testJPLASCIIBlock(1, 2451536.5, 1000) + testJPLASCIIBlock(2, 2451568.5, 2000)
+	if records, err = readJPLASCIIBlocks(strings.NewReader(second), ncoeff, records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || len(records[1]) != ncoeff || records[1][2] != 2000 || records[1][12] != 0.3 {
+		t.Fatalf("records %v", records)
+	}
+	if _, err := readJPLASCIIBlocks(strings.NewReader(testJPLASCIIBlock(3, 2451610.5, 3000)), ncoeff, records); err == nil {
+		t.Fatal("gap between blocks is accepted")
+	}
+	if _, err := readJPLASCIIBlocks(strings.NewReader(testJPLASCIIBlock(3, 2451600.5, 3000)[:40]), ncoeff, records); err == nil {
+		t.Fatal("incomplete block is accepted")
+	}
+}
+
+func TestJPLTheory(t *testing.T) {
+	// две записи по 32 суток, величина с двумя подынтервалами по три коэффициента
+	records := [][]float64{
+		{2451536.5, 2451568.5, 1, 2, 3, 4, 5, 6, 7, 8, 9, 11, 12, 13, 14, 15, 16, 17, 18, 19},
+		{2451568.5, 2451600.5, 21, 22, 23, 24, 25, 26, 27, 28, 29, 31, 32, 33, 34, 35, 36, 37, 38, 39},
+	}
+	d := &jplEphemeris{
+		header:  JPLHeader{Start: 2451536.5, End: 2451600.5, Step: 32},
+		records: len(records),
+		read: func(record int) ([]float64, error) {
+			return records[record], nil
+		},
+	}
+	d.pointers[10] = [3]int{3, 3, 2}
+	theory := d.theory(10, EphemerisSun, EphemerisSunSystem, FormatSPK, 2)
+	if theory.nIntervals != 4 || theory.rSize != 11 || theory.polynomialDegree != 2 || theory.segment.length != 48 {
+		t.Fatalf("theory %+v", theory)
+	}
+	// третий интервал сегмента - первый подынтервал второй записи
+	data, err := theory.read(2*theory.rSize, theory.rSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []float64{secondsFromJ2000(2451576.5), 8 * secondsInDay, 42, 44, 46, 48, 50, 52, 54, 56, 58}
+	for i := range expected {
+		if data[i] != expected[i] {
+			t.Fatalf("record %v, expected %v", data, expected)
+		}
+	}
+	trailer, err := theory.read(4*theory.rSize, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trailer[0] != secondsFromJ2000(2451536.5) || trailer[1] != 16*secondsInDay || trailer[2] != 11 || trailer[3] != 4 {
+		t.Fatalf("trailer %v", trailer)
+	}
+}
+
+func TestJPLLibrationFrames(t *testing.T) {
+	for _, test := range []struct {
+		version, frame int
+	}{
+		{405, EphemerisMoonPrincipalAxesDE403},
+		{421, EphemerisMoonPrincipalAxesDE421},
+		{430, EphemerisMoonPrincipalAxesDE430},
+		{441, EphemerisMoonPrincipalAxesDE440},
+		{450, 0},
+	} {
+		d := &jplEphemeris{
+			header:  JPLHeader{Version: test.version, Start: 2451536.5, End: 2451568.5, Step: 32, EMRAT: 81.3},
+			records: 1,
+		}
+		d.pointers[jplItemLibrations] = [3]int{3, 2, 1}
+		d.pointers[jplItemTimeDiff] = [3]int{9, 2, 1}
+		ephemeris := NewEphemeris()
+		if err := ephemeris.addJPLTheories(d); err != nil {
+			t.Fatalf("DE%d: %v", test.version, err)
+		}
+		if test.frame == 0 {
+			// либрации неизвестной версии пропускаются, TT-TDB загружается
+			if len(ephemeris.theories) != 1 || ephemeris.theories[0].object != EphemerisCodeMinusTDB {
+				t.Fatalf("DE%d: %d theories", test.version, len(ephemeris.theories))
+			}
+			continue
+		}
+		if theory := ephemeris.theories[len(ephemeris.theories)-2]; theory.object != test.frame || theory.fileType != FormatPCK {
+			t.Fatalf("DE%d: frame %d", test.version, theory.object)
+		}
+	}
+}
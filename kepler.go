@@ -0,0 +1,168 @@
+package rightround
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// maxUniversalIterations наибольшее количество итераций при решении уравнения Кеплера в универсальных переменных.
+const maxUniversalIterations = 100
+
+// stumpff вычисляет функции Штумпфа c2(z) и c3(z).
+func stumpff(z float64) (float64, float64) {
+	switch {
+	case z > 1e-6:
+		s := math.Sqrt(z)
+		return (1 - math.Cos(s)) / z, (s - math.Sin(s)) / (s * z)
+	case z < -1e-6:
+		s := math.Sqrt(-z)
+		return (math.Cosh(s) - 1) / -z, (math.Sinh(s) - s) / (s * -z)
+	}
+	// разложение в ряд вблизи параболы
+	return 1.0/2 - z/24 + z*z/720, 1.0/6 - z/120 + z*z/5040
+}
+
+// PropagateTwoBody вычисляет положение и скорость через интервал времени dt по начальному состоянию
+// в задаче двух тел с гравитационным параметром gm (в согласованных единицах). Используется решение
+// уравнения Кеплера в универсальных переменных, пригодное для любых типов конических сечений.
+func PropagateTwoBody(coords, velocity Coords, gm, dt float64) (Coords, Coords, error) {
+	r0 := coords.length()
+	if gm <= 0 || r0 == 0 {
+		return Coords{}, Coords{}, errors.New("bad state or gravitational parameter")
+	}
+	if dt == 0 {
+		return coords, velocity, nil
+	}
+	sqrtGM := math.Sqrt(gm)
+	rv := coords.dot(velocity) / sqrtGM
+	// обратная большая полуось
+	alpha := 2/r0 - velocity.dot(velocity)/gm
+
+	// начальное приближение универсальной аномалии
+	var chi float64
+	if alpha > 1e-12 {
+		chi = sqrtGM * dt * alpha
+	} else if alpha < -1e-12 {
+		// гипербола (по Вальядо)
+		a := 1 / alpha
+		sign := math.Copysign(1, dt)
+		chi = sign * math.Sqrt(-a) * math.Log(-2*gm*alpha*dt/(coords.dot(velocity)+sign*math.Sqrt(-gm*a)*(1-r0*alpha)))
+	}
+	if chi == 0 || math.IsNaN(chi) || math.IsInf(chi, 0) {
+		chi = sqrtGM * dt / r0
+	}
+
+	var r, c2, c3 float64
+	converged := false
+	for i := 0; i < maxUniversalIterations; i++ {
+		z := alpha * chi * chi
+		c2, c3 = stumpff(z)
+		r = chi*chi*c2 + rv*chi*(1-z*c3) + r0*(1-z*c2)
+		delta := (sqrtGM*dt - chi*chi*chi*c3 - rv*chi*chi*c2 - r0*chi*(1-z*c3)) / r
+		chi += delta
+		if math.Abs(delta) <= 1e-13*math.Max(1, math.Abs(chi)) {
+			converged = true
+			break
+		}
+	}
+	if !converged {
+		return Coords{}, Coords{}, fmt.Errorf("universal kepler equation did not converge (dt = %g)", dt)
+	}
+	z := alpha * chi * chi
+	c2, c3 = stumpff(z)
+	r = chi*chi*c2 + rv*chi*(1-z*c3) + r0*(1-z*c2)
+
+	// функции Лагранжа
+	f := 1 - chi*chi*c2/r0
+	g := dt - chi*chi*chi*c3/sqrtGM
+	fDot := sqrtGM / (r * r0) * chi * (z*c3 - 1)
+	gDot := 1 - chi*chi*c2/r
+	return Coords{
+		X: f*coords.X + g*velocity.X,
+		Y: f*coords.Y + g*velocity.Y,
+		Z: f*coords.Z + g*velocity.Z,
+	}, Coords{
+		X: fDot*coords.X + gDot*velocity.X,
+		Y: fDot*coords.Y + gDot*velocity.Y,
+		Z: fDot*coords.Z + gDot*velocity.Z,
+	}, nil
+}
+
+// Propagate вычисляет положение и скорость по оскулирующим элементам через интервал времени dt
+// (в единицах времени элементов).
+func (el KeplerianElements) Propagate(dt float64) (Coords, Coords, error) {
+	coords, velocity, err := el.State()
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+	return PropagateTwoBody(coords, velocity, el.GM, dt)
+}
+
+// readDiscreteState читает состояние с заданным номером из сегмента типа 5 (км, км/с).
+func (t *Theory) readDiscreteState(index int) (Coords, Coords, error) {
+	state, err := t.readStates(index, 1)
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+	return Coords{X: state[0], Y: state[1], Z: state[2]}, Coords{X: state[3], Y: state[4], Z: state[5]}, nil
+}
+
+// propagateDiscreteState распространяет состояние с заданным номером на момент seconds (секунды от J2000).
+func (t *Theory) propagateDiscreteState(index int, seconds float64) (Coords, Coords, error) {
+	coords, velocity, err := t.readDiscreteState(index)
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+	return PropagateTwoBody(coords, velocity, t.gm, seconds-t.epochs[index])
+}
+
+// calculateByDiscreteStates вычисляет положение и скорость (в сутках) по сегменту типа 5. Как и в SPICE,
+// между соседними состояниями результаты распространения от обоих концов интервала смешиваются
+// с весом 1/2 + cos(πx)/2, вне интервала состояний используется ближайшее состояние.
+func (e *Ephemeris) calculateByDiscreteStates(theory *Theory, date1, date2 float64, scaleDistance, withVelocity bool) (Coords, Coords, error) {
+	seconds := ((date1 - julianDate2000) + date2) * secondsInDay
+	n := len(theory.epochs)
+	i := sort.SearchFloat64s(theory.epochs, seconds)
+
+	var coords, velocity Coords
+	var err error
+	switch {
+	case i < n && theory.epochs[i] == seconds:
+		coords, velocity, err = theory.readDiscreteState(i)
+	case i == 0:
+		coords, velocity, err = theory.propagateDiscreteState(0, seconds)
+	case i == n:
+		coords, velocity, err = theory.propagateDiscreteState(n-1, seconds)
+	default:
+		left, right := theory.epochs[i-1], theory.epochs[i]
+		leftCoords, leftVelocity, err := theory.propagateDiscreteState(i-1, seconds)
+		if err != nil {
+			return Coords{}, Coords{}, err
+		}
+		rightCoords, rightVelocity, err := theory.propagateDiscreteState(i, seconds)
+		if err != nil {
+			return Coords{}, Coords{}, err
+		}
+		arg := math.Pi * (seconds - left) / (right - left)
+		w := 0.5 + 0.5*math.Cos(arg)
+		dw := -0.5 * math.Pi * math.Sin(arg) / (right - left)
+		coords = leftCoords.scale(w).sub(rightCoords.scale(w - 1))
+		velocity = leftVelocity.scale(w).sub(rightVelocity.scale(w - 1)).sub(rightCoords.sub(leftCoords).scale(dw))
+	}
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+
+	distanceScale := 1.0
+	if scaleDistance {
+		distanceScale = e.distanceScalingFactor
+	}
+	coords = coords.scale(distanceScale)
+	if !withVelocity {
+		return coords, Coords{}, nil
+	}
+	// скорость в сутках, как и для остальных представлений
+	return coords, velocity.scale(distanceScale * secondsInDay), nil
+}
@@ -0,0 +1,86 @@
+package rightround
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// LoadMetaKernel загружает мета-ядро: все ядра из KERNELS_TO_LOAD загружаются по порядку,
+// поэтому сегменты ядер, перечисленных позже, имеют приоритет.
+// В путях символы из PATH_SYMBOLS с префиксом $ заменяются на значения из PATH_VALUES.
+func (e *Ephemeris) LoadMetaKernel(path string) error {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if e.loadingMetaKernels[absolute] {
+		return fmt.Errorf("meta-kernel %s is loaded recursively", path)
+	}
+	e.loadingMetaKernels[absolute] = true
+	defer delete(e.loadingMetaKernels, absolute)
+
+	meta := NewKernelPool()
+	if err := meta.LoadFile(path); err != nil {
+		return err
+	}
+	if err := e.pool.LoadFile(path); err != nil {
+		return err
+	}
+
+	kernels, ok := meta.ContinuedStrings("KERNELS_TO_LOAD", "+")
+	if !ok {
+		return fmt.Errorf("KERNELS_TO_LOAD not found in meta-kernel %s", path)
+	}
+	values, _ := meta.ContinuedStrings("PATH_VALUES", "+")
+	symbols, _ := meta.Strings("PATH_SYMBOLS")
+	if len(values) != len(symbols) {
+		return fmt.Errorf("PATH_VALUES and PATH_SYMBOLS sizes differ in meta-kernel %s", path)
+	}
+
+	for _, kernel := range kernels {
+		resolved, err := resolvePathSymbols(strings.TrimSpace(kernel), symbols, values)
+		if err != nil {
+			return fmt.Errorf("meta-kernel %s: %w", path, err)
+		}
+		if err := e.LoadFile(resolved); err != nil {
+			return fmt.Errorf("meta-kernel %s: %s: %w", path, resolved, err)
+		}
+	}
+	return nil
+}
+
+// resolvePathSymbols заменяет символы путей вида $NAME их значениями.
+func resolvePathSymbols(path string, symbols, values []string) (string, error) {
+	var result strings.Builder
+	for {
+		start := strings.IndexByte(path, '$')
+		if start < 0 {
+			result.WriteString(path)
+			return result.String(), nil
+		}
+		result.WriteString(path[:start])
+		end := start + 1
+		for end < len(path) && (path[end] == '_' || isAlphanumeric(path[end])) {
+			end++
+		}
+		symbol := path[start+1 : end]
+		found := false
+		for i, name := range symbols {
+			if strings.EqualFold(name, symbol) {
+				result.WriteString(values[i])
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("unknown path symbol $%s", symbol)
+		}
+		path = path[end:]
+	}
+}
+
+// isAlphanumeric проверяет, является ли символ латинской буквой или цифрой.
+func isAlphanumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
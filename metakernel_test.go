@@ -0,0 +1,67 @@
+package rightround
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeMetaKernel записывает мета-ядро со списком ядер kernels, заданных относительно символа $DIR.
+func writeMetaKernel(t *testing.T, dir, name string, kernels ...string) string {
+	text := "KPL/MK\n\\begindata\nPATH_VALUES = ( '" + dir + "' )\nPATH_SYMBOLS = ( 'DIR' )\nKERNELS_TO_LOAD = ("
+	for _, kernel := range kernels {
+		text += " '$DIR/" + kernel + "'"
+	}
+	text += " )\n\\begintext\n"
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadMetaKernel(t *testing.T) {
+	dir := tempDir(t)
+	if err := ioutil.WriteFile(filepath.Join(dir, "constants.tpc"), []byte("KPL/PCK\n\\begindata\nBODY399_RADII = ( 6378.1366 6378.1366 6356.7519 )\n\\begintext\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// ядро, перечисленное в нескольких мета-ядрах, не образует цикла
+	writeMetaKernel(t, dir, "inner.tm", "constants.tpc")
+	path := writeMetaKernel(t, dir, "outer.tm", "inner.tm", "constants.tpc", "inner.tm")
+	ephemeris := NewEphemeris()
+	if err := ephemeris.LoadMetaKernel(path); err != nil {
+		t.Fatal(err)
+	}
+	if radii, ok := ephemeris.pool.Floats("BODY399_RADII"); !ok || len(radii) != 3 {
+		t.Fatalf("radii %v", radii)
+	}
+
+	for _, path := range []string{
+		writeMetaKernel(t, dir, "self.tm", "self.tm"),
+		writeMetaKernel(t, dir, "a.tm", "constants.tpc", "b.tm"),
+	} {
+		writeMetaKernel(t, dir, "b.tm", "a.tm")
+		if err := NewEphemeris().LoadFile(path); err == nil {
+			t.Fatalf("%s: recursive meta-kernel is loaded", path)
+		}
+	}
+
+	// ошибки ядер сохраняют признаки
+	if err := ioutil.WriteFile(filepath.Join(dir, "unknown.bin"), make([]byte, 1024), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		kernel string
+		target error
+	}{
+		{"missing.bsp", os.ErrNotExist},
+		{"unknown.bin", ErrUnsupported},
+	} {
+		path := writeMetaKernel(t, dir, "errors.tm", test.kernel)
+		if err := NewEphemeris().LoadFile(path); !errors.Is(err, test.target) {
+			t.Fatalf("%s: error %v", test.kernel, err)
+		}
+	}
+}
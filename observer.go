@@ -0,0 +1,61 @@
+package rightround
+
+// Observer наблюдатель на поверхности Земли.
+type Observer struct {
+	Latitude  float64 // геодезическая широта, радианы
+	Longitude float64 // восточная долгота, радианы
+	Height    float64 // высота над эллипсоидом, километры
+	Ellipsoid Ellipsoid
+}
+
+// NewObserver создаёт наблюдателя по геодезическим координатам на эллипсоиде WGS84.
+func NewObserver(latitude, longitude, height float64) Observer {
+	return Observer{Latitude: latitude, Longitude: longitude, Height: height, Ellipsoid: EllipsoidWGS84}
+}
+
+// Position возвращает координаты наблюдателя в земной системе ITRS (в километрах).
+func (o Observer) Position() Coords {
+	return o.Ellipsoid.GeodeticToCartesian(o.Latitude, o.Longitude, o.Height)
+}
+
+// CalculateObserverCoords вычисляет геоцентрические координаты и скорость наблюдателя в ICRF
+// в установленных единицах измерения.
+func (e *Ephemeris) CalculateObserverCoords(observer Observer, date1, date2 float64, withVelocity bool) (Coords, Coords, error) {
+	transform, err := e.CalculateEarthTransform(date1, date2)
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+	coords, velocity := transform.Inverse().Apply(observer.Position(), Coords{})
+	coords.X *= e.distanceScalingFactor
+	coords.Y *= e.distanceScalingFactor
+	coords.Z *= e.distanceScalingFactor
+	if !withVelocity {
+		return coords, Coords{}, nil
+	}
+	velocity.X *= e.distanceScalingFactor
+	velocity.Y *= e.distanceScalingFactor
+	velocity.Z *= e.distanceScalingFactor
+	return coords, velocity, nil
+}
+
+// CalculateTopocentricCoords вычисляет координаты и скорость объекта относительно наблюдателя в ICRF
+// (геометрическое положение без учёта светового времени) в установленных единицах измерения.
+func (e *Ephemeris) CalculateTopocentricCoords(object int, observer Observer, date1, date2 float64, withVelocity bool) (Coords, Coords, error) {
+	coords, velocity, err := e.CalculateRectangularCoordsAndScaleVelocity(object, EphemerisEarth, date1, date2, withVelocity)
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+	observerCoords, observerVelocity, err := e.CalculateObserverCoords(observer, date1, date2, withVelocity)
+	if err != nil {
+		return Coords{}, Coords{}, err
+	}
+	coords.X -= observerCoords.X
+	coords.Y -= observerCoords.Y
+	coords.Z -= observerCoords.Z
+	if withVelocity {
+		velocity.X -= observerVelocity.X
+		velocity.Y -= observerVelocity.Y
+		velocity.Z -= observerVelocity.Z
+	}
+	return coords, velocity, nil
+}
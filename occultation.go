@@ -0,0 +1,169 @@
+package rightround
+
+import (
+	"fmt"
+	"math"
+)
+
+// Модели формы тел.
+const (
+	ShapeCodePoint     = 1 // точка
+	ShapeCodeSphere    = 2 // сфера с экваториальным радиусом тела
+	ShapeCodeEllipsoid = 3 // трёхосный эллипсоид, ориентированный по модели вращения тела
+)
+
+// Типы покрытий (по соглашениям SPICE). Прохождение тела по диску другого тела - кольцеобразное покрытие.
+const (
+	OccultationCodeAny     = 1 // любое перекрытие дисков
+	OccultationCodeFull    = 2 // ближнее тело полностью закрывает дальнее
+	OccultationCodeAnnular = 3 // диск ближнего тела целиком внутри диска дальнего
+	OccultationCodePartial = 4 // частичное перекрытие дисков
+)
+
+// Viewpoint точка наблюдения: центр тела или наблюдатель на поверхности Земли.
+type Viewpoint struct {
+	Body int       // тело, из центра которого ведётся наблюдение
+	Site *Observer // наблюдатель на поверхности Земли; если задан, Body не используется
+}
+
+// OccultationBody тело, участвующее в покрытии, и модель его формы.
+type OccultationBody struct {
+	Object int
+	Shape  int
+}
+
+// diskGeometry видимое положение тела и угловой радиус его диска.
+type diskGeometry struct {
+	direction Coords  // направление на тело от наблюдателя
+	distance  float64 // расстояние до тела
+	lightTime float64 // световое время, сутки
+}
+
+// calculateViewpoint вычисляет барицентрические координаты и скорость точки наблюдения.
+func (e *Ephemeris) calculateViewpoint(viewpoint Viewpoint, date float64) (Coords, Coords, error) {
+	if viewpoint.Site != nil {
+		return e.calculateBarycentricObserver(*viewpoint.Site, date, 0)
+	}
+	return e.CalculateRectangularCoordsAndScaleVelocity(viewpoint.Body, EphemerisSunSystem, date, 0, true)
+}
+
+// bodyOrientation возвращает матрицу перехода от ICRF к связанной с телом системе координат.
+func (e *Ephemeris) bodyOrientation(body int, date float64) (Matrix, error) {
+	var transform Transform
+	var err error
+	if bodyCode(body) == EphemerisEarth {
+		transform, err = e.CalculateEarthTransform(date, 0)
+	} else {
+		transform, err = e.CalculateIAUTransform(body, date, 0)
+	}
+	return transform.Rotation, err
+}
+
+// angularRadius возвращает угловой радиус (в радианах) тела на расстоянии distance в направлении across
+// (единичный вектор в картинной плоскости). Для эллипсоида используется опорная функция его ортогональной
+// проекции, что точно для удалённых тел и даёт точные моменты контактов по линии центров.
+func (e *Ephemeris) angularRadius(body OccultationBody, distance float64, across Coords, date float64) (float64, error) {
+	switch body.Shape {
+	case ShapeCodePoint:
+		return 0, nil
+	case ShapeCodeSphere, ShapeCodeEllipsoid:
+	default:
+		return 0, fmt.Errorf("%w shape: %d", ErrUnknown, body.Shape)
+	}
+	ellipsoid, err := e.BodyEllipsoid(body.Object)
+	if err != nil {
+		return 0, err
+	}
+	radius := ellipsoid.A
+	if body.Shape == ShapeCodeEllipsoid {
+		rotation, err := e.bodyOrientation(body.Object, date)
+		if err != nil {
+			return 0, err
+		}
+		u := rotation.Apply(across)
+		radius = math.Sqrt(ellipsoid.A*ellipsoid.A*u.X*u.X + ellipsoid.B*ellipsoid.B*u.Y*u.Y + ellipsoid.C*ellipsoid.C*u.Z*u.Z)
+	}
+	return math.Asin(math.Min(1, radius*e.distanceScalingFactor/distance)), nil
+}
+
+// occultationGeometry вычисляет видимое угловое расстояние между центрами тел, их угловые радиусы
+// по линии центров и признак того, что тело front ближе к наблюдателю, чем back.
+func (e *Ephemeris) occultationGeometry(front, back OccultationBody, viewpoint Viewpoint, date float64) (float64, float64, float64, bool, error) {
+	observerCoords, observerVelocity, err := e.calculateViewpoint(viewpoint, date)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	frontCoords, err := e.calculateApparent(front.Object, observerCoords, observerVelocity, date, 0)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	backCoords, err := e.calculateApparent(back.Object, observerCoords, observerVelocity, date, 0)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	frontDistance, backDistance := frontCoords.length(), backCoords.length()
+	frontDirection, backDirection := frontCoords.scale(1/frontDistance), backCoords.scale(1/backDistance)
+
+	// направление в картинной плоскости от центра ближнего тела к центру дальнего
+	across := backDirection.sub(frontDirection.scale(frontDirection.dot(backDirection)))
+	if length := across.length(); length > 0 {
+		across = across.scale(1 / length)
+	}
+	speed := e.speedOfLightPerDay()
+	frontRadius, err := e.angularRadius(front, frontDistance, across, date-frontDistance/speed)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	backRadius, err := e.angularRadius(back, backDistance, across.scale(-1), date-backDistance/speed)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	return angleBetween(frontCoords, backCoords), frontRadius, backRadius, frontDistance < backDistance, nil
+}
+
+// OccultationQuantity возвращает величину, отрицательную во время покрытия заданного типа тела back телом front
+// при наблюдении из точки viewpoint (по видимым положениям с учётом светового времени и аберрации),
+// и положительную вне его. Величина выражается в радианах; когда тело front дальше тела back,
+// она не меньше π.
+func (e *Ephemeris) OccultationQuantity(kind int, front, back OccultationBody, viewpoint Viewpoint) (Quantity, error) {
+	switch kind {
+	case OccultationCodeAny, OccultationCodeFull, OccultationCodeAnnular, OccultationCodePartial:
+	default:
+		return nil, fmt.Errorf("%w occultation type: %d", ErrUnknown, kind)
+	}
+	return func(date float64) (float64, error) {
+		separation, frontRadius, backRadius, inFront, err := e.occultationGeometry(front, back, viewpoint, date)
+		if err != nil {
+			return 0, err
+		}
+		// ближнее тело не может покрыть дальнее: смещение сохраняет непрерывность величины
+		offset := 0.0
+		if !inFront {
+			offset = math.Pi
+		}
+		overlap := separation - frontRadius - backRadius
+		full := separation - (frontRadius - backRadius)
+		annular := separation - (backRadius - frontRadius)
+		switch kind {
+		case OccultationCodeFull:
+			return full + offset, nil
+		case OccultationCodeAnnular:
+			return annular + offset, nil
+		case OccultationCodePartial:
+			// частичное: диски перекрываются, но ни один не лежит целиком внутри другого
+			return math.Max(overlap, -math.Min(full, annular)) + offset, nil
+		}
+		return overlap + offset, nil
+	}, nil
+}
+
+// FindOccultations находит интервалы внутри окна confine, в которых тело front покрывает тело back
+// (или проходит по его диску) при наблюдении из точки viewpoint. Для взаимных явлений спутников шаг поиска
+// должен быть меньше длительности самых коротких явлений.
+func (e *Ephemeris) FindOccultations(kind int, front, back OccultationBody, viewpoint Viewpoint, confine Window, options SearchOptions) (Window, error) {
+	quantity, err := e.OccultationQuantity(kind, front, back, viewpoint)
+	if err != nil {
+		return nil, err
+	}
+	return Search(quantity, SearchCodeLess, 0, confine, options)
+}
@@ -0,0 +1,631 @@
+package rightround
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Форматы файлов CCSDS OEM.
+const (
+	OEMFormatKVN = 1 // текст "ключ = значение"
+	OEMFormatXML = 2 // NDM/XML
+)
+
+// Методы интерполяции состояний.
+const (
+	InterpolationCodeLagrange = 1
+	InterpolationCodeHermite  = 2
+)
+
+// oemVersion версия формата CCSDS OEM.
+const oemVersion = "2.0"
+
+// defaultOEMInterpolationDegree степень интерполяции по умолчанию.
+const defaultOEMInterpolationDegree = 7
+
+// oemBodyNames имена центров и объектов, принятые в OEM (совпадают с именами NAIF).
+var oemBodyNames = map[int]string{
+	EphemerisSunSystem:   "SOLAR SYSTEM BARYCENTER",
+	EphemerisMercury:     "MERCURY BARYCENTER",
+	EphemerisVenus:       "VENUS BARYCENTER",
+	EphemerisEarthMoon:   "EARTH BARYCENTER",
+	EphemerisMars:        "MARS BARYCENTER",
+	EphemerisJupiter:     "JUPITER BARYCENTER",
+	EphemerisSaturn:      "SATURN BARYCENTER",
+	EphemerisUranus:      "URANUS BARYCENTER",
+	EphemerisNeptune:     "NEPTUNE BARYCENTER",
+	EphemerisPluto:       "PLUTO BARYCENTER",
+	EphemerisSun:         "SUN",
+	EphemerisMercuryBody: "MERCURY",
+	EphemerisVenusBody:   "VENUS",
+	EphemerisMoon:        "MOON",
+	EphemerisEarth:       "EARTH",
+	EphemerisMarsBody:    "MARS",
+	EphemerisJupiterBody: "JUPITER",
+	EphemerisSaturnBody:  "SATURN",
+	EphemerisUranusBody:  "URANUS",
+	EphemerisNeptuneBody: "NEPTUNE",
+	EphemerisPlutoBody:   "PLUTO",
+}
+
+// oemBodyAliases дополнительные имена центров.
+var oemBodyAliases = map[string]int{
+	"SSB":                   EphemerisSunSystem,
+	"EMB":                   EphemerisEarthMoon,
+	"EARTH-MOON BARYCENTER": EphemerisEarthMoon,
+	"EARTH MOON BARYCENTER": EphemerisEarthMoon,
+}
+
+// OEMOptions параметры записи состояний объекта в файл CCSDS OEM.
+// Состояния записываются в ICRF в километрах и км/с, даты - в шкале TDB.
+type OEMOptions struct {
+	Object              int
+	Center              int
+	ObjectName          string   // имя объекта (по умолчанию - имя NAIF или код)
+	ObjectID            string   // идентификатор объекта (по умолчанию - код)
+	Originator          string   // организация, создавшая файл
+	Format              int      // OEMFormatKVN или OEMFormatXML
+	Interpolation       int      // рекомендуемый метод интерполяции (по умолчанию InterpolationCodeHermite)
+	InterpolationDegree int      // рекомендуемая степень интерполяции (по умолчанию 7)
+	Grid                TimeGrid // даты состояний
+	Comments            []string // комментарии заголовка
+}
+
+// oemMetadata метаданные сегмента OEM.
+type oemMetadata struct {
+	Comments            []string `xml:"COMMENT"`
+	ObjectName          string   `xml:"OBJECT_NAME"`
+	ObjectID            string   `xml:"OBJECT_ID"`
+	CenterName          string   `xml:"CENTER_NAME"`
+	RefFrame            string   `xml:"REF_FRAME"`
+	TimeSystem          string   `xml:"TIME_SYSTEM"`
+	StartTime           string   `xml:"START_TIME"`
+	UseableStartTime    string   `xml:"USEABLE_START_TIME,omitempty"`
+	UseableStopTime     string   `xml:"USEABLE_STOP_TIME,omitempty"`
+	StopTime            string   `xml:"STOP_TIME"`
+	Interpolation       string   `xml:"INTERPOLATION,omitempty"`
+	InterpolationDegree string   `xml:"INTERPOLATION_DEGREE,omitempty"`
+}
+
+// oemState вектор состояния OEM (км, км/с).
+type oemState struct {
+	Epoch string  `xml:"EPOCH"`
+	X     float64 `xml:"X"`
+	Y     float64 `xml:"Y"`
+	Z     float64 `xml:"Z"`
+	XDot  float64 `xml:"X_DOT"`
+	YDot  float64 `xml:"Y_DOT"`
+	ZDot  float64 `xml:"Z_DOT"`
+}
+
+// oemSegment сегмент OEM: метаданные и векторы состояния.
+type oemSegment struct {
+	Metadata oemMetadata `xml:"metadata"`
+	Data     struct {
+		Comments []string   `xml:"COMMENT"`
+		States   []oemState `xml:"stateVector"`
+	} `xml:"data"`
+}
+
+// oemHeader заголовок OEM.
+type oemHeader struct {
+	Comments     []string `xml:"COMMENT"`
+	CreationDate string   `xml:"CREATION_DATE"`
+	Originator   string   `xml:"ORIGINATOR"`
+}
+
+// oemMessage содержимое файла OEM (структура соответствует NDM/XML).
+type oemMessage struct {
+	XMLName  xml.Name     `xml:"oem"`
+	ID       string       `xml:"id,attr"`
+	Version  string       `xml:"version,attr"`
+	Header   oemHeader    `xml:"header"`
+	Segments []oemSegment `xml:"body>segment"`
+}
+
+// WriteOEM записывает состояния объекта относительно центра на датах сетки в файл CCSDS OEM.
+func (e *Ephemeris) WriteOEM(path string, options OEMOptions) error {
+	if options.Format != OEMFormatKVN && options.Format != OEMFormatXML {
+		return fmt.Errorf("%w OEM format: %d", ErrUnknown, options.Format)
+	}
+	count, err := options.Grid.Len()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: no dates", ErrInvalidGrid)
+	}
+
+	var segment oemSegment
+	segment.Metadata = oemMetadata{
+		ObjectName: options.ObjectName,
+		ObjectID:   options.ObjectID,
+		CenterName: oemBodyNames[options.Center],
+		RefFrame:   "ICRF",
+		TimeSystem: "TDB",
+	}
+	if segment.Metadata.ObjectName == "" {
+		segment.Metadata.ObjectName = oemBodyNames[options.Object]
+	}
+	if segment.Metadata.ObjectName == "" {
+		segment.Metadata.ObjectName = strconv.Itoa(options.Object)
+	}
+	if segment.Metadata.ObjectID == "" {
+		segment.Metadata.ObjectID = strconv.Itoa(options.Object)
+	}
+	if segment.Metadata.CenterName == "" {
+		segment.Metadata.CenterName = strconv.Itoa(options.Center)
+	}
+	degree := options.InterpolationDegree
+	if degree <= 0 {
+		degree = defaultOEMInterpolationDegree
+	}
+	switch options.Interpolation {
+	case InterpolationCodeLagrange:
+		segment.Metadata.Interpolation = "LAGRANGE"
+	case 0, InterpolationCodeHermite:
+		segment.Metadata.Interpolation = "HERMITE"
+	default:
+		return fmt.Errorf("%w interpolation: %d", ErrUnknown, options.Interpolation)
+	}
+	segment.Metadata.InterpolationDegree = strconv.Itoa(degree)
+
+	for i := 0; i < count; i++ {
+		date1, date2 := options.Grid.date(i)
+		coords, velocity, err := e.CalculateRectangularCoords(options.Object, options.Center, date1, date2, true)
+		if err != nil {
+			return err
+		}
+		// перевод в километры и км/с независимо от установленных единиц
+		coords = coords.scale(1 / e.distanceScalingFactor)
+		velocity = velocity.scale(1 / e.distanceScalingFactor / secondsInDay)
+		segment.Data.States = append(segment.Data.States, oemState{
+			Epoch: formatOEMEpoch(date1, date2),
+			X:     coords.X, Y: coords.Y, Z: coords.Z,
+			XDot: velocity.X, YDot: velocity.Y, ZDot: velocity.Z,
+		})
+	}
+	segment.Metadata.StartTime = segment.Data.States[0].Epoch
+	segment.Metadata.StopTime = segment.Data.States[count-1].Epoch
+
+	message := oemMessage{
+		ID:      "CCSDS_OEM_VERS",
+		Version: oemVersion,
+		Header: oemHeader{
+			Comments:     options.Comments,
+			CreationDate: time.Now().UTC().Format("2006-01-02T15:04:05"),
+			Originator:   options.Originator,
+		},
+		Segments: []oemSegment{segment},
+	}
+	if message.Header.Originator == "" {
+		message.Header.Originator = "RIGHTROUND"
+	}
+
+	var data []byte
+	if options.Format == OEMFormatXML {
+		if data, err = xml.MarshalIndent(message, "", "  "); err != nil {
+			return err
+		}
+		data = append([]byte(xml.Header), append(data, '\n')...)
+	} else {
+		data = message.kvn()
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// kvn возвращает содержимое сообщения в формате KVN.
+func (m *oemMessage) kvn() []byte {
+	var buffer bytes.Buffer
+	line := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&buffer, "%-20s = %s\n", key, value)
+		}
+	}
+	comments := func(comments []string) {
+		for _, comment := range comments {
+			buffer.WriteString("COMMENT " + comment + "\n")
+		}
+	}
+	number := func(v float64) string {
+		return strconv.FormatFloat(v, 'E', -1, 64)
+	}
+
+	line("CCSDS_OEM_VERS", m.Version)
+	comments(m.Header.Comments)
+	line("CREATION_DATE", m.Header.CreationDate)
+	line("ORIGINATOR", m.Header.Originator)
+	for _, segment := range m.Segments {
+		meta := segment.Metadata
+		buffer.WriteString("\nMETA_START\n")
+		comments(meta.Comments)
+		line("OBJECT_NAME", meta.ObjectName)
+		line("OBJECT_ID", meta.ObjectID)
+		line("CENTER_NAME", meta.CenterName)
+		line("REF_FRAME", meta.RefFrame)
+		line("TIME_SYSTEM", meta.TimeSystem)
+		line("START_TIME", meta.StartTime)
+		line("USEABLE_START_TIME", meta.UseableStartTime)
+		line("USEABLE_STOP_TIME", meta.UseableStopTime)
+		line("STOP_TIME", meta.StopTime)
+		line("INTERPOLATION", meta.Interpolation)
+		line("INTERPOLATION_DEGREE", meta.InterpolationDegree)
+		buffer.WriteString("META_STOP\n\n")
+		comments(segment.Data.Comments)
+		for _, state := range segment.Data.States {
+			buffer.WriteString(strings.Join([]string{state.Epoch,
+				number(state.X), number(state.Y), number(state.Z),
+				number(state.XDot), number(state.YDot), number(state.ZDot)}, " ") + "\n")
+		}
+	}
+	return buffer.Bytes()
+}
+
+// formatOEMEpoch возвращает дату в формате ISO с микросекундами. Доля суток вычисляется
+// по частям даты, чтобы не терять точность.
+func formatOEMEpoch(date1, date2 float64) string {
+	midnight := math.Floor(date1+date2-0.5) + 0.5
+	microseconds := math.Round(((date1 - midnight) + date2) * secondsInDay * 1e6)
+	if microseconds >= secondsInDay*1e6 {
+		midnight++
+		microseconds -= secondsInDay * 1e6
+	} else if microseconds < 0 {
+		midnight--
+		microseconds += secondsInDay * 1e6
+	}
+	year, month, day, _ := julianDateToCalendar(midnight)
+	us := int64(microseconds)
+	return fmt.Sprintf("%04d-%02d-%02dT%02d:%02d:%02d.%06d", year, month, day,
+		us/3600000000, us/60000000%60, us/1000000%60, us%1000000)
+}
+
+// parseOEMEpoch разбирает дату OEM ("YYYY-MM-DDThh:mm:ss.s" или "YYYY-DDDThh:mm:ss.s")
+// и возвращает количество секунд от J2000. Для секунды координации (ss >= 60) возвращается
+// момент hh:mm:59 и отдельно - количество секунд после него.
+func parseOEMEpoch(text string) (float64, float64, error) {
+	text = strings.TrimSuffix(strings.TrimSpace(text), "Z")
+	datePart, timePart := text, ""
+	if i := strings.IndexByte(text, 'T'); i >= 0 {
+		datePart, timePart = text[:i], text[i+1:]
+	}
+	fields := strings.Split(datePart, "-")
+	numbers := make([]int, len(fields))
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return 0, 0, fmt.Errorf("bad OEM epoch %q", text)
+		}
+		numbers[i] = n
+	}
+	var julianDate float64
+	switch len(numbers) {
+	case 2:
+		// год и порядковый номер дня
+		julianDate = calendarToJulianDate(numbers[0], 1, 1, 0) + float64(numbers[1]-1)
+	case 3:
+		julianDate = calendarToJulianDate(numbers[0], numbers[1], numbers[2], 0)
+	default:
+		return 0, 0, fmt.Errorf("bad OEM epoch %q", text)
+	}
+
+	seconds, leap := 0.0, 0.0
+	if timePart != "" {
+		parts := strings.Split(timePart, ":")
+		if len(parts) != 3 {
+			return 0, 0, fmt.Errorf("bad OEM epoch %q", text)
+		}
+		for i, multiplier := range []float64{60 * 60, 60, 1} {
+			part, err := strconv.ParseFloat(parts[i], 64)
+			if err != nil {
+				return 0, 0, fmt.Errorf("bad OEM epoch %q", text)
+			}
+			if i == 2 && part >= 60 {
+				if part >= 61 {
+					return 0, 0, fmt.Errorf("bad OEM epoch %q", text)
+				}
+				leap, part = part-59, 59
+			}
+			seconds += part * multiplier
+		}
+	}
+	return (julianDate-julianDate2000)*secondsInDay + seconds, leap, nil
+}
+
+// parseOEMKVN разбирает сообщение OEM в формате KVN.
+func parseOEMKVN(data []byte) (*oemMessage, error) {
+	message := &oemMessage{}
+	var segment *oemSegment
+	inMeta, inCovariance := false, false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "COMMENT"):
+			continue
+		case line == "META_START":
+			message.Segments = append(message.Segments, oemSegment{})
+			segment = &message.Segments[len(message.Segments)-1]
+			inMeta = true
+			continue
+		case line == "META_STOP":
+			inMeta = false
+			continue
+		case line == "COVARIANCE_START":
+			inCovariance = true
+			continue
+		case line == "COVARIANCE_STOP":
+			inCovariance = false
+			continue
+		case inCovariance:
+			continue
+		}
+
+		if i := strings.IndexByte(line, '='); i >= 0 {
+			key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+			if !inMeta {
+				switch key {
+				case "CCSDS_OEM_VERS":
+					message.Version = value
+				case "CREATION_DATE":
+					message.Header.CreationDate = value
+				case "ORIGINATOR":
+					message.Header.Originator = value
+				}
+				continue
+			}
+			meta := &segment.Metadata
+			target := map[string]*string{
+				"OBJECT_NAME":          &meta.ObjectName,
+				"OBJECT_ID":            &meta.ObjectID,
+				"CENTER_NAME":          &meta.CenterName,
+				"REF_FRAME":            &meta.RefFrame,
+				"TIME_SYSTEM":          &meta.TimeSystem,
+				"START_TIME":           &meta.StartTime,
+				"USEABLE_START_TIME":   &meta.UseableStartTime,
+				"USEABLE_STOP_TIME":    &meta.UseableStopTime,
+				"STOP_TIME":            &meta.StopTime,
+				"INTERPOLATION":        &meta.Interpolation,
+				"INTERPOLATION_DEGREE": &meta.InterpolationDegree,
+			}[key]
+			if target != nil {
+				*target = value
+			}
+			continue
+		}
+
+		if segment == nil || inMeta {
+			return nil, fmt.Errorf("line %d: unexpected data", lineNumber)
+		}
+		// эпоха, положение и скорость; ускорения, если заданы, не используются
+		fields := strings.Fields(line)
+		if len(fields) != 7 && len(fields) != 10 {
+			return nil, fmt.Errorf("line %d: bad state vector", lineNumber)
+		}
+		var values [6]float64
+		for i := range values {
+			value, err := strconv.ParseFloat(fields[i+1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad state vector", lineNumber)
+			}
+			values[i] = value
+		}
+		segment.Data.States = append(segment.Data.States, oemState{Epoch: fields[0],
+			X: values[0], Y: values[1], Z: values[2], XDot: values[3], YDot: values[4], ZDot: values[5]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if message.Version == "" {
+		return nil, fmt.Errorf("%w format: CCSDS_OEM_VERS not found", ErrUnsupported)
+	}
+	return message, nil
+}
+
+// oemCenterCode возвращает код центра по имени OEM.
+func oemCenterCode(name string) (int, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if code, err := strconv.Atoi(name); err == nil {
+		return code, nil
+	}
+	if code, ok := oemBodyAliases[name]; ok {
+		return code, nil
+	}
+	for code, bodyName := range oemBodyNames {
+		if bodyName == name {
+			return code, nil
+		}
+	}
+	return 0, fmt.Errorf("%w center: %q", ErrUnknown, name)
+}
+
+// LoadOEM загружает состояния из файла CCSDS OEM (KVN или XML) как теории, интерполируемые
+// методом Лагранжа или Эрмита (как сегменты SPK типов 9 и 13) согласно метаданным.
+// Загруженные теории используются вместе с сегментами SPK и имеют приоритет над загруженными ранее.
+// object - код, назначаемый объекту; если 0, используется числовой OBJECT_ID.
+// Поддерживаются системы координат ICRF и EME2000 и шкалы времени TDB, TT и UTC.
+func (e *Ephemeris) LoadOEM(path string, object int) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var message *oemMessage
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '<' {
+		message = &oemMessage{}
+		if err := xml.Unmarshal(data, message); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	} else if message, err = parseOEMKVN(data); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	theories := make([]*Theory, 0, len(message.Segments))
+	for i := range message.Segments {
+		theory, err := e.oemTheory(&message.Segments[i], object)
+		if err != nil {
+			return fmt.Errorf("%s: segment %d: %w", path, i+1, err)
+		}
+		theories = append(theories, theory)
+	}
+	for _, theory := range theories {
+		e.addTheory(theory)
+	}
+	return nil
+}
+
+// oemTheory создаёт теорию по сегменту OEM.
+func (e *Ephemeris) oemTheory(segment *oemSegment, object int) (*Theory, error) {
+	meta := segment.Metadata
+	if object == 0 {
+		code, err := strconv.Atoi(strings.TrimSpace(meta.ObjectID))
+		if err != nil {
+			return nil, fmt.Errorf("object code for %q is required", meta.ObjectID)
+		}
+		object = code
+	}
+	center, err := oemCenterCode(meta.CenterName)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToUpper(strings.TrimSpace(meta.RefFrame)) {
+	case "ICRF", "EME2000", "J2000":
+	default:
+		return nil, fmt.Errorf("%w reference frame: %q", ErrUnsupported, meta.RefFrame)
+	}
+	var scale int
+	switch strings.ToUpper(strings.TrimSpace(meta.TimeSystem)) {
+	case "TDB":
+		scale = TimeScaleCodeTDB
+	case "TT":
+		scale = TimeScaleCodeTT
+	case "UTC":
+		scale = TimeScaleCodeUTC
+	default:
+		return nil, fmt.Errorf("%w time system: %q", ErrUnsupported, meta.TimeSystem)
+	}
+	// перевод секунд от J2000 в заданной шкале в секунды TDB
+	toTDB := func(text string) (float64, error) {
+		seconds, leap, err := parseOEMEpoch(text)
+		if err != nil {
+			return 0, err
+		}
+		if leap > 0 {
+			// секунда координации допустима только в UTC в конце суток, после которых меняется TAI - UTC;
+			// момент переводится от 23:59:59, а оставшиеся секунды добавляются в равномерной шкале
+			day := julianDate2000 + math.Floor(seconds/secondsInDay+0.5) - 0.5
+			if scale != TimeScaleCodeUTC || seconds-(day-julianDate2000)*secondsInDay != secondsInDay-1 ||
+				taiMinusUTC(day+1)-taiMinusUTC(day) != 1 {
+				return 0, fmt.Errorf("no leap second at %q", text)
+			}
+		}
+		if scale == TimeScaleCodeTDB {
+			return seconds, nil
+		}
+		date := julianDate2000 + seconds/secondsInDay
+		converted, err := e.ConvertTimeScale(date, scale, TimeScaleCodeTDB)
+		if err != nil {
+			return 0, err
+		}
+		return seconds + (converted-date)*secondsInDay + leap, nil
+	}
+
+	theory := &Theory{
+		object:         object,
+		basis:          center,
+		representation: representationHermite,
+		fileType:       FormatSPK,
+		cachedInterval: -1,
+	}
+	degree := defaultOEMInterpolationDegree
+	if meta.InterpolationDegree != "" {
+		if degree, err = strconv.Atoi(strings.TrimSpace(meta.InterpolationDegree)); err != nil || degree < 1 {
+			return nil, fmt.Errorf("bad interpolation degree %q", meta.InterpolationDegree)
+		}
+	}
+	switch strings.ToUpper(strings.TrimSpace(meta.Interpolation)) {
+	case "", "HERMITE":
+		// окно из (N+1)/2 состояний, но не менее двух
+		window := (degree + 1) / 2
+		if window < 2 {
+			window = 2
+		}
+		theory.polynomialDegree = 2*window - 1
+	case "LAGRANGE":
+		theory.representation = representationLagrange
+		theory.polynomialDegree = degree
+	case "LINEAR":
+		theory.representation = representationLagrange
+		theory.polynomialDegree = 1
+	default:
+		return nil, fmt.Errorf("%w interpolation: %q", ErrUnsupported, meta.Interpolation)
+	}
+	if theory.polynomialDegree > maxInterpolationDegree {
+		return nil, fmt.Errorf("bad interpolation degree (%d)", theory.polynomialDegree)
+	}
+
+	states := segment.Data.States
+	if len(states) == 0 {
+		return nil, fmt.Errorf("no state vectors")
+	}
+	// данные располагаются так же, как в сегменте SPK типа 9 или 13
+	n := len(states)
+	data := make([]float64, 0, 7*n+n/type5DirectorySize+2)
+	epochs := make([]float64, n)
+	for i, state := range states {
+		if epochs[i], err = toTDB(state.Epoch); err != nil {
+			return nil, err
+		}
+		if i > 0 && epochs[i] <= epochs[i-1] {
+			return nil, fmt.Errorf("state vector epochs are not increasing at %s", state.Epoch)
+		}
+		data = append(data, state.X, state.Y, state.Z, state.XDot, state.YDot, state.ZDot)
+	}
+	data = append(data, epochs...)
+	for i := type5DirectorySize; i <= n-1; i += type5DirectorySize {
+		data = append(data, epochs[i-1])
+	}
+	if theory.representation == representationLagrange {
+		data = append(data, float64(theory.polynomialDegree))
+	} else {
+		data = append(data, float64((theory.polynomialDegree+1)/2-1))
+	}
+	data = append(data, float64(n))
+	theory.epochs = data[6*n : 7*n]
+	theory.reader = memoryReader(data)
+
+	// интервал применимости: USEABLE_*_TIME, START_TIME/STOP_TIME или моменты первого и последнего состояний
+	start, end := epochs[0], epochs[n-1]
+	for _, bound := range []struct {
+		texts  []string
+		target *float64
+	}{
+		{[]string{meta.UseableStartTime, meta.StartTime}, &start},
+		{[]string{meta.UseableStopTime, meta.StopTime}, &end},
+	} {
+		for _, text := range bound.texts {
+			if text != "" {
+				if *bound.target, err = toTDB(text); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+	if end < start {
+		return nil, fmt.Errorf("stop time is before start time")
+	}
+	theory.setSpan(start, end)
+	theory.segment = &DAFSegment{
+		length:      int32(len(data)),
+		dParameters: []float64{start, end},
+		iParameters: []int32{int32(object), int32(center), frameJ2000, int32(theory.representation)},
+	}
+	return theory, nil
+}
@@ -0,0 +1,192 @@
+package rightround
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testOEMSource возвращает эфемериды с объектом 1000 на орбите testOrbit относительно барицентра.
+func testOEMSource(t *testing.T, dir string) *Ephemeris {
+	path := filepath.Join(dir, "orbit.bsp")
+	writer := NewSPKWriter(path, "test orbit")
+	spec := SegmentSpec{Object: 1000, Center: EphemerisSunSystem, Representation: representationPositionOnly, Start: 2451545, End: 2451545 + 100, Tolerance: 1e-6}
+	if err := writer.AddChebyshevSegment(spec, testOrbit); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Save(); err != nil {
+		t.Fatal(err)
+	}
+	ephemeris := NewEphemeris()
+	if err := ephemeris.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return ephemeris
+}
+
+// compareStates сравнивает состояния объектов на датах от start до end.
+func compareStates(t *testing.T, expected, actual *Ephemeris, expectedObject, actualObject int, start, end, step float64) {
+	for date := start; date <= end; date += step {
+		expectedCoords, expectedVelocity, err := expected.CalculateRectangularCoordsAndScaleVelocity(expectedObject, EphemerisSunSystem, date, 0, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		coords, velocity, err := actual.CalculateRectangularCoordsAndScaleVelocity(actualObject, EphemerisSunSystem, date, 0, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := coords.sub(expectedCoords).length(); diff > 1e-6 {
+			t.Fatalf("object %d at %v: position error %g km", actualObject, date, diff)
+		}
+		if diff := velocity.sub(expectedVelocity).length(); diff > 1e-10 {
+			t.Fatalf("object %d at %v: velocity error %g km/s", actualObject, date, diff)
+		}
+	}
+}
+
+func TestOEMRoundTrip(t *testing.T) {
+	dir := tempDir(t)
+	source := testOEMSource(t, dir)
+	for _, test := range []struct {
+		name                  string
+		format, interpolation int
+	}{
+		{"hermite.oem", OEMFormatKVN, InterpolationCodeHermite},
+		{"lagrange.oem", OEMFormatKVN, InterpolationCodeLagrange},
+		{"hermite.xml", OEMFormatXML, InterpolationCodeHermite},
+		{"lagrange.xml", OEMFormatXML, InterpolationCodeLagrange},
+	} {
+		path := filepath.Join(dir, test.name)
+		options := OEMOptions{
+			Object:        1000,
+			Center:        EphemerisSunSystem,
+			Format:        test.format,
+			Interpolation: test.interpolation,
+			Grid:          TimeGrid{Start: 2451545, End: 2451545 + 100, Step: 0.5},
+		}
+		if err := source.WriteOEM(path, options); err != nil {
+			t.Fatal(err)
+		}
+		ephemeris := NewEphemeris()
+		if err := ephemeris.LoadOEM(path, 0); err != nil {
+			t.Fatal(err)
+		}
+		compareStates(t, source, ephemeris, 1000, 1000, 2451545, 2451545+100, 0.37)
+	}
+}
+
+func TestOEMBadXML(t *testing.T) {
+	path := filepath.Join(tempDir(t), "bad.xml")
+	if err := ioutil.WriteFile(path, []byte("<oem><header>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEphemeris().LoadOEM(path, 0); err == nil || !strings.HasPrefix(err.Error(), path+": ") {
+		t.Fatalf("error %v", err)
+	}
+}
+
+func TestSubsetInterpolated(t *testing.T) {
+	dir := tempDir(t)
+	source := testOEMSource(t, dir)
+	ephemeris := NewEphemeris()
+	for i, interpolation := range []int{InterpolationCodeLagrange, InterpolationCodeHermite} {
+		path := filepath.Join(dir, "states.oem")
+		options := OEMOptions{Object: 1000, Center: EphemerisSunSystem, Format: OEMFormatKVN, Interpolation: interpolation,
+			Grid: TimeGrid{Start: 2451545, End: 2451545 + 100, Step: 0.25}}
+		if err := source.WriteOEM(path, options); err != nil {
+			t.Fatal(err)
+		}
+		if err := ephemeris.LoadOEM(path, 2000+i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(dir, "subset.bsp")
+	const start, end = 2451545 + 30.1, 2451545 + 60.2
+	if err := ephemeris.WriteSPK(path, SubsetOptions{Start: start, End: end, InternalName: "subset"}); err != nil {
+		t.Fatal(err)
+	}
+	subset := NewEphemeris()
+	if err := subset.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []int{2000, 2001} {
+		coverage := subset.Coverage(object)
+		if len(coverage) != 1 || coverage[0].Start > start || coverage[0].End < end ||
+			coverage[0].Start < start-5 || coverage[0].End > end+5 {
+			t.Fatalf("object %d: coverage %v", object, coverage)
+		}
+		compareStates(t, ephemeris, subset, object, object, start, end, 0.29)
+	}
+}
+
+// testLeapOEM возвращает OEM в UTC, в котором X объекта равна номеру состояния.
+func testLeapOEM(epochs ...string) string {
+	text := `CCSDS_OEM_VERS = 2.0
+CREATION_DATE = 2017-01-01T00:00:00
+ORIGINATOR = TEST
+
+META_START
+OBJECT_NAME = TEST
+OBJECT_ID = 1000
+CENTER_NAME = SOLAR SYSTEM BARYCENTER
+REF_FRAME = ICRF
+TIME_SYSTEM = UTC
+START_TIME = ` + epochs[0] + `
+STOP_TIME = ` + epochs[len(epochs)-1] + `
+INTERPOLATION = LAGRANGE
+INTERPOLATION_DEGREE = 1
+META_STOP
+
+`
+	for i, epoch := range epochs {
+		text += fmt.Sprintf("%s %d 0 0 1 0 0\n", epoch, i)
+	}
+	return text
+}
+
+func TestOEMLeapSecond(t *testing.T) {
+	dir := tempDir(t)
+	path := filepath.Join(dir, "leap.oem")
+	text := testLeapOEM("2016-12-31T23:59:58.000", "2016-12-31T23:59:59.000", "2016-12-31T23:59:60.000",
+		"2017-01-01T00:00:00.000", "2017-01-01T00:00:01.000")
+	if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ephemeris := NewEphemeris()
+	if err := ephemeris.LoadOEM(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	// 2017-01-01T00:00:00 UTC наступает через 2 секунды после 2016-12-31T23:59:59, X растёт на 1 в секунду
+	date, err := ephemeris.ConvertTimeScale(2457754.5, TimeScaleCodeUTC, TimeScaleCodeTDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coords, _, err := ephemeris.CalculateRectangularCoords(1000, EphemerisSunSystem, date, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(coords.X-3) > 1e-4 {
+		t.Fatalf("X = %v at the end of the leap second", coords.X)
+	}
+
+	// секунда координации в сутки без изменения TAI - UTC
+	text = testLeapOEM("2016-06-30T23:59:59.000", "2016-06-30T23:59:60.000")
+	if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEphemeris().LoadOEM(path, 0); err == nil {
+		t.Fatal("leap second is accepted on 2016-06-30")
+	}
+	// секунда координации есть только в UTC
+	text = strings.Replace(testLeapOEM("2016-12-31T23:59:59.000", "2016-12-31T23:59:60.000"), "TIME_SYSTEM = UTC", "TIME_SYSTEM = TDB", 1)
+	if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEphemeris().LoadOEM(path, 0); err == nil {
+		t.Fatal("leap second is accepted in TDB")
+	}
+}
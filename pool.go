@@ -0,0 +1,403 @@
+package rightround
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// KernelPool хранилище переменных текстовых ядер NAIF (PCK, FK, LSK, мета-ядра).
+type KernelPool struct {
+	numbers map[string][]float64
+	strings map[string][]string
+}
+
+// NewKernelPool создаёт пустое хранилище переменных.
+func NewKernelPool() *KernelPool {
+	return &KernelPool{
+		numbers: make(map[string][]float64),
+		strings: make(map[string][]string),
+	}
+}
+
+// LoadFile загружает переменные из текстового ядра.
+func (p *KernelPool) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return p.Load(file)
+}
+
+// Load загружает переменные из текстового ядра: учитываются только блоки между \begindata и \begintext.
+func (p *KernelPool) Load(r io.Reader) error {
+	var data strings.Builder
+	inData := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch strings.TrimSpace(line) {
+		case `\begindata`:
+			inData = true
+			continue
+		case `\begintext`:
+			inData = false
+			continue
+		}
+		if inData {
+			data.WriteString(line)
+			data.WriteByte('\n')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	tokens, err := tokenizeKernelData(data.String())
+	if err != nil {
+		return err
+	}
+	return p.assign(tokens)
+}
+
+// kernelToken лексема секции данных текстового ядра.
+type kernelToken struct {
+	value    string
+	isString bool
+}
+
+// tokenizeKernelData разбивает секцию данных на лексемы: имена, операторы, скобки, числа и строки.
+func tokenizeKernelData(data string) ([]kernelToken, error) {
+	var tokens []kernelToken
+	runes := []rune(data)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r) || r == ',':
+			i++
+		case r == '(' || r == ')' || r == '=':
+			tokens = append(tokens, kernelToken{value: string(r)})
+			i++
+		case r == '+' && i+1 < len(runes) && runes[i+1] == '=':
+			tokens = append(tokens, kernelToken{value: "+="})
+			i += 2
+		case r == '\'':
+			var value strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, errors.New("unterminated string in text kernel")
+				}
+				if runes[i] == '\'' {
+					// удвоенная кавычка внутри строки
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						value.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, kernelToken{value: value.String(), isString: true})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(",()='", runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+			if strings.HasSuffix(word, "+") && i < len(runes) && runes[i] == '=' {
+				tokens = append(tokens, kernelToken{value: strings.TrimSuffix(word, "+")}, kernelToken{value: "+="})
+				i++
+				continue
+			}
+			tokens = append(tokens, kernelToken{value: word})
+		}
+	}
+	return tokens, nil
+}
+
+// assign выполняет присваивания переменных по последовательности лексем.
+func (p *KernelPool) assign(tokens []kernelToken) error {
+	for i := 0; i < len(tokens); {
+		name := tokens[i]
+		if name.isString || strings.ContainsAny(name.value, "()=") || i+1 >= len(tokens) {
+			return fmt.Errorf("unexpected token %q in text kernel", name.value)
+		}
+		operator := tokens[i+1].value
+		if tokens[i+1].isString || (operator != "=" && operator != "+=") {
+			return fmt.Errorf("assignment expected after %q in text kernel", name.value)
+		}
+		i += 2
+		if i >= len(tokens) {
+			return fmt.Errorf("value expected for %q in text kernel", name.value)
+		}
+
+		var values []kernelToken
+		if !tokens[i].isString && tokens[i].value == "(" {
+			for i++; ; i++ {
+				if i >= len(tokens) {
+					return fmt.Errorf("unclosed value list for %q in text kernel", name.value)
+				}
+				if !tokens[i].isString && tokens[i].value == ")" {
+					i++
+					break
+				}
+				values = append(values, tokens[i])
+			}
+		} else {
+			values = append(values, tokens[i])
+			i++
+		}
+
+		if err := p.setValues(name.value, values, operator == "+="); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setValues присваивает или добавляет значения переменной.
+func (p *KernelPool) setValues(name string, values []kernelToken, add bool) error {
+	if len(values) == 0 {
+		return fmt.Errorf("empty value list for %q in text kernel", name)
+	}
+	isString := values[0].isString
+	for _, value := range values {
+		if value.isString != isString {
+			return fmt.Errorf("mixed value types for %q in text kernel", name)
+		}
+	}
+
+	if isString {
+		if add {
+			if _, ok := p.numbers[name]; ok {
+				return fmt.Errorf("type mismatch for %q in text kernel", name)
+			}
+		} else {
+			delete(p.strings, name)
+		}
+		delete(p.numbers, name)
+		for _, value := range values {
+			p.strings[name] = append(p.strings[name], value.value)
+		}
+		return nil
+	}
+
+	numbers := make([]float64, len(values))
+	for i, value := range values {
+		number, err := parseKernelNumber(value.value)
+		if err != nil {
+			return fmt.Errorf("bad value for %q in text kernel: %v", name, err)
+		}
+		numbers[i] = number
+	}
+	if add {
+		if _, ok := p.strings[name]; ok {
+			return fmt.Errorf("type mismatch for %q in text kernel", name)
+		}
+	} else {
+		delete(p.numbers, name)
+	}
+	delete(p.strings, name)
+	p.numbers[name] = append(p.numbers[name], numbers...)
+	return nil
+}
+
+// parseKernelNumber разбирает число (допускается экспонента D) или дату с префиксом @,
+// которая преобразуется в секунды от J2000.
+func parseKernelNumber(value string) (float64, error) {
+	if strings.HasPrefix(value, "@") {
+		return parseKernelDate(value[1:])
+	}
+	return strconv.ParseFloat(strings.NewReplacer("D", "E", "d", "e").Replace(value), 64)
+}
+
+// kernelMonths названия месяцев, допустимые в датах текстовых ядер.
+var kernelMonths = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+
+// parseKernelDate разбирает дату вида 1972-JAN-1, 2000-01-01T12:00:00 или 2000-JAN-01/12:00
+// и возвращает количество секунд от J2000 без учёта секунд координации.
+func parseKernelDate(value string) (float64, error) {
+	// разделитель даты и времени в формате ISO
+	if i := strings.IndexByte(value, 'T'); i > 0 && value[i-1] >= '0' && value[i-1] <= '9' {
+		value = value[:i] + " " + value[i+1:]
+	}
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == '-' || r == '/' || r == ':' || r == ' '
+	})
+	if len(fields) < 3 {
+		return 0, fmt.Errorf("bad date %q", value)
+	}
+	year, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, fmt.Errorf("bad date %q", value)
+	}
+	month, err := strconv.Atoi(fields[1])
+	if err != nil {
+		month = 0
+		for i, name := range kernelMonths {
+			if strings.EqualFold(fields[1], name) {
+				month = i + 1
+			}
+		}
+		if month == 0 {
+			return 0, fmt.Errorf("bad date %q", value)
+		}
+	}
+	day, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return 0, fmt.Errorf("bad date %q", value)
+	}
+	seconds := 0.0
+	for i, multiplier := range []float64{60 * 60, 60, 1} {
+		if len(fields) <= 3+i {
+			break
+		}
+		part, err := strconv.ParseFloat(fields[3+i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("bad date %q", value)
+		}
+		seconds += part * multiplier
+	}
+	julianDate := calendarToJulianDate(year, month, day, seconds/secondsInDay)
+	return (julianDate - julianDate2000) * secondsInDay, nil
+}
+
+// Names возвращает отсортированный список имён переменных.
+func (p *KernelPool) Names() []string {
+	names := make([]string, 0, len(p.numbers)+len(p.strings))
+	for name := range p.numbers {
+		names = append(names, name)
+	}
+	for name := range p.strings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Floats возвращает значения числовой переменной.
+func (p *KernelPool) Floats(name string) ([]float64, bool) {
+	values, ok := p.numbers[name]
+	return values, ok
+}
+
+// Ints возвращает значения числовой переменной, округлённые до целых.
+func (p *KernelPool) Ints(name string) ([]int, bool) {
+	values, ok := p.numbers[name]
+	if !ok {
+		return nil, false
+	}
+	result := make([]int, len(values))
+	for i, value := range values {
+		if value < 0 {
+			result[i] = int(value - 0.5)
+		} else {
+			result[i] = int(value + 0.5)
+		}
+	}
+	return result, true
+}
+
+// Strings возвращает значения строковой переменной.
+func (p *KernelPool) Strings(name string) ([]string, bool) {
+	values, ok := p.strings[name]
+	return values, ok
+}
+
+// ContinuedStrings возвращает значения строковой переменной, объединяя элементы,
+// которые заканчиваются маркером продолжения marker (например, "//"), со следующими за ними.
+func (p *KernelPool) ContinuedStrings(name, marker string) ([]string, bool) {
+	values, ok := p.strings[name]
+	if !ok {
+		return nil, false
+	}
+	var result []string
+	var current strings.Builder
+	continued := false
+	for _, value := range values {
+		trimmed := strings.TrimRight(value, " ")
+		if strings.HasSuffix(trimmed, marker) {
+			current.WriteString(strings.TrimSuffix(trimmed, marker))
+			continued = true
+			continue
+		}
+		current.WriteString(value)
+		result = append(result, current.String())
+		current.Reset()
+		continued = false
+	}
+	if continued {
+		result = append(result, current.String())
+	}
+	return result, true
+}
+
+// SetFloats присваивает значения числовой переменной.
+func (p *KernelPool) SetFloats(name string, values []float64) {
+	delete(p.strings, name)
+	p.numbers[name] = append([]float64(nil), values...)
+}
+
+// SetStrings присваивает значения строковой переменной.
+func (p *KernelPool) SetStrings(name string, values []string) {
+	delete(p.numbers, name)
+	p.strings[name] = append([]string(nil), values...)
+}
+
+// bodyFloats возвращает значения переменной BODY<code>_<item>.
+func (p *KernelPool) bodyFloats(body int, item string) ([]float64, bool) {
+	return p.Floats(fmt.Sprintf("BODY%d_%s", body, item))
+}
+
+// rotationalElements возвращает элементы вращения тела, заданные в текстовом PCK,
+// или nil, если они не заданы.
+func (p *KernelPool) rotationalElements(body int) (*RotationalElements, error) {
+	ra, ok := p.bodyFloats(body, "POLE_RA")
+	if !ok {
+		return nil, nil
+	}
+	dec, ok := p.bodyFloats(body, "POLE_DEC")
+	if !ok {
+		return nil, nil
+	}
+	pm, ok := p.bodyFloats(body, "PM")
+	if !ok {
+		return nil, nil
+	}
+	elements := &RotationalElements{PoleRA: ra, PoleDec: dec, PrimeMeridian: pm}
+	elements.NutPrecRA, _ = p.bodyFloats(body, "NUT_PREC_RA")
+	elements.NutPrecDec, _ = p.bodyFloats(body, "NUT_PREC_DEC")
+	elements.NutPrecPM, _ = p.bodyFloats(body, "NUT_PREC_PM")
+
+	// нутационно-прецессионные углы задаются для барицентра системы полиномами степени
+	// MAX_PHASE_DEGREE (по умолчанию 1) от времени в столетиях
+	system := body
+	if body > 100 && body < 1000 {
+		system = body / 100
+	}
+	if angles, ok := p.bodyFloats(system, "NUT_PREC_ANGLES"); ok {
+		degree := 1
+		if values, ok := p.bodyFloats(system, "MAX_PHASE_DEGREE"); ok && len(values) > 0 {
+			degree = int(values[0])
+		}
+		if degree < 1 || len(angles)%(degree+1) != 0 {
+			return nil, fmt.Errorf("bad BODY%d_NUT_PREC_ANGLES for phase degree %d", system, degree)
+		}
+		for i := 0; i < len(angles); i += degree + 1 {
+			elements.NutPrecAngles = append(elements.NutPrecAngles, angles[i:i+degree+1])
+		}
+	}
+	return elements, nil
+}
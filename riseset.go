@@ -0,0 +1,104 @@
+package rightround
+
+import (
+	"math"
+	"sort"
+)
+
+// Типы событий.
+const (
+	EventCodeRise         = 1 // восход
+	EventCodeSet          = 2 // заход
+	EventCodeUpperTransit = 3 // верхняя кульминация
+	EventCodeLowerTransit = 4 // нижняя кульминация
+)
+
+// Event событие и его момент (юлианская дата TDB).
+type Event struct {
+	Code       int
+	JulianDate float64
+}
+
+// Значения по умолчанию для поиска событий, в сутках.
+const (
+	defaultSearchStep      = 1.0 / 24
+	defaultSearchTolerance = 1e-7
+)
+
+// RiseSetOptions параметры поиска восходов, заходов и кульминаций.
+type RiseSetOptions struct {
+	Horizon      float64     // высота горизонта, радианы
+	Atmosphere   *Atmosphere // атмосфера для учёта рефракции (nil - без рефракции)
+	SemiDiameter bool        // события относятся к верхнему краю диска (для Солнца и Луны)
+	Step         float64     // шаг поиска, сутки (0 - один час)
+	Tolerance    float64     // точность моментов, сутки (0 - около 0.01 секунды)
+}
+
+// FindRiseSetTransit находит моменты восхода, захода, верхней и нижней кульминаций объекта
+// для наблюдателя в интервале юлианских дат TDB [start, end]. События упорядочены по времени.
+func (e *Ephemeris) FindRiseSetTransit(object int, observer Observer, start, end float64, options RiseSetOptions) ([]Event, error) {
+	step, tolerance := options.Step, options.Tolerance
+	if step <= 0 {
+		step = defaultSearchStep
+	}
+	if tolerance <= 0 {
+		tolerance = defaultSearchTolerance
+	}
+	radius := 0.0
+	if options.SemiDiameter {
+		ellipsoid, err := e.BodyEllipsoid(object)
+		if err != nil {
+			return nil, err
+		}
+		radius = ellipsoid.A * e.distanceScalingFactor
+	}
+
+	elevation := func(date float64) (float64, error) {
+		coords, err := e.CalculateHorizontalCoords(object, observer, date, 0, options.Atmosphere)
+		if err != nil {
+			return 0, err
+		}
+		if radius > 0 {
+			coords.Elevation += math.Asin(math.Min(1, radius/coords.Distance))
+		}
+		return coords.Elevation - options.Horizon, nil
+	}
+	hourAngle := func(date float64) (float64, error) {
+		coords, err := e.CalculateHorizontalCoords(object, observer, date, 0, nil)
+		if err != nil {
+			return 0, err
+		}
+		return math.Sin(coords.HourAngle), nil
+	}
+
+	var events []Event
+	roots, err := findRoots(elevation, start, end, constantStep(step), tolerance)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range roots {
+		code := EventCodeSet
+		if r.increasing {
+			code = EventCodeRise
+		}
+		events = append(events, Event{Code: code, JulianDate: r.date})
+	}
+
+	roots, err = findRoots(hourAngle, start, end, constantStep(step), tolerance)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range roots {
+		// часовой угол проходит 0 при возрастании синуса и π при убывании
+		code := EventCodeLowerTransit
+		if r.increasing {
+			code = EventCodeUpperTransit
+		}
+		events = append(events, Event{Code: code, JulianDate: r.date})
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].JulianDate < events[j].JulianDate
+	})
+	return events, nil
+}
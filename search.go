@@ -0,0 +1,96 @@
+package rightround
+
+import "math"
+
+// root ноль функции: момент времени и направление изменения знака.
+type root struct {
+	date       float64
+	increasing bool
+}
+
+// findRoots находит нули функции f на отрезке [start, end]: отрезок просматривается с шагом step(date),
+// найденные смены знака уточняются методом Брента до точности tolerance.
+func findRoots(f func(date float64) (float64, error), start, end float64, step func(date float64) float64, tolerance float64) ([]root, error) {
+	var roots []root
+	left := start
+	leftValue, err := f(left)
+	if err != nil {
+		return nil, err
+	}
+	for left < end {
+		right := math.Min(left+math.Max(step(left), tolerance), end)
+		rightValue, err := f(right)
+		if err != nil {
+			return nil, err
+		}
+		if leftValue == 0 {
+			// ноль в узле сетки учитывается один раз - как левая граница отрезка
+			if rightValue != 0 {
+				roots = append(roots, root{date: left, increasing: rightValue > 0})
+			}
+		} else if leftValue*rightValue < 0 {
+			date, err := solveBrent(f, left, right, leftValue, rightValue, tolerance)
+			if err != nil {
+				return nil, err
+			}
+			roots = append(roots, root{date: date, increasing: rightValue > leftValue})
+		}
+		left, leftValue = right, rightValue
+	}
+	return roots, nil
+}
+
+// constantStep возвращает функцию постоянного шага поиска.
+func constantStep(step float64) func(date float64) float64 {
+	return func(float64) float64 {
+		return step
+	}
+}
+
+// solveBrent уточняет ноль функции на отрезке [a, b] со сменой знака методом Брента.
+func solveBrent(f func(date float64) (float64, error), a, b, fa, fb, tolerance float64) (float64, error) {
+	if math.Abs(fa) < math.Abs(fb) {
+		a, b, fa, fb = b, a, fb, fa
+	}
+	c, fc := a, fa
+	d := b - a
+	bisected := true
+	for i := 0; i < 100; i++ {
+		if fb == 0 || math.Abs(b-a) <= tolerance {
+			return b, nil
+		}
+		var s float64
+		if fa != fc && fb != fc {
+			// обратная квадратичная интерполяция
+			s = a*fb*fc/((fa-fb)*(fa-fc)) + b*fa*fc/((fb-fa)*(fb-fc)) + c*fa*fb/((fc-fa)*(fc-fb))
+		} else {
+			// метод секущих
+			s = b - fb*(b-a)/(fb-fa)
+		}
+		mid := (3*a + b) / 4
+		if (s-mid)*(s-b) >= 0 ||
+			(bisected && math.Abs(s-b) >= math.Abs(b-c)/2) ||
+			(!bisected && math.Abs(s-b) >= math.Abs(c-d)/2) ||
+			(bisected && math.Abs(b-c) < tolerance) ||
+			(!bisected && math.Abs(c-d) < tolerance) {
+			s = (a + b) / 2
+			bisected = true
+		} else {
+			bisected = false
+		}
+		fs, err := f(s)
+		if err != nil {
+			return 0, err
+		}
+		d, c, fc = c, b, fb
+		if fa*fs < 0 {
+			b, fb = s, fs
+		} else {
+			a, fa = s, fs
+		}
+		if math.Abs(fa) < math.Abs(fb) {
+			a, b, fa, fb = b, a, fb, fa
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,71 @@
+package rightround
+
+import "math"
+
+// Типы явлений, связанных с годичным движением Солнца.
+const (
+	EventCodeMarchEquinox     = 13 // мартовское (весеннее) равноденствие
+	EventCodeJuneSolstice     = 14 // июньское (летнее) солнцестояние
+	EventCodeSeptemberEquinox = 15 // сентябрьское (осеннее) равноденствие
+	EventCodeDecemberSolstice = 16 // декабрьское (зимнее) солнцестояние
+	EventCodeSolarLongitude   = 17 // прохождение Солнцем заданной долготы
+)
+
+// solarLongitudeSearchStep шаг поиска прохождений Солнцем заданной долготы, в сутках.
+const solarLongitudeSearchStep = 10.0
+
+// FindSolarLongitude находит моменты, когда видимая геоцентрическая эклиптическая долгота Солнца
+// (относительно истинного равноденствия даты) равна longitude (в радианах), в интервале [start, end].
+// Границы интервала и моменты событий задаются в шкале времени timeScale.
+func (e *Ephemeris) FindSolarLongitude(longitude, start, end float64, timeScale int) ([]Event, error) {
+	start, end, err := e.toTDBInterval(start, end, timeScale)
+	if err != nil {
+		return nil, err
+	}
+	events, err := e.findSolarLongitude(longitude, EventCodeSolarLongitude, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return e.convertEvents(events, timeScale)
+}
+
+// findSolarLongitude находит моменты прохождения Солнцем долготы longitude в интервале дат TDB [start, end].
+func (e *Ephemeris) findSolarLongitude(longitude float64, code int, start, end float64) ([]Event, error) {
+	f := func(date float64) (float64, error) {
+		value, _, _, err := e.CalculateApparentEclipticCoords(EphemerisSun, date, 0)
+		if err != nil {
+			return 0, err
+		}
+		return normalizeAngle(value - longitude), nil
+	}
+	roots, err := findRoots(f, start, end, constantStep(solarLongitudeSearchStep), defaultSearchTolerance)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	for _, r := range roots {
+		// долгота Солнца всегда возрастает, убывающие корни соответствуют скачку разности на 2π
+		if r.increasing {
+			events = append(events, Event{Code: code, JulianDate: r.date})
+		}
+	}
+	return events, nil
+}
+
+// FindSeasons находит моменты равноденствий и солнцестояний в заданном году (по календарю шкалы timeScale).
+// Моменты событий задаются в шкале времени timeScale.
+func (e *Ephemeris) FindSeasons(year int, timeScale int) ([]Event, error) {
+	start, end, err := e.toTDBInterval(calendarToJulianDate(year, 1, 1, 0), calendarToJulianDate(year+1, 1, 1, 0), timeScale)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	for i, code := range []int{EventCodeMarchEquinox, EventCodeJuneSolstice, EventCodeSeptemberEquinox, EventCodeDecemberSolstice} {
+		found, err := e.findSolarLongitude(float64(i)*math.Pi/2, code, start, end)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, found...)
+	}
+	return e.convertEvents(events, timeScale)
+}
@@ -0,0 +1,41 @@
+package rightround
+
+// SegmentInfo описание сегмента загруженного файла SPK или PCK.
+type SegmentInfo struct {
+	FileType       int     // FormatSPK или FormatPCK
+	Object         int     // объект: тело, система координат PCK или разность шкал времени
+	Basis          int     // центр (SPK) или базовая система координат углов (PCK)
+	Frame          int     // код системы координат сегмента
+	Representation int     // тип сегмента
+	Start          float64 // начало интервала дат (TDB)
+	End            float64 // конец интервала дат (TDB)
+	Degree         int     // степень полиномов (для типов 2, 3, 9, 13 и 20)
+	Intervals      int     // количество интервалов (для типов 5, 9 и 13 - количество состояний)
+}
+
+// Segments возвращает описания загруженных сегментов в порядке загрузки.
+func (e *Ephemeris) Segments() []SegmentInfo {
+	segments := make([]SegmentInfo, 0, len(e.theories))
+	for _, t := range e.theories {
+		span := t.span()
+		info := SegmentInfo{
+			FileType:       t.fileType,
+			Object:         t.object,
+			Basis:          t.basis,
+			Frame:          t.basis,
+			Representation: t.representation,
+			Start:          span.Start,
+			End:            span.End,
+			Degree:         t.polynomialDegree,
+			Intervals:      t.nIntervals,
+		}
+		if t.fileType == FormatSPK {
+			info.Frame = int(t.segment.iParameters[2])
+		}
+		if t.epochs != nil {
+			info.Intervals = len(t.epochs)
+		}
+		segments = append(segments, info)
+	}
+	return segments
+}
@@ -0,0 +1,251 @@
+package rightround
+
+import (
+	"fmt"
+	"math"
+)
+
+// Ellipsoid трёхосный эллипсоид, задающий форму тела: полуоси A и B лежат в плоскости экватора
+// (A - в направлении нулевого меридиана), C - полярная полуось. Значения в километрах.
+type Ellipsoid struct {
+	A, B, C float64
+}
+
+// NewEllipsoid создаёт эллипсоид вращения по экваториальному радиусу (в километрах) и сжатию.
+func NewEllipsoid(equatorialRadius, flattening float64) Ellipsoid {
+	return Ellipsoid{A: equatorialRadius, B: equatorialRadius, C: equatorialRadius * (1 - flattening)}
+}
+
+// Общеземные эллипсоиды.
+var (
+	EllipsoidWGS84 = NewEllipsoid(6378.137, 1/298.257223563)
+	EllipsoidGRS80 = NewEllipsoid(6378.137, 1/298.257222101)
+)
+
+// GeodeticToCartesian возвращает координаты в связанной с телом системе координат для точки
+// с геодезическими широтой и долготой (в радианах) и высотой над эллипсоидом (в километрах).
+func (e Ellipsoid) GeodeticToCartesian(latitude, longitude, height float64) Coords {
+	sinLat, cosLat := math.Sincos(latitude)
+	sinLon, cosLon := math.Sincos(longitude)
+	normal := Coords{X: cosLat * cosLon, Y: cosLat * sinLon, Z: sinLat}
+	// точка поверхности эллипсоида, в которой нормаль совпадает с заданной
+	scale := math.Sqrt(e.A*e.A*normal.X*normal.X + e.B*e.B*normal.Y*normal.Y + e.C*e.C*normal.Z*normal.Z)
+	return Coords{
+		X: e.A*e.A*normal.X/scale + height*normal.X,
+		Y: e.B*e.B*normal.Y/scale + height*normal.Y,
+		Z: e.C*e.C*normal.Z/scale + height*normal.Z,
+	}
+}
+
+// CartesianToGeodetic возвращает геодезические (планетографические) широту и восточную долготу (в радианах)
+// и высоту над эллипсоидом (в километрах) для точки, заданной в связанной с телом системе координат.
+// Широта определяется направлением нормали к поверхности в ближайшей точке эллипсоида.
+func (e Ellipsoid) CartesianToGeodetic(c Coords) (float64, float64, float64) {
+	point := [3]float64{c.X, c.Y, c.Z}
+	squares := [3]float64{e.A * e.A, e.B * e.B, e.C * e.C}
+
+	// нижняя граница параметра t определяется наименьшей полуосью среди ненулевых компонент
+	lower := math.Inf(1)
+	smallest := 0
+	for i := range squares {
+		if point[i] != 0 && squares[i] < lower {
+			lower = squares[i]
+		}
+		if squares[i] < squares[smallest] {
+			smallest = i
+		}
+	}
+
+	var t float64
+	var q [3]float64
+	if math.IsInf(lower, 1) {
+		// центр эллипсоида
+		t = -squares[smallest]
+		q[smallest] = math.Sqrt(squares[smallest])
+	} else if point[smallest] == 0 && squares[smallest] < lower {
+		// точка внутри эллипсоида в плоскости, перпендикулярной наименьшей полуоси:
+		// ближайшая точка может лежать вне этой плоскости
+		t = -squares[smallest]
+		q = nearestSurfacePoint(point, squares, t)
+		sum := 0.0
+		for i := range q {
+			if i != smallest {
+				sum += q[i] * q[i] / squares[i]
+			}
+		}
+		if sum <= 1 {
+			q[smallest] = math.Sqrt(squares[smallest] * (1 - sum))
+		} else {
+			t, q = solveSurfaceParameter(point, squares, -lower)
+		}
+	} else {
+		t, q = solveSurfaceParameter(point, squares, -lower)
+	}
+
+	normal := Coords{X: q[0] / squares[0], Y: q[1] / squares[1], Z: q[2] / squares[2]}
+	height := Coords{X: point[0] - q[0], Y: point[1] - q[1], Z: point[2] - q[2]}.length()
+	if t < 0 {
+		height = -height
+	}
+	return math.Asin(math.Max(-1, math.Min(1, normal.Z/normal.length()))), math.Atan2(normal.Y, normal.X), height
+}
+
+// nearestSurfacePoint возвращает точку поверхности эллипсоида q_i = a_i^2 p_i / (a_i^2 + t),
+// соответствующую параметру t.
+func nearestSurfacePoint(point, squares [3]float64, t float64) [3]float64 {
+	var q [3]float64
+	for i := range q {
+		if point[i] != 0 {
+			q[i] = squares[i] * point[i] / (squares[i] + t)
+		}
+	}
+	return q
+}
+
+// solveSurfaceParameter находит параметр t ближайшей точки поверхности эллипсоида,
+// являющийся корнем уравнения sum (a_i p_i / (a_i^2 + t))^2 = 1, методом Ньютона с бисекцией.
+func solveSurfaceParameter(point, squares [3]float64, lower float64) (float64, [3]float64) {
+	maxSquare := math.Max(squares[0], math.Max(squares[1], squares[2]))
+	upper := math.Sqrt(maxSquare) * math.Sqrt(point[0]*point[0]+point[1]*point[1]+point[2]*point[2])
+	function := func(t float64) (float64, float64) {
+		value, derivative := -1.0, 0.0
+		for i := range squares {
+			ratio := math.Sqrt(squares[i]) * point[i] / (squares[i] + t)
+			value += ratio * ratio
+			derivative -= 2 * ratio * ratio / (squares[i] + t)
+		}
+		return value, derivative
+	}
+
+	t := 0.0
+	if t <= lower || t >= upper {
+		t = (lower + upper) / 2
+	}
+	for i := 0; i < 200; i++ {
+		value, derivative := function(t)
+		if value > 0 {
+			lower = t
+		} else {
+			upper = t
+		}
+		next := t - value/derivative
+		if next <= lower || next >= upper || math.IsNaN(next) {
+			next = (lower + upper) / 2
+		}
+		converged := math.Abs(next-t) <= 1e-15*math.Max(1, math.Abs(t))
+		t = next
+		if converged {
+			break
+		}
+	}
+	return t, nearestSurfacePoint(point, squares, t)
+}
+
+// PlanetocentricToCartesian возвращает координаты точки с планетоцентрическими широтой и восточной долготой
+// (в радианах) на расстоянии radius от центра тела.
+func PlanetocentricToCartesian(latitude, longitude, radius float64) Coords {
+	sinLat, cosLat := math.Sincos(latitude)
+	sinLon, cosLon := math.Sincos(longitude)
+	return Coords{X: radius * cosLat * cosLon, Y: radius * cosLat * sinLon, Z: radius * sinLat}
+}
+
+// CartesianToPlanetocentric возвращает планетоцентрические широту и восточную долготу (в радианах)
+// и расстояние от центра тела.
+func CartesianToPlanetocentric(c Coords) (float64, float64, float64) {
+	radius := c.length()
+	if radius == 0 {
+		return 0, 0, 0
+	}
+	return math.Asin(c.Z / radius), math.Atan2(c.Y, c.X), radius
+}
+
+// bodyRadii встроенные радиусы тел (в километрах) по pck00010.tpc.
+var bodyRadii = map[int]Coords{
+	EphemerisSun:         {X: 696000, Y: 696000, Z: 696000},
+	EphemerisMercuryBody: {X: 2439.7, Y: 2439.7, Z: 2439.7},
+	EphemerisVenusBody:   {X: 6051.8, Y: 6051.8, Z: 6051.8},
+	EphemerisEarth:       {X: 6378.1366, Y: 6378.1366, Z: 6356.7519},
+	EphemerisMoon:        {X: 1737.4, Y: 1737.4, Z: 1737.4},
+	EphemerisMarsBody:    {X: 3396.19, Y: 3396.19, Z: 3376.20},
+	EphemerisJupiterBody: {X: 71492, Y: 71492, Z: 66854},
+	EphemerisSaturnBody:  {X: 60268, Y: 60268, Z: 54364},
+	EphemerisUranusBody:  {X: 25559, Y: 25559, Z: 24973},
+	EphemerisNeptuneBody: {X: 24764, Y: 24764, Z: 24341},
+	EphemerisPlutoBody:   {X: 1195, Y: 1195, Z: 1195},
+}
+
+// BodyEllipsoid возвращает эллипсоид тела по радиусам из загруженных текстовых PCK,
+// а при их отсутствии - по встроенным значениям.
+func (e *Ephemeris) BodyEllipsoid(body int) (Ellipsoid, error) {
+	radii, err := e.BodyRadii(body)
+	if err != nil {
+		var ok bool
+		if radii, ok = bodyRadii[bodyCode(body)]; !ok {
+			return Ellipsoid{}, err
+		}
+	}
+	return Ellipsoid{A: radii.X, B: radii.Y, C: radii.Z}, nil
+}
+
+// isPlanetographicLongitudeEast определяет, отсчитывается ли планетографическая долгота тела к востоку.
+// Как и в SPICE, для Земли, Луны и Солнца долгота восточная, для остальных тел - противоположна направлению вращения;
+// переменная BODY<код>_PGR_POSITIVE_LON текстового ядра переопределяет это правило.
+func (e *Ephemeris) isPlanetographicLongitudeEast(body int) (bool, error) {
+	body = bodyCode(body)
+	if sense, ok := e.pool.Strings(fmt.Sprintf("BODY%d_PGR_POSITIVE_LON", body)); ok && len(sense) == 1 {
+		switch sense[0] {
+		case "EAST":
+			return true, nil
+		case "WEST":
+			return false, nil
+		}
+		return false, fmt.Errorf("bad planetographic longitude sense %q for body %d", sense[0], body)
+	}
+	if body == EphemerisEarth || body == EphemerisMoon || body == EphemerisSun {
+		return true, nil
+	}
+	elements, err := e.RotationalElements(body)
+	if err != nil {
+		return false, err
+	}
+	// при ретроградном вращении долгота восточная
+	return len(elements.PrimeMeridian) > 1 && elements.PrimeMeridian[1] < 0, nil
+}
+
+// CartesianToPlanetographic возвращает планетографические широту и долготу (в радианах, долгота от 0 до 2π
+// в принятом для тела направлении) и высоту над эллипсоидом тела для точки в связанной с телом системе координат.
+func (e *Ephemeris) CartesianToPlanetographic(body int, c Coords) (float64, float64, float64, error) {
+	ellipsoid, err := e.BodyEllipsoid(body)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	east, err := e.isPlanetographicLongitudeEast(body)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	latitude, longitude, height := ellipsoid.CartesianToGeodetic(c)
+	if !east {
+		longitude = -longitude
+	}
+	if longitude < 0 {
+		longitude += 2 * math.Pi
+	}
+	return latitude, longitude, height, nil
+}
+
+// PlanetographicToCartesian возвращает координаты в связанной с телом системе координат для точки
+// с планетографическими широтой, долготой (в радианах) и высотой над эллипсоидом тела.
+func (e *Ephemeris) PlanetographicToCartesian(body int, latitude, longitude, height float64) (Coords, error) {
+	ellipsoid, err := e.BodyEllipsoid(body)
+	if err != nil {
+		return Coords{}, err
+	}
+	east, err := e.isPlanetographicLongitudeEast(body)
+	if err != nil {
+		return Coords{}, err
+	}
+	if !east {
+		longitude = -longitude
+	}
+	return ellipsoid.GeodeticToCartesian(latitude, longitude, height), nil
+}
@@ -0,0 +1,217 @@
+package rightround
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Параметры подбора чебышёвских сегментов по умолчанию.
+const (
+	defaultFitDegree = 12
+	// defaultFitTolerance допустимая погрешность положения по умолчанию, км. Меньшее значение (например, 1 мм)
+	// недостижимо для внешних планет: шаг чисел double на расстоянии 4.5e9 км составляет около 1e-6 км
+	defaultFitTolerance = 1e-3
+	maxFitIntervals     = 1 << 20
+	// относительная погрешность вычисления состояний, ниже которой прекращение убывания погрешности
+	// подбора объясняется шумом округления, а не недостаточным дроблением интервалов
+	fitNoiseLevel = 1e-11
+)
+
+// StateFunc возвращает положение (км) и скорость (км/с) объекта на юлианскую дату TDB (date1 + date2).
+type StateFunc func(date1, date2 float64) (Coords, Coords, error)
+
+// SegmentSpec параметры создаваемого сегмента SPK.
+type SegmentSpec struct {
+	Object, Center    int
+	Frame             int     // система координат (0 - J2000)
+	Representation    int     // тип сегмента: 2 (положения) или 3 (положения и скорости)
+	Start, End        float64 // интервал юлианских дат TDB
+	Degree            int     // степень полиномов (0 - по умолчанию)
+	Tolerance         float64 // допустимая погрешность положения, км (0 - по умолчанию)
+	VelocityTolerance float64 // допустимая погрешность скорости для типа 3, км/с (0 - не проверяется)
+	Name              string  // имя сегмента
+}
+
+// SPKWriter создаёт файл SPK. Сегменты накапливаются в памяти и записываются методом Save.
+type SPKWriter struct {
+	path         string
+	internalName string
+	comments     []string
+	segments     []dafWriterSegment
+}
+
+// NewSPKWriter создаёт SPKWriter для файла path с внутренним именем internalName.
+func NewSPKWriter(path, internalName string) *SPKWriter {
+	return &SPKWriter{path: path, internalName: internalName}
+}
+
+// AddComment добавляет строки в область комментариев файла.
+func (w *SPKWriter) AddComment(lines ...string) {
+	w.comments = append(w.comments, lines...)
+}
+
+// Save записывает файл SPK.
+func (w *SPKWriter) Save() error {
+	return writeDAF(w.path, "DAF/SPK", 2, 6, w.internalName, w.comments, w.segments)
+}
+
+// secondsFromJ2000 переводит юлианскую дату в секунды от J2000.
+func secondsFromJ2000(julianDate float64) float64 {
+	return (julianDate - julianDate2000) * secondsInDay
+}
+
+// fitChebyshev возвращает коэффициенты интерполяционного полинома Чебышева степени n-1
+// по значениям в узлах Чебышева.
+func fitChebyshev(values []float64) []float64 {
+	n := len(values)
+	coefficients := make([]float64, n)
+	for j := range coefficients {
+		sum := 0.0
+		for k, value := range values {
+			sum += value * math.Cos(math.Pi*float64(j)*(float64(k)+0.5)/float64(n))
+		}
+		coefficients[j] = 2 * sum / float64(n)
+	}
+	coefficients[0] /= 2
+	return coefficients
+}
+
+// evalChebyshev вычисляет значение ряда Чебышева в точке x из [-1, 1].
+func evalChebyshev(coefficients []float64, x float64) float64 {
+	polynomials := calcChebyshevPolynomials(len(coefficients), x)
+	value := 0.0
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		value += polynomials[i] * coefficients[i]
+	}
+	return value
+}
+
+// fitInterval подбирает коэффициенты для одного интервала, начинающегося через offset суток
+// после начала сегмента и имеющего длину length суток, и возвращает запись сегмента
+// и наибольшие погрешности положения (км) и скорости (км/с, для типа 3).
+func fitInterval(spec SegmentSpec, states StateFunc, offset, length float64, components int) ([]float64, float64, float64, error) {
+	n := spec.Degree + 1
+	values := make([][]float64, components)
+	for c := range values {
+		values[c] = make([]float64, n)
+	}
+	for k := 0; k < n; k++ {
+		x := math.Cos(math.Pi * (float64(k) + 0.5) / float64(n))
+		coords, velocity, err := states(spec.Start, offset+length*(x+1)/2)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		state := [6]float64{coords.X, coords.Y, coords.Z, velocity.X, velocity.Y, velocity.Z}
+		for c := range values {
+			values[c][k] = state[c]
+		}
+	}
+	record := []float64{secondsFromJ2000(spec.Start) + (offset+length/2)*secondsInDay, length / 2 * secondsInDay}
+	coefficients := make([][]float64, components)
+	for c := range values {
+		coefficients[c] = fitChebyshev(values[c])
+		record = append(record, coefficients[c]...)
+	}
+
+	// проверка в серединах между узлами и на концах интервала
+	checks := []float64{-1, 1}
+	for k := 0; k+1 < n; k++ {
+		a := math.Cos(math.Pi * (float64(k) + 0.5) / float64(n))
+		b := math.Cos(math.Pi * (float64(k) + 1.5) / float64(n))
+		checks = append(checks, (a+b)/2)
+	}
+	var positionError, velocityError float64
+	for _, x := range checks {
+		coords, velocity, err := states(spec.Start, offset+length*(x+1)/2)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		fitted := Coords{X: evalChebyshev(coefficients[0], x), Y: evalChebyshev(coefficients[1], x), Z: evalChebyshev(coefficients[2], x)}
+		positionError = math.Max(positionError, fitted.sub(coords).length())
+		if components == 6 {
+			fitted = Coords{X: evalChebyshev(coefficients[3], x), Y: evalChebyshev(coefficients[4], x), Z: evalChebyshev(coefficients[5], x)}
+			velocityError = math.Max(velocityError, fitted.sub(velocity).length())
+		}
+	}
+	return record, positionError, velocityError, nil
+}
+
+// AddChebyshevSegment подбирает сегмент типа 2 или 3, описывающий состояния states на интервале
+// [spec.Start, spec.End] с заданной погрешностью, и добавляет его в файл. Интервалы сегмента
+// имеют одинаковую длину; их количество удваивается, пока погрешность не станет допустимой.
+func (w *SPKWriter) AddChebyshevSegment(spec SegmentSpec, states StateFunc) error {
+	components := 3
+	switch spec.Representation {
+	case representationPositionOnly:
+	case representationPositionVelocity:
+		components = 6
+	default:
+		return fmt.Errorf("%w representation (%d)", ErrUnsupported, spec.Representation)
+	}
+	if spec.End <= spec.Start {
+		return errors.New("bad segment interval")
+	}
+	if spec.Degree == 0 {
+		spec.Degree = defaultFitDegree
+	}
+	if spec.Degree < 1 || spec.Degree > maxPolynomialDegree {
+		return fmt.Errorf("bad polynomial degree (%d)", spec.Degree)
+	}
+	if spec.Tolerance <= 0 {
+		spec.Tolerance = defaultFitTolerance
+	}
+	if spec.Frame == 0 {
+		spec.Frame = frameJ2000
+	}
+
+	// уровни шума положения и скорости, ниже которых погрешность подбора не убывает из-за округления
+	startCoords, startVelocity, err := states(spec.Start, 0)
+	if err != nil {
+		return err
+	}
+	positionNoise := fitNoiseLevel * startCoords.length()
+	velocityNoise := fitNoiseLevel * startVelocity.length()
+	checkVelocity := components == 6 && spec.VelocityTolerance > 0
+	previousRatio := math.Inf(1)
+	for nIntervals := 1; nIntervals <= maxFitIntervals; nIntervals *= 2 {
+		length := (spec.End - spec.Start) / float64(nIntervals)
+		var data []float64
+		// наибольшие погрешности по всем интервалам уровня дробления
+		var positionError, velocityError float64
+		for i := 0; i < nIntervals; i++ {
+			record, intervalPositionError, intervalVelocityError, err := fitInterval(spec, states, float64(i)*length, length, components)
+			if err != nil {
+				return err
+			}
+			positionError = math.Max(positionError, intervalPositionError)
+			velocityError = math.Max(velocityError, intervalVelocityError)
+			data = append(data, record...)
+		}
+		ratio := positionError / spec.Tolerance
+		if checkVelocity {
+			ratio = math.Max(ratio, velocityError/spec.VelocityTolerance)
+		}
+		if ratio > 1 {
+			// погрешность перестала убывать при дроблении интервалов, и каждая недостигнутая
+			// компонента находится на уровне своего шума
+			positionNoisy := positionError <= spec.Tolerance || positionError < positionNoise
+			velocityNoisy := !checkVelocity || velocityError <= spec.VelocityTolerance || velocityError < velocityNoise
+			if ratio >= previousRatio && positionNoisy && velocityNoisy {
+				return fmt.Errorf("tolerance is not reached: fitting error stopped decreasing at %g of tolerance", ratio)
+			}
+			previousRatio = ratio
+			continue
+		}
+		rSize := 2 + components*(spec.Degree+1)
+		data = append(data, secondsFromJ2000(spec.Start), length*secondsInDay, float64(rSize), float64(nIntervals))
+		w.segments = append(w.segments, dafWriterSegment{
+			name:        spec.Name,
+			dParameters: []float64{secondsFromJ2000(spec.Start), secondsFromJ2000(spec.End)},
+			iParameters: []int32{int32(spec.Object), int32(spec.Center), int32(spec.Frame), int32(spec.Representation)},
+			data:        data,
+		})
+		return nil
+	}
+	return fmt.Errorf("tolerance is not reached with %d intervals", maxFitIntervals)
+}
@@ -0,0 +1,76 @@
+package rightround
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testOrbit возвращает состояние на круговой орбите с колебанием по оси Z (км, км/с).
+func testOrbit(date1, date2 float64) (Coords, Coords, error) {
+	const radius, period = 1.5e8, 365.25
+	omega := 2 * math.Pi / period
+	t := (date1 - julianDate2000) + date2
+	sin, cos := math.Sincos(omega * t)
+	sin2, cos2 := math.Sincos(2 * omega * t)
+	coords := Coords{X: radius * cos, Y: radius * sin, Z: 0.1 * radius * sin2}
+	velocity := Coords{X: -radius * omega * sin, Y: radius * omega * cos, Z: 0.2 * radius * omega * cos2}
+	return coords, velocity.scale(1.0 / secondsInDay), nil
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "rightround")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+func TestSPKWriterRoundTrip(t *testing.T) {
+	path := filepath.Join(tempDir(t), "orbit.bsp")
+	writer := NewSPKWriter(path, "test orbit")
+	writer.AddComment("test")
+	const start, end = 2451545.0, 2451545.0 + 400
+	for _, spec := range []SegmentSpec{
+		{Object: 1000, Center: EphemerisSunSystem, Representation: representationPositionOnly, Start: start, End: end, Tolerance: 1e-4},
+		{Object: 1001, Center: EphemerisSunSystem, Representation: representationPositionVelocity, Start: start, End: end, Tolerance: 1e-4, VelocityTolerance: 1e-9},
+	} {
+		if err := writer.AddChebyshevSegment(spec, testOrbit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	ephemeris := NewEphemeris()
+	if err := ephemeris.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []int{1000, 1001} {
+		for date := start; date <= end; date += 3.7 {
+			coords, velocity, err := ephemeris.CalculateRectangularCoordsAndScaleVelocity(object, EphemerisSunSystem, date, 0, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedCoords, expectedVelocity, _ := testOrbit(date, 0)
+			if diff := coords.sub(expectedCoords).length(); diff > 1e-4 {
+				t.Fatalf("object %d at %v: position error %g km", object, date, diff)
+			}
+			if diff := velocity.sub(expectedVelocity).length(); diff > 1e-8 {
+				t.Fatalf("object %d at %v: velocity error %g km/s", object, date, diff)
+			}
+		}
+	}
+}
+
+func TestSPKWriterUnreachableTolerance(t *testing.T) {
+	writer := NewSPKWriter(filepath.Join(tempDir(t), "orbit.bsp"), "test orbit")
+	spec := SegmentSpec{Object: 1000, Representation: representationPositionOnly, Start: 2451545, End: 2451545 + 10, Tolerance: 1e-12}
+	if err := writer.AddChebyshevSegment(spec, testOrbit); err == nil {
+		t.Fatal("tolerance below rounding noise is reported as reached")
+	}
+}
@@ -0,0 +1,162 @@
+package rightround
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// SubsetOptions параметры выборки сегментов SPK для записи в новый файл.
+type SubsetOptions struct {
+	Objects      []int    // объекты, сегменты которых включаются в файл (пусто - все объекты)
+	Start, End   float64  // интервал юлианских дат TDB (0 - без ограничения)
+	InternalName string   // внутреннее имя создаваемого файла
+	Comments     []string // комментарии создаваемого файла
+}
+
+// type5DirectorySize количество моментов между элементами каталога в сегментах типов 5, 9 и 13.
+const type5DirectorySize = 100
+
+// WriteSPK записывает в новый файл SPK сегменты загруженных файлов для выбранных объектов, обрезанные
+// до заданного интервала дат по границам записей. Сегменты записываются в порядке загрузки, так что
+// правило приоритета последних загруженных сегментов сохраняется; сегменты, полностью перекрытые
+// в пределах интервала более поздними сегментами того же объекта и центра, пропускаются.
+func (e *Ephemeris) WriteSPK(path string, options SubsetOptions) error {
+	objects := make(map[int]bool)
+	for _, object := range options.Objects {
+		objects[object] = true
+	}
+	bounds := Interval{Start: options.Start, End: options.End}
+	if bounds.Start == 0 {
+		bounds.Start = math.Inf(-1)
+	}
+	if bounds.End == 0 {
+		bounds.End = math.Inf(1)
+	}
+	if bounds.End < bounds.Start {
+		return errors.New("bad subset interval")
+	}
+
+	var segments []dafWriterSegment
+	for i, theory := range e.theories {
+		if theory.fileType != FormatSPK || (len(objects) > 0 && !objects[theory.object]) {
+			continue
+		}
+		span := NewWindow(theory.span()).Intersection(Window{bounds})
+		if span.Measure() == 0 {
+			continue
+		}
+		// части сегмента, перекрытые более поздними сегментами
+		var later []Interval
+		for _, next := range e.theories[i+1:] {
+			if next.fileType == FormatSPK && next.object == theory.object && next.basis == theory.basis {
+				later = append(later, next.span())
+			}
+		}
+		if span.Difference(NewWindow(later...)).Measure() == 0 {
+			continue
+		}
+		segment, err := theory.subset(span[0].Start, span[0].End)
+		if err != nil {
+			return err
+		}
+		segments = append(segments, segment)
+	}
+	if len(segments) == 0 {
+		return errors.New("no segments match subset options")
+	}
+	return writeDAF(path, "DAF/SPK", 2, 6, options.InternalName, options.Comments, segments)
+}
+
+// subset возвращает сегмент теории, обрезанный до интервала юлианских дат [start, end]
+// по границам записей.
+func (t *Theory) subset(start, end float64) (dafWriterSegment, error) {
+	segment := dafWriterSegment{
+		dParameters: []float64{secondsFromJ2000(start), secondsFromJ2000(end)},
+		iParameters: append([]int32(nil), t.segment.iParameters...),
+	}
+	switch t.representation {
+	case representationPositionOnly, representationPositionVelocity, representationVelocityOnly:
+		origin := t.julianDays + t.julianDaysMod
+		first := int(math.Floor((start - origin) / t.intervalLen))
+		last := int(math.Ceil((end-origin)/t.intervalLen)) - 1
+		if first < 0 {
+			first = 0
+		}
+		if last >= t.nIntervals {
+			last = t.nIntervals - 1
+		}
+		if last < first {
+			last = first
+		}
+		data, err := t.read(first*t.rSize, (last-first+1)*t.rSize)
+		if err != nil {
+			return dafWriterSegment{}, err
+		}
+		trailerLength := 4
+		if t.representation == representationVelocityOnly {
+			trailerLength = 7
+		}
+		trailer, err := t.read(int(t.segment.length)-trailerLength, trailerLength)
+		if err != nil {
+			return dafWriterSegment{}, err
+		}
+		if t.representation == representationVelocityOnly {
+			// начальная дата задана целой и дробной частями юлианской даты, длина интервала - в сутках
+			trailer[3] += float64(first) * trailer[4]
+			trailer[6] = float64(last - first + 1)
+		} else {
+			trailer[0] += float64(first) * trailer[1]
+			trailer[3] = float64(last - first + 1)
+		}
+		segment.data = append(data, trailer...)
+
+	case representationDiscreteStates, representationLagrange, representationHermite:
+		n := len(t.epochs)
+		from, to := secondsFromJ2000(start), secondsFromJ2000(end)
+		// сохраняются состояния внутри интервала и соседние с каждой стороны: одно для типа 5
+		// и окно интерполяции для типов 9 и 13, чтобы результаты внутри интервала не изменились
+		margin := 1
+		if t.representation != representationDiscreteStates {
+			_, margin = t.interpolationWindow(from)
+		}
+		first, last := 0, n-1
+		for first < n-1 && t.epochs[first+1] <= from {
+			first++
+		}
+		for last > 0 && t.epochs[last-1] >= to {
+			last--
+		}
+		first -= margin - 1
+		last += margin - 1
+		if first < 0 {
+			first = 0
+		}
+		if last > n-1 {
+			last = n - 1
+		}
+		count := last - first + 1
+		states, err := t.readStates(first, count)
+		if err != nil {
+			return dafWriterSegment{}, err
+		}
+		epochs := t.epochs[first : last+1]
+		data := append(append([]float64(nil), states...), epochs...)
+		for i := type5DirectorySize; i <= count-1; i += type5DirectorySize {
+			data = append(data, epochs[i-1])
+		}
+		switch t.representation {
+		case representationDiscreteStates:
+			data = append(data, t.gm)
+		case representationLagrange:
+			data = append(data, float64(t.polynomialDegree))
+		case representationHermite:
+			data = append(data, float64((t.polynomialDegree+1)/2-1))
+		}
+		segment.data = append(data, float64(count))
+
+	default:
+		return dafWriterSegment{}, fmt.Errorf("%w representation (%d)", ErrUnsupported, t.representation)
+	}
+	return segment, nil
+}
@@ -1,6 +1,9 @@
 package rightround
 
-import "math"
+import (
+	"errors"
+	"math"
+)
 
 type Theory struct {
 	segment            *DAFSegment
@@ -18,12 +21,56 @@ type Theory struct {
 	cachedInterval     int
 	cachedCoefficients []float64
 	fileType           int
+	// дискретные состояния (тип 5): моменты в секундах от J2000 и гравитационный параметр центра
+	epochs []float64
+	gm     float64
+	// чтение данных теорий, загруженных не из файла DAF (OEM, эфемериды JPL в собственном формате);
+	// данные располагаются так же, как в сегменте SPK соответствующего типа
+	reader func(start, length int) ([]float64, error)
+}
+
+// read читает length чисел данных сегмента теории, начиная с номера start.
+func (t *Theory) read(start, length int) ([]float64, error) {
+	if t.reader != nil {
+		return t.reader(start, length)
+	}
+	return t.segment.readRange(start, length)
+}
+
+// memoryReader возвращает функцию чтения данных сегмента, расположенных в памяти.
+func memoryReader(data []float64) func(start, length int) ([]float64, error) {
+	return func(start, length int) ([]float64, error) {
+		if start < 0 || start+length > len(data) {
+			return nil, errors.New("segment is out of data range")
+		}
+		return data[start : start+length], nil
+	}
+}
+
+// setSpan задаёт интервал теории, заданный секундами от J2000, как один интервал.
+func (t *Theory) setSpan(start, end float64) {
+	days := int(start / secondsInDay)
+	t.julianDays = julianDate2000 + float64(days)
+	t.julianDaysMod = (start - float64(days)*secondsInDay) / secondsInDay
+	t.intervalLen = (end - start) / secondsInDay
+	t.nIntervals = 1
+	t.dScale = 1
+	t.tScale = 1
+}
+
+// readStates возвращает count состояний (по 6 чисел), начиная с состояния first.
+func (t *Theory) readStates(first, count int) ([]float64, error) {
+	return t.read(6*first, 6*count)
 }
 
 // findInterval возвращает номер интервала, которому принадлежит юлианская дата, и число от -1 до 1, которое описывает позицию внутри интервала.
 func (t *Theory) findInterval(date1, date2 float64) (int, float64) {
 	diff := date1 + date2 - t.julianDays - t.julianDaysMod
 	interval := int(math.Floor(diff / t.intervalLen))
+	if interval == t.nIntervals && interval > 0 {
+		// конечная дата теории относится к последнему интервалу
+		interval--
+	}
 	diffInterval := diff - float64(interval)*t.intervalLen
 	return interval, (diffInterval/t.intervalLen)*2 - 1
 }
@@ -0,0 +1,154 @@
+package rightround
+
+import (
+	"fmt"
+	"math"
+)
+
+// ttMinusTAI разность шкал TT - TAI в секундах.
+const ttMinusTAI = 32.184
+
+// leapSeconds разности TAI - UTC в секундах, действующие с указанной юлианской даты UTC.
+var leapSeconds = []struct {
+	julianDate float64
+	seconds    float64
+}{
+	{2441317.5, 10}, // 1972-01-01
+	{2441499.5, 11}, // 1972-07-01
+	{2441683.5, 12}, // 1973-01-01
+	{2442048.5, 13}, // 1974-01-01
+	{2442413.5, 14}, // 1975-01-01
+	{2442778.5, 15}, // 1976-01-01
+	{2443144.5, 16}, // 1977-01-01
+	{2443509.5, 17}, // 1978-01-01
+	{2443874.5, 18}, // 1979-01-01
+	{2444239.5, 19}, // 1980-01-01
+	{2444786.5, 20}, // 1981-07-01
+	{2445151.5, 21}, // 1982-07-01
+	{2445516.5, 22}, // 1983-07-01
+	{2446247.5, 23}, // 1985-07-01
+	{2447161.5, 24}, // 1988-01-01
+	{2447892.5, 25}, // 1990-01-01
+	{2448257.5, 26}, // 1991-01-01
+	{2448804.5, 27}, // 1992-07-01
+	{2449169.5, 28}, // 1993-07-01
+	{2449534.5, 29}, // 1994-07-01
+	{2450083.5, 30}, // 1996-01-01
+	{2450630.5, 31}, // 1997-07-01
+	{2451179.5, 32}, // 1999-01-01
+	{2453736.5, 33}, // 2006-01-01
+	{2454832.5, 34}, // 2009-01-01
+	{2456109.5, 35}, // 2012-07-01
+	{2457204.5, 36}, // 2015-07-01
+	{2457754.5, 37}, // 2017-01-01
+}
+
+// taiMinusUTC возвращает разность TAI - UTC (в секундах) на юлианскую дату UTC.
+// До 1972 года используется начальное значение таблицы.
+func taiMinusUTC(julianDateUTC float64) float64 {
+	result := leapSeconds[0].seconds
+	for _, leap := range leapSeconds {
+		if julianDateUTC < leap.julianDate {
+			break
+		}
+		result = leap.seconds
+	}
+	return result
+}
+
+// ttToUTC переводит юлианскую дату из шкалы TT в шкалу UTC.
+func ttToUTC(julianDateTT float64) float64 {
+	utc := julianDateTT - (ttMinusTAI+taiMinusUTC(julianDateTT))/secondsInDay
+	// уточнение на случай секунды координации между датами TT и UTC
+	return julianDateTT - (ttMinusTAI+taiMinusUTC(utc))/secondsInDay
+}
+
+// Шкалы времени.
+const (
+	TimeScaleCodeTDB = 1 // барицентрическое динамическое время
+	TimeScaleCodeTT  = 2 // земное время
+	TimeScaleCodeUTC = 3 // всемирное координированное время
+	TimeScaleCodeUT1 = 4 // всемирное время
+)
+
+// timeScaleIterations количество итераций при обращении разностей шкал времени.
+const timeScaleIterations = 3
+
+// ttMinusTDB возвращает разность TT - TDB (в секундах) на юлианскую дату TDB: по загруженной теории,
+// а при её отсутствии - по главным периодическим членам (погрешность порядка 30 микросекунд).
+func (e *Ephemeris) ttMinusTDB(julianDateTDB float64) (float64, error) {
+	if theory := e.findTheory(func(t *Theory) bool {
+		return t.object == EphemerisCodeMinusTDB && t.isDateInRange(julianDateTDB, 0)
+	}); theory != nil {
+		coords, _, err := e.calculateByTheory(theory, julianDateTDB, 0, false, false)
+		return coords.X, err
+	}
+	// средняя аномалия Земли, радианы
+	g := (357.53 + 0.98560028*(julianDateTDB-julianDate2000)) * math.Pi / 180
+	return -(0.001657*math.Sin(g) + 0.000014*math.Sin(2*g)), nil
+}
+
+// toTT переводит юлианскую дату из заданной шкалы в шкалу TT.
+func (e *Ephemeris) toTT(date float64, scale int) (float64, error) {
+	switch scale {
+	case TimeScaleCodeTT:
+		return date, nil
+	case TimeScaleCodeTDB:
+		diff, err := e.ttMinusTDB(date)
+		return date + diff/secondsInDay, err
+	case TimeScaleCodeUTC:
+		tt := date + (ttMinusTAI+taiMinusUTC(date))/secondsInDay
+		return tt, nil
+	case TimeScaleCodeUT1:
+		tt := date
+		for i := 0; i < timeScaleIterations; i++ {
+			parameters, err := e.earthOrientation.parameters(tt)
+			if err != nil {
+				return 0, err
+			}
+			tt = date + (ttMinusTAI-parameters.ut1MinusAT)/secondsInDay
+		}
+		return tt, nil
+	}
+	return 0, fmt.Errorf("%w time scale: %d", ErrUnknown, scale)
+}
+
+// fromTT переводит юлианскую дату из шкалы TT в заданную шкалу.
+func (e *Ephemeris) fromTT(tt float64, scale int) (float64, error) {
+	switch scale {
+	case TimeScaleCodeTT:
+		return tt, nil
+	case TimeScaleCodeTDB:
+		tdb := tt
+		for i := 0; i < timeScaleIterations; i++ {
+			diff, err := e.ttMinusTDB(tdb)
+			if err != nil {
+				return 0, err
+			}
+			tdb = tt - diff/secondsInDay
+		}
+		return tdb, nil
+	case TimeScaleCodeUTC:
+		return ttToUTC(tt), nil
+	case TimeScaleCodeUT1:
+		parameters, err := e.earthOrientation.parameters(tt)
+		if err != nil {
+			return 0, err
+		}
+		return tt + (parameters.ut1MinusAT-ttMinusTAI)/secondsInDay, nil
+	}
+	return 0, fmt.Errorf("%w time scale: %d", ErrUnknown, scale)
+}
+
+// ConvertTimeScale переводит юлианскую дату из шкалы from в шкалу to.
+// Для UT1 используются загруженные параметры ориентации Земли, для TDB - теория TT - TDB, если она загружена.
+func (e *Ephemeris) ConvertTimeScale(date float64, from, to int) (float64, error) {
+	if from == to {
+		return date, nil
+	}
+	tt, err := e.toTT(date, from)
+	if err != nil {
+		return 0, err
+	}
+	return e.fromTT(tt, to)
+}
@@ -9,7 +9,13 @@ const kilometersInAU = 149597870.7
 // secondsInDay количество секунд в сутках.
 const secondsInDay = 24 * 60 * 60
 
+// daysInCentury количество суток в юлианском столетии.
+const daysInCentury = 36525
+
 // julianDate2000 12:00 1 января 2000 года в юлианских днях.
 const julianDate2000 = 2451545
 
 const maxPolynomialDegree = 20
+
+// maxInterpolationDegree наибольшая степень интерполяционного полинома для сегментов типов 9 и 13 (как в SPICE).
+const maxInterpolationDegree = 27
@@ -0,0 +1,204 @@
+package rightround
+
+import (
+	"math"
+	"sort"
+)
+
+// Interval интервал юлианских дат TDB [Start, End].
+type Interval struct {
+	Start, End float64
+}
+
+// Length возвращает длину интервала в сутках.
+func (i Interval) Length() float64 {
+	return i.End - i.Start
+}
+
+// Window упорядоченный набор непересекающихся интервалов юлианских дат.
+// Отдельные моменты времени представляются интервалами нулевой длины.
+// Операции над окнами не изменяют исходные окна и возвращают нормализованный результат.
+type Window []Interval
+
+// NewWindow создаёт окно из произвольного набора интервалов: интервалы упорядочиваются,
+// пересекающиеся и соприкасающиеся объединяются, интервалы с концом раньше начала отбрасываются.
+func NewWindow(intervals ...Interval) Window {
+	sorted := make([]Interval, 0, len(intervals))
+	for _, interval := range intervals {
+		if interval.End >= interval.Start {
+			sorted = append(sorted, interval)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+	var w Window
+	for _, interval := range sorted {
+		if n := len(w); n > 0 && interval.Start <= w[n-1].End {
+			w[n-1].End = math.Max(w[n-1].End, interval.End)
+			continue
+		}
+		w = append(w, interval)
+	}
+	return w
+}
+
+// Contains проверяет, принадлежит ли дата окну.
+func (w Window) Contains(date float64) bool {
+	i := sort.Search(len(w), func(i int) bool {
+		return w[i].End >= date
+	})
+	return i < len(w) && w[i].Start <= date
+}
+
+// Measure возвращает суммарную длину интервалов окна в сутках.
+func (w Window) Measure() float64 {
+	measure := 0.0
+	for _, interval := range w {
+		measure += interval.Length()
+	}
+	return measure
+}
+
+// Bounds возвращает начало первого и конец последнего интервала окна.
+func (w Window) Bounds() (float64, float64, bool) {
+	if len(w) == 0 {
+		return 0, 0, false
+	}
+	return w[0].Start, w[len(w)-1].End, true
+}
+
+// Union возвращает объединение окон.
+func (w Window) Union(other Window) Window {
+	intervals := make([]Interval, 0, len(w)+len(other))
+	intervals = append(intervals, w...)
+	intervals = append(intervals, other...)
+	return NewWindow(intervals...)
+}
+
+// Intersection возвращает пересечение окон.
+func (w Window) Intersection(other Window) Window {
+	var result Window
+	for i, j := 0, 0; i < len(w) && j < len(other); {
+		start := math.Max(w[i].Start, other[j].Start)
+		end := math.Min(w[i].End, other[j].End)
+		if start <= end {
+			result = append(result, Interval{Start: start, End: end})
+		}
+		if w[i].End < other[j].End {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+// Complement возвращает дополнение окна в пределах отрезка [start, end].
+// Как и в SPICE, граничные точки интервалов окна в дополнение входят.
+func (w Window) Complement(start, end float64) Window {
+	var result Window
+	left := start
+	for _, interval := range w {
+		if interval.End < start {
+			continue
+		}
+		if interval.Start > end {
+			break
+		}
+		if interval.Start > left {
+			result = append(result, Interval{Start: left, End: interval.Start})
+		}
+		left = math.Max(left, interval.End)
+	}
+	if left < end {
+		result = append(result, Interval{Start: left, End: end})
+	}
+	return result
+}
+
+// Difference возвращает разность окон: части окна w, не покрытые окном other.
+func (w Window) Difference(other Window) Window {
+	start, end, ok := w.Bounds()
+	if !ok {
+		return nil
+	}
+	var result Window
+	for _, interval := range w.Intersection(other.Complement(start, end)) {
+		// интервалы нулевой длины на границах вычитаемого окна не сохраняются
+		if interval.Length() > 0 || !other.Contains(interval.Start) {
+			result = append(result, interval)
+		}
+	}
+	return result
+}
+
+// Expand расширяет каждый интервал окна на left суток в начале и right суток в конце
+// (отрицательные значения сужают интервалы). Интервалы, ставшие пустыми, удаляются,
+// пересекающиеся после расширения - объединяются.
+func (w Window) Expand(left, right float64) Window {
+	intervals := make([]Interval, 0, len(w))
+	for _, interval := range w {
+		intervals = append(intervals, Interval{Start: interval.Start - left, End: interval.End + right})
+	}
+	return NewWindow(intervals...)
+}
+
+// Contract сужает каждый интервал окна на left суток в начале и right суток в конце.
+func (w Window) Contract(left, right float64) Window {
+	return w.Expand(-left, -right)
+}
+
+// FillGaps заполняет промежутки между интервалами окна, длина которых не превышает gap суток.
+func (w Window) FillGaps(gap float64) Window {
+	var result Window
+	for _, interval := range w {
+		if n := len(result); n > 0 && interval.Start-result[n-1].End <= gap {
+			result[n-1].End = interval.End
+			continue
+		}
+		result = append(result, interval)
+	}
+	return result
+}
+
+// FilterIntervals удаляет из окна интервалы, длина которых меньше minLength суток.
+func (w Window) FilterIntervals(minLength float64) Window {
+	var result Window
+	for _, interval := range w {
+		if interval.Length() >= minLength {
+			result = append(result, interval)
+		}
+	}
+	return result
+}
+
+// span возвращает интервал дат, охватываемый теорией.
+func (t *Theory) span() Interval {
+	start := t.julianDays + t.julianDaysMod
+	return Interval{Start: start, End: start + t.intervalLen*float64(t.nIntervals)}
+}
+
+// Coverage возвращает окно дат, для которых загруженные сегменты описывают объект
+// (тело, систему координат PCK или разность шкал времени) относительно любого центра.
+func (e *Ephemeris) Coverage(object int) Window {
+	var intervals []Interval
+	for _, t := range e.theories {
+		if t.object == object {
+			intervals = append(intervals, t.span())
+		}
+	}
+	return NewWindow(intervals...)
+}
+
+// CoverageRelative возвращает окно дат, для которых загруженные сегменты описывают объект
+// непосредственно относительно заданного центра.
+func (e *Ephemeris) CoverageRelative(object, basis int) Window {
+	var intervals []Interval
+	for _, t := range e.theories {
+		if t.object == object && t.basis == basis {
+			intervals = append(intervals, t.span())
+		}
+	}
+	return NewWindow(intervals...)
+}